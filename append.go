@@ -0,0 +1,41 @@
+package json
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// AppendMarshal appends the JSON encoding of v to dst as Marshal returns it,
+// so that a buffer can be reused between calls. On error dst is returned
+// unchanged, though its spare capacity may have been written.
+func AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	e := &encodeState{
+		buf:        dst,
+		escapeHTML: true,
+	}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return dst, err
+	}
+	return e.buf, nil
+}
+
+// AppendString appends s as a JSON string, escaped as Marshal escapes it.
+func AppendString(dst []byte, s string) []byte {
+	return appendString(dst, s, true, false)
+}
+
+// AppendInt appends i as a JSON number.
+func AppendInt(dst []byte, i int64) []byte {
+	return strconv.AppendInt(dst, i, 10)
+}
+
+// AppendFloat appends f, a float64 or a float32 if bits is 32, as a JSON
+// number formatted as Marshal formats it. NaN and infinities cannot be written
+// and return an *UnsupportedValueError.
+func AppendFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, &UnsupportedValueError{reflect.ValueOf(f), strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+	return appendFloat(dst, f, bits), nil
+}
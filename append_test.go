@@ -0,0 +1,75 @@
+package json
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMarshal(t *testing.T) {
+	values := map[string]interface{}{
+		"struct": encodeSimple{A: "<a>", B: 1},
+		"map":    map[string]interface{}{"b": []int{1}, "a": nil},
+		"string": "x y",
+		"nil":    nil,
+	}
+	for name, v := range values {
+		t.Run(name, func(t *testing.T) {
+			expected, err := json.Marshal(v)
+			require.NoError(t, err)
+			actual, err := AppendMarshal([]byte("prefix "), v)
+			require.NoError(t, err)
+			assert.Equal(t, "prefix "+string(expected), string(actual))
+		})
+	}
+
+	dst := make([]byte, 2, 10)
+	actual, err := AppendMarshal(dst, []interface{}{1, make(chan int)})
+	assert.EqualError(t, err, "json: unsupported type: chan int")
+	assert.Equal(t, dst, actual)
+}
+
+func TestAppendMarshalAllocs(t *testing.T) {
+	v := encodeSimple{A: "a", B: 1}
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		buf, err = AppendMarshal(buf[:0], &v)
+		if err != nil {
+			panic(err)
+		}
+	})
+	assert.LessOrEqual(t, allocs, 1.0, "only the encoder's state is allocated")
+}
+
+func TestAppendScalars(t *testing.T) {
+	b := []byte("[")
+	b = AppendString(b, "<\"é\">\n")
+	b = append(b, ',')
+	b = AppendInt(b, math.MinInt64)
+	for _, f := range []float64{0.1, 1e21, 1e-7, -0.0} {
+		b = append(b, ',')
+		var err error
+		b, err = AppendFloat(b, f, 64)
+		require.NoError(t, err)
+	}
+	b = append(b, ',')
+	b, err := AppendFloat(b, float64(float32(0.1)), 32)
+	require.NoError(t, err)
+	b = append(b, ']')
+
+	expected, err := json.Marshal([]interface{}{"<\"é\">\n", int64(math.MinInt64), 0.1, 1e21, 1e-7, -0.0, float32(0.1)})
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(b))
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, errJ := json.Marshal(f)
+		actual, err := AppendFloat(b, f, 64)
+		assert.EqualError(t, err, errJ.Error())
+		assert.IsType(t, &UnsupportedValueError{}, err)
+		assert.Equal(t, b, actual)
+	}
+}
@@ -0,0 +1,64 @@
+package json
+
+import "unsafe"
+
+// defaultArenaChunk is the size of the chunks an Arena allocates by default.
+const defaultArenaChunk = 64 << 10
+
+// Arena is an experimental chunked allocator for the strings produced while
+// decoding. Rather than allocating every string separately, a Decoder using an
+// Arena copies string contents into large shared chunks, so a document with
+// many strings costs a handful of allocations. The zero value is ready to use.
+//
+// A chunk is only collected once no string decoded into it is reachable, so an
+// Arena suits batch processing where all of the decoded values are discarded
+// together. An Arena must not be used by more than one Decoder at a time.
+type Arena struct {
+	// ChunkSize is the size of each chunk allocated by the Arena, if zero a
+	// default of 64KiB is used.
+	ChunkSize int
+
+	chunk []byte
+}
+
+// UseArena causes the Decoder to allocate decoded strings from a. Passing a
+// nil Arena restores normal allocation.
+func (d *Decoder) UseArena(a *Arena) {
+	d.arena = a
+}
+
+// Free releases the Arena's reference to its current chunk, a new chunk will be
+// allocated by the next string. Strings already decoded remain valid.
+func (a *Arena) Free() {
+	a.chunk = nil
+}
+
+// string returns a string with the contents of b, stored in the Arena.
+func (a *Arena) string(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	size := a.ChunkSize
+	if size <= 0 {
+		size = defaultArenaChunk
+	}
+	if len(b) > size/4 {
+		// large strings would waste most of a chunk
+		return string(b)
+	}
+	if len(b) > cap(a.chunk)-len(a.chunk) {
+		a.chunk = make([]byte, 0, size)
+	}
+	start := len(a.chunk)
+	a.chunk = append(a.chunk, b...)
+	return unsafe.String(&a.chunk[start], len(b))
+}
+
+// makeString returns a string with the contents of b.
+func (d *Decoder) makeString(b []byte) string {
+	d.stats.Strings++
+	if d.arena == nil {
+		return string(b)
+	}
+	return d.arena.string(b)
+}
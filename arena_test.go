@@ -0,0 +1,60 @@
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeArena(t *testing.T) {
+	tests := map[string]string{
+		"string":       `"hello"`,
+		"empty string": `""`,
+		"object":       `{"a":"b","c":["d","e",""],"f":{"g":"h"}}`,
+		"large string": `["` + strings.Repeat("x", defaultArenaChunk) + `","y"]`,
+		"many strings": `["` + strings.Repeat(`abcdefghijklmnopqrstuvwxyz","`, 10000) + `"]`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var expected, actual interface{}
+			require.NoError(t, NewDecoder(strings.NewReader(input)).Decode(&expected))
+			dec := NewDecoder(strings.NewReader(input))
+			dec.UseArena(&Arena{})
+			require.NoError(t, dec.Decode(&actual))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestArenaShare(t *testing.T) {
+	a := &Arena{ChunkSize: 8}
+	s1 := a.string([]byte("ab"))
+	s2 := a.string([]byte("cd"))
+	s3 := a.string([]byte("efghi"))
+	assert.Equal(t, "ab", s1)
+	assert.Equal(t, "cd", s2)
+	assert.Equal(t, "efghi", s3)
+	assert.Equal(t, unsafe.Add(unsafe.Pointer(unsafe.StringData(s1)), 2), unsafe.Pointer(unsafe.StringData(s2)))
+	a.Free()
+	assert.Nil(t, a.chunk)
+	assert.Equal(t, "ab", s1)
+}
+
+func TestDecodeArenaAllocs(t *testing.T) {
+	input := []byte(`["` + strings.Repeat(`abcdefghijklmnopqrstuvwxyz","`, 1000) + `"]`)
+	decode := func(a *Arena) func() {
+		return func() {
+			var v []string
+			dec := NewDecoder(bytes.NewReader(input))
+			dec.UseArena(a)
+			require.NoError(t, dec.Decode(&v))
+		}
+	}
+	allocs := testing.AllocsPerRun(10, decode(nil))
+	arenaAllocs := testing.AllocsPerRun(10, decode(&Arena{}))
+	assert.Less(t, arenaAllocs, allocs-900)
+}
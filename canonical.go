@@ -0,0 +1,179 @@
+package json
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// SetCanonical sets whether the Encoder writes canonical JSON as defined by
+// RFC 8785, the JSON Canonicalization Scheme, so that equal values are always
+// encoded to the same bytes and can be signed or hashed. In canonical JSON
+// object members are sorted, numbers are written as ECMAScript writes them,
+// strings escape as little as possible, and there is no whitespace.
+// SetEscapeHTML, SetEscapeSolidus and SetIndent have no effect while it is set,
+// and numbers that do not fit in a float64 are an error.
+func (enc *Encoder) SetCanonical(on bool) {
+	enc.canonical = on
+}
+
+// Canonicalize returns the JSON value in data as canonical JSON, as
+// Encoder.SetCanonical describes.
+func Canonicalize(data []byte) ([]byte, error) {
+	n, err := parseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	return appendCanonical(nil, n)
+}
+
+// appendCanonical appends n as canonical JSON.
+func appendCanonical(b []byte, n *node) ([]byte, error) {
+	var err error
+	switch {
+	case n.kind == '{':
+		order := make([]int, len(n.keys))
+		units := make([][]uint16, len(n.keys))
+		for i, key := range n.keys {
+			order[i] = i
+			units[i] = utf16.Encode([]rune(key))
+		}
+		// members are sorted by the UTF-16 code units of their keys
+		sort.SliceStable(order, func(i, j int) bool {
+			return lessUTF16(units[order[i]], units[order[j]])
+		})
+		b = append(b, '{')
+		for i, m := range order {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendCanonicalString(b, n.keys[m])
+			b = append(b, ':')
+			if b, err = appendCanonical(b, n.values[m]); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, '}'), nil
+	case n.kind == '[':
+		b = append(b, '[')
+		for i, value := range n.values {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if b, err = appendCanonical(b, value); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, ']'), nil
+	case n.kind == '"':
+		var s string
+		if err = Unmarshal(n.raw, &s); err != nil {
+			return nil, err
+		}
+		return appendCanonicalString(b, s), nil
+	case n.isNumber():
+		f, err := strconv.ParseFloat(string(n.raw), 64)
+		if err != nil {
+			return nil, errors.New("json: number " + string(n.raw) + " cannot be canonicalized")
+		}
+		return appendES6Number(b, f), nil
+	default:
+		return append(b, n.raw...), nil
+	}
+}
+
+// lessUTF16 reports whether the UTF-16 string a sorts before b.
+func lessUTF16(a, b []uint16) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// appendCanonicalString appends s as a JSON string, escaping only what must be
+// escaped and using the short escapes where there are some.
+func appendCanonicalString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		b = append(b, s[start:i]...)
+		switch c {
+		case '\\', '"':
+			b = append(b, '\\', c)
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		}
+		start = i + 1
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// appendES6Number appends the finite f as ECMAScript's Number.prototype.toString
+// writes it, the shortest decimal that reads back as f, in exponent form only
+// if it is very large or small.
+func appendES6Number(b []byte, f float64) []byte {
+	if f == 0 {
+		return append(b, '0')
+	}
+	if math.Signbit(f) {
+		b = append(b, '-')
+		f = -f
+	}
+	// the digits and the exponent n of the leading digit plus one
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(e, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	n, _ := strconv.Atoi(exp)
+	n++
+	k := len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		b = append(b, digits...)
+		for i := k; i < n; i++ {
+			b = append(b, '0')
+		}
+	case 0 < n && n <= 21:
+		b = append(b, digits[:n]...)
+		b = append(b, '.')
+		b = append(b, digits[n:]...)
+	case -6 < n && n <= 0:
+		b = append(b, '0', '.')
+		for i := n; i < 0; i++ {
+			b = append(b, '0')
+		}
+		b = append(b, digits...)
+	default:
+		b = append(b, digits[0])
+		if k > 1 {
+			b = append(b, '.')
+			b = append(b, digits[1:]...)
+		}
+		b = append(b, 'e')
+		if n-1 >= 0 {
+			b = append(b, '+')
+		}
+		b = strconv.AppendInt(b, int64(n-1), 10)
+	}
+	return b
+}
@@ -0,0 +1,107 @@
+package json
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := map[string]struct {
+		input, expected string
+	}{
+		// the examples of RFC 8785
+		"example": {
+			input: `{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"/",
+				"literals": [null, true, false]
+			}`,
+			expected: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		"sorting": {
+			input: `{
+				"\u20ac": "Euro Sign",
+				"\r": "Carriage Return",
+				"\ufb33": "Hebrew Letter Dalet With Dagesh",
+				"1": "One",
+				"\ud83d\ude00": "Emoji: Grinning Face",
+				"\u0080": "Control",
+				"\u00f6": "Latin Small Letter O With Diaeresis"
+			}`,
+			expected: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\"," +
+				"\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		"nested": {
+			input:    ` [ {"b": [], "a": {"d": 1, "c": 2}} , "<\u2028>" ] `,
+			expected: "[{\"a\":{\"c\":2,\"d\":1},\"b\":[]},\"<\u2028>\"]",
+		},
+		"controls": {
+			input:    `"\b\f\n\r\t\u0001\u001F"`,
+			expected: `"\b\f\n\r\t\u0001\u001f"`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := Canonicalize([]byte(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+
+	_, err := Canonicalize([]byte(`[1e400]`))
+	assert.EqualError(t, err, "json: number 1e400 cannot be canonicalized")
+	_, err = Canonicalize([]byte(`[1,]`))
+	assert.EqualError(t, err, "invalid character ']' looking for beginning of value")
+}
+
+func TestAppendES6Number(t *testing.T) {
+	// the examples of RFC 8785 appendix B
+	tests := map[uint64]string{
+		0x0000000000000000: "0",
+		0x8000000000000000: "0",
+		0x0000000000000001: "5e-324",
+		0x8000000000000001: "-5e-324",
+		0x7fefffffffffffff: "1.7976931348623157e+308",
+		0xffefffffffffffff: "-1.7976931348623157e+308",
+		0x4340000000000000: "9007199254740992",
+		0xc340000000000000: "-9007199254740992",
+		0x4430000000000000: "295147905179352830000",
+		0x44b52d02c7e14af5: "9.999999999999997e+22",
+		0x44b52d02c7e14af6: "1e+23",
+		0x44b52d02c7e14af7: "1.0000000000000001e+23",
+		0x444b1ae4d6e2ef4e: "999999999999999700000",
+		0x444b1ae4d6e2ef4f: "999999999999999900000",
+		0x444b1ae4d6e2ef50: "1e+21",
+		0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+		0x3eb0c6f7a0b5ed8d: "0.000001",
+		0x41b3de4355555553: "333333333.3333332",
+		0x41b3de4355555554: "333333333.33333325",
+		0x41b3de4355555555: "333333333.3333333",
+		0x41b3de4355555556: "333333333.3333334",
+		0x41b3de4355555557: "333333333.33333343",
+		0xbecbf647612f3696: "-0.0000033333333333333333",
+		0x43143ff3c1cb0959: "1424953923781206.2",
+	}
+	for bits, expected := range tests {
+		assert.Equal(t, expected, string(appendES6Number(nil, math.Float64frombits(bits))), "%#016x", bits)
+	}
+}
+
+func TestEncoderSetCanonical(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetCanonical(true)
+	enc.SetIndent("", "  ")
+	require.NoError(t, enc.Encode(decodeStruct{A: "<&>", B: 100, Inners: []decodeInner{{X: 1}}}))
+	require.NoError(t, enc.Encode(map[string]float64{"b": 1e21, "a": 0.1}))
+	assert.Error(t, enc.Encode(math.Inf(1)))
+	enc.SetNonFinite(NonFiniteNull)
+	require.NoError(t, enc.Encode(math.Inf(1)))
+	assert.Equal(t, `{"-":0,"A":"<&>","C":false,"G":null,"Inner":{"A":"","X":0,"Y":0},"Inners":[{"A":"","X":1,"Y":0}],"bee":100}`+"\n"+
+		`{"a":0.1,"b":1e+21}`+"\n"+
+		"null\n", buf.String())
+}
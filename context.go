@@ -0,0 +1,34 @@
+package json
+
+import (
+	"context"
+)
+
+// DecodeContext is like Decode, but stops reading the input once ctx is done,
+// returning a *DecodeError that wraps ctx.Err(). This lets a server abandon a
+// slow or adversarial request body when the request is cancelled or its
+// deadline passes. The context is checked before each read from the input and
+// at the start of each object and array, so a Read that blocks is not
+// interrupted; closing the reader, as net/http does with request bodies, ends
+// it. Once decoding has been stopped the Decoder is partway through a value and
+// must be Reset before it is used again.
+func (d *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
+	d.ctx = ctx
+	defer func() { d.ctx = nil }()
+	if err := d.checkContext(); err != nil {
+		return err
+	}
+	return d.Decode(v)
+}
+
+// checkContext returns an error if the context passed to DecodeContext is
+// done.
+func (d *Decoder) checkContext() error {
+	if d.ctx == nil {
+		return nil
+	}
+	if err := d.ctx.Err(); err != nil {
+		return &DecodeError{Offset: d.offset, Err: err}
+	}
+	return nil
+}
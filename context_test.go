@@ -0,0 +1,121 @@
+package json
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cancelReader calls cancel once it has been read from n times.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	r.n--
+	if r.n == 0 {
+		r.cancel()
+	}
+	return r.r.Read(p)
+}
+
+func TestDecodeContext(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, {"b": 2}]} "next"`))
+	var v map[string]interface{}
+	require.NoError(t, dec.DecodeContext(context.Background(), &v))
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{float64(1), map[string]interface{}{"b": float64(2)}}}, v)
+
+	// the context only applies to the one call
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, dec.DecodeContext(ctx, new(string)))
+	cancel()
+	assert.Equal(t, io.EOF, dec.Decode(new(string)))
+}
+
+func TestDecodeContextDone(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		reads    int
+		expected string
+	}{
+		"before reading": {
+			input:    `{"a": 1}`,
+			reads:    0,
+			expected: "json: read error at offset 0: context canceled",
+		},
+		"before object": {
+			input:    `  {"a": 1}`,
+			reads:    1,
+			expected: "json: read error in object at offset 3: context canceled",
+		},
+		"before more input": {
+			input:    `"` + strings.Repeat("x", readChunk) + `"`,
+			reads:    1,
+			expected: "json: read error in string at offset 4096: context canceled",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := &cancelReader{r: strings.NewReader(tt.input), n: tt.reads, cancel: cancel}
+			if tt.reads == 0 {
+				cancel()
+			}
+			var v interface{}
+			err := NewDecoder(r).DecodeContext(ctx, &v)
+			assert.EqualError(t, err, tt.expected)
+			assert.True(t, errors.Is(err, context.Canceled))
+		})
+	}
+}
+
+func TestDecodeContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		// a slow client sends its body a byte at a time
+		for _, b := range []byte(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10`) {
+			time.Sleep(time.Millisecond)
+			if _, err := pw.Write([]byte{b}); err != nil {
+				return
+			}
+		}
+	}()
+	var v []int
+	err := NewDecoder(pr).DecodeContext(ctx, &v)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+}
+
+// contextInt cancels the context of TestDecodeContextNested when it is decoded.
+type contextInt int
+
+func TestDecodeContextNested(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dec := NewDecoder(strings.NewReader(`{"a": 1, "b": [2]}`))
+	dec.RegisterDecoder(reflect.TypeOf(contextInt(0)), func(dec *Decoder, v reflect.Value) error {
+		cancel()
+		return dec.Decode((*int)(v.Addr().Interface().(*contextInt)))
+	})
+	var v struct {
+		A contextInt
+		B []int
+	}
+	err := dec.DecodeContext(ctx, &v)
+	assert.EqualError(t, err, "json: read error in array at offset 15: context canceled")
+	assert.Equal(t, contextInt(1), v.A)
+}
@@ -0,0 +1,44 @@
+package json
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// defaultJSON returns the JSON that a field of type t with the default tag
+// option value is decoded from when its key is absent or null, as in
+// `json:"port,default:8080"`. The value is JSON, except for strings, durations
+// and TextUnmarshalers which are written unquoted, as in
+// `json:"host,default:localhost"`. It cannot contain a comma.
+func defaultJSON(t reflect.Type, value string) []byte {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String && t != numberType || t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return []byte(strconv.Quote(value))
+	}
+	return []byte(value)
+}
+
+// setDefaults decodes the default of each field of the struct pointed to by v
+// that has one and was absent or null, present holds the presence of each field
+// of fields.
+func (d *Decoder) setDefaults(v reflect.Value, fields *structFields, present []byte) error {
+	for i := range fields.list {
+		f := &fields.list[i]
+		if f.defaultValue == nil || present[i] == fieldSet {
+			continue
+		}
+		fv, _, err := structField(v.Elem(), fields, f.name, true)
+		if err != nil {
+			// the field is behind a nil pointer to an unexported embedded
+			// struct, which cannot be allocated, so it is left unset
+			continue
+		}
+		if err := d.valueDecoder(f.defaultValue).Decode(fv.Interface()); err != nil {
+			addErrorContext(err, v.Elem().Type(), f)
+			return err
+		}
+	}
+	return nil
+}
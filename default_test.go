@@ -0,0 +1,163 @@
+package json
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type defaultsInner struct {
+	Level string `json:"level,default:info"`
+}
+
+type defaultsEmbedded struct {
+	Retries int `json:"retries,default:3"`
+}
+
+type defaultsConfig struct {
+	defaultsEmbedded
+	Host    string         `json:"host,default:localhost"`
+	Port    int            `json:"port,omitempty,default:8080"`
+	Ratio   float64        `json:"ratio,default:0.5"`
+	Debug   bool           `json:"debug,default:true"`
+	Name    *string        `json:"name,default:app"`
+	Tags    []string       `json:"tags,default:[\"a\"]"`
+	Limits  map[string]int `json:"limits,default:{\"cpu\":2}"`
+	Timeout time.Duration  `json:"timeout,default:1m30s"`
+	Start   time.Time      `json:"start,default:2024-01-02T03:04:05Z"`
+	Count   Number         `json:"count,default:1.10"`
+	Log     defaultsInner  `json:"log"`
+	Plain   string         `json:"plain"`
+}
+
+func TestDecodeDefaults(t *testing.T) {
+	name := "app"
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	defaults := func() defaultsConfig {
+		n := name
+		return defaultsConfig{
+			defaultsEmbedded: defaultsEmbedded{Retries: 3},
+			Host:             "localhost",
+			Port:             8080,
+			Ratio:            0.5,
+			Debug:            true,
+			Name:             &n,
+			Tags:             []string{"a"},
+			Limits:           map[string]int{"cpu": 2},
+			Timeout:          90 * time.Second,
+			Start:            start,
+			Count:            "1.10",
+		}
+	}
+	tests := map[string]struct {
+		input    string
+		expected func() defaultsConfig
+	}{
+		"absent": {
+			input: `{"log": {}}`,
+			expected: func() defaultsConfig {
+				c := defaults()
+				c.Log.Level = "info"
+				return c
+			},
+		},
+		"null": {
+			input: `{"host": null, "port": null, "debug": null, "name": null, "tags": null, "retries": null, "log": {"level": null}}`,
+			expected: func() defaultsConfig {
+				c := defaults()
+				c.Log.Level = "info"
+				return c
+			},
+		},
+		"set": {
+			input: `{"host": "example.com", "port": 0, "debug": false, "tags": [], "retries": 0, "plain": "x"}`,
+			expected: func() defaultsConfig {
+				c := defaults()
+				c.Host = "example.com"
+				c.Port = 0
+				c.Debug = false
+				c.Tags = []string{}
+				c.Retries = 0
+				c.Plain = "x"
+				return c
+			},
+		},
+		"case insensitive": {
+			input: `{"HOST": "example.com", "Port": 1}`,
+			expected: func() defaultsConfig {
+				c := defaults()
+				c.Host = "example.com"
+				c.Port = 1
+				return c
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual defaultsConfig
+			require.NoError(t, Unmarshal([]byte(tt.input), &actual))
+			assert.Equal(t, tt.expected(), actual)
+
+			var stream defaultsConfig
+			require.NoError(t, NewDecoder(iotest.OneByteReader(strings.NewReader(tt.input))).Decode(&stream))
+			assert.Equal(t, tt.expected(), stream)
+		})
+	}
+}
+
+func TestDecodeDefaultsNotObject(t *testing.T) {
+	actual := defaultsConfig{Host: "x"}
+	require.NoError(t, Unmarshal([]byte(`null`), &actual))
+	assert.Equal(t, defaultsConfig{Host: "x"}, actual)
+
+	var list []defaultsInner
+	require.NoError(t, Unmarshal([]byte(`[{}, {"level": "debug"}]`), &list))
+	assert.Equal(t, []defaultsInner{{Level: "info"}, {Level: "debug"}}, list)
+}
+
+func TestDecodeDefaultsEmbeddedPointer(t *testing.T) {
+	var actual struct {
+		*defaultsEmbedded
+		Host string `json:"host,default:localhost"`
+	}
+	require.NoError(t, Unmarshal([]byte(`{}`), &actual))
+	assert.Nil(t, actual.defaultsEmbedded)
+	assert.Equal(t, "localhost", actual.Host)
+
+	actual.defaultsEmbedded = &defaultsEmbedded{Retries: 1}
+	require.NoError(t, Unmarshal([]byte(`{"host": "x"}`), &actual))
+	assert.Equal(t, &defaultsEmbedded{Retries: 3}, actual.defaultsEmbedded)
+}
+
+func TestDecodeDefaultsMergePatch(t *testing.T) {
+	actual := defaultsInner{Level: "warn"}
+	require.NoError(t, NewDecoder(strings.NewReader(`{}`)).DecodeMergePatch(&actual))
+	assert.Equal(t, defaultsInner{Level: "warn"}, actual)
+
+	require.NoError(t, NewDecoder(strings.NewReader(`{"level": null}`)).DecodeMergePatch(&actual))
+	assert.Equal(t, defaultsInner{}, actual)
+}
+
+func TestDecodeDefaultsInvalid(t *testing.T) {
+	var actual struct {
+		Port int `json:"port,default:http"`
+	}
+	err := Unmarshal([]byte(`{}`), &actual)
+	assert.EqualError(t, err, `invalid character 'h' looking for beginning of value`)
+
+	var typed struct {
+		Port int `json:"port,default:1.5"`
+	}
+	err = Unmarshal([]byte(`{}`), &typed)
+	assert.EqualError(t, err, "json: cannot unmarshal number 1.5 into Go struct field .port of type int")
+}
+
+func TestEncodeIgnoresDefaults(t *testing.T) {
+	b, err := Marshal(defaultsInner{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":""}`, string(b))
+}
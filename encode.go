@@ -0,0 +1,732 @@
+package json
+
+import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// startDetectingCyclesAfter is the nesting of pointers, maps and slices beyond
+// which the encoder starts looking for cycles, checking shallower values would
+// be a waste of time.
+const startDetectingCyclesAfter = 1000
+
+// Marshaler is implemented by types that encode themselves as JSON.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(RawMessage(nil))
+	orderedMapType    = reflect.TypeOf(OrderedMap{})
+	orderedMapPtrType = reflect.TypeOf((*OrderedMap)(nil))
+	urlType           = reflect.TypeOf(url.URL{})
+)
+
+// Marshal returns the JSON encoding of v, using the same rules as
+// encoding/json, except that a url.URL is encoded as the string it formats as.
+func Marshal(v interface{}) ([]byte, error) {
+	return AppendMarshal(nil, v)
+}
+
+// MarshalIndent is like Marshal but indents the output as Encoder.SetIndent
+// describes.
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendIndent(make([]byte, 0, 2*len(b)), b, prefix, indent), nil
+}
+
+type Encoder struct {
+	w            io.Writer
+	escapeHTML   bool
+	indentPrefix string
+	indent       string
+	nonFinite    NonFinite
+	timeLayout   string
+	tagKey       string
+	encoders     map[reflect.Type]func(*Encoder, reflect.Value) error
+	canonical    bool
+	unsortedKeys bool
+	// stringifyLargeInts quotes integers too large for JavaScript.
+	stringifyLargeInts bool
+	// comments writes the comment tags of struct fields.
+	comments bool
+	// escapeSolidus escapes / in strings.
+	escapeSolidus bool
+	// arrays holds the number of elements written to each array opened by
+	// OpenArray, innermost last.
+	arrays []int
+	// pending is output held back until an element is written.
+	pending []byte
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:          w,
+		escapeHTML: true,
+	}
+}
+
+// Encode writes the JSON encoding of v followed by a newline. Nothing is written
+// if v cannot be encoded.
+//
+// If v is a channel that can be received from, or an iter.Seq, it is written as
+// a JSON array one element at a time as each is received, flushing the writer
+// after each if it has a Flush method, so that a consumer such as an HTTP
+// client sees them as they are produced. A channel is read until it is closed.
+// If an element cannot be encoded the error is returned leaving the array
+// incomplete, and any values remaining in the channel are not received.
+func (enc *Encoder) Encode(v interface{}) error {
+	if len(enc.arrays) > 0 {
+		return errors.New("json: Encode called with an array open, use EncodeElement")
+	}
+	rv := reflect.ValueOf(v)
+	if enc.isStream(rv) {
+		return enc.encodeStream(rv)
+	}
+	b, err := enc.marshal(rv, enc.indentPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(append(b, '\n'))
+	return err
+}
+
+// EncodeRaw writes data, which must be a single JSON value, followed by a
+// newline. It is written verbatim, such as a response that was encoded and
+// cached earlier, and is only checked to be valid. Settings such as SetIndent
+// and SetEscapeHTML are not applied, and nothing is written if data is not
+// valid. Like Encode it may not be called while an array is open, use
+// EncodeElement with a RawMessage instead.
+func (enc *Encoder) EncodeRaw(data []byte) error {
+	if len(enc.arrays) > 0 {
+		return errors.New("json: EncodeRaw called with an array open, use EncodeElement")
+	}
+	if err := checkValid(data); err != nil {
+		return err
+	}
+	b := make([]byte, 0, len(data)+1)
+	_, err := enc.w.Write(append(append(b, data...), '\n'))
+	return err
+}
+
+// marshal returns the encoding of v with the Encoder's settings, indented
+// starting with prefix if indentation is on.
+func (enc *Encoder) marshal(v reflect.Value, prefix string) ([]byte, error) {
+	e := &encodeState{
+		escapeHTML:         enc.escapeHTML,
+		nonFinite:          enc.nonFinite,
+		timeLayout:         enc.timeLayout,
+		tagKey:             enc.tagKey,
+		encoders:           enc.encoders,
+		unsortedKeys:       enc.unsortedKeys,
+		stringifyLargeInts: enc.stringifyLargeInts,
+		comments:           enc.comments && !enc.canonical,
+		escapeSolidus:      enc.escapeSolidus,
+	}
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	if enc.canonical {
+		return Canonicalize(e.buf)
+	}
+	if enc.indented() {
+		return appendIndent(make([]byte, 0, 2*len(e.buf)), e.buf, prefix, enc.indent), nil
+	}
+	return e.buf, nil
+}
+
+// indented reports whether the Encoder indents its output.
+func (enc *Encoder) indented() bool {
+	return !enc.canonical && (enc.indentPrefix != "" || enc.indent != "" || enc.comments)
+}
+
+// SetEscapeHTML sets whether the characters <, > and & are escaped in strings,
+// they are escaped by default.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.escapeHTML = on
+}
+
+// SetEscapeSolidus sets whether / is escaped as \/ in strings, so that the
+// output can be embedded in HTML without containing </script>, and matches
+// systems that always escape it. It is not escaped by default.
+func (enc *Encoder) SetEscapeSolidus(on bool) {
+	enc.escapeSolidus = on
+}
+
+// SetIndent makes the Encoder put each element of an array or object on a new
+// line, starting with prefix followed by one copy of indent for each level of
+// nesting. The first line of each value has no prefix. Calling SetIndent("", "")
+// turns indentation off.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.indentPrefix = prefix
+	enc.indent = indent
+}
+
+// SetComments sets whether the Encoder writes JSONC, with the comment struct
+// tag of each field written as // lines above its member, as in
+// `json:"port" comment:"The port to listen on."`. A comment with newlines is
+// written as several lines. As comments need lines of their own each element
+// of an array or object is put on a new line, as SetIndent does, even if
+// indentation is off. Comments are not written by an Encoder set to write
+// canonical JSON, nor inside values written by registered encoders.
+func (enc *Encoder) SetComments(on bool) {
+	enc.comments = on
+}
+
+// SetTagKey makes the Encoder name struct fields and read their options from
+// the given key of their struct tags rather than json, so that a struct tagged
+// for another package, as in `config:"name,omitempty"`, can be encoded without
+// duplicating its tags.
+func (enc *Encoder) SetTagKey(key string) {
+	enc.tagKey = key
+}
+
+// SortKeys sets whether the Encoder writes the members of maps sorted by key,
+// as it does by default, so that the same map is always encoded the same way.
+// Turning it off writes them in Go's map iteration order, which is faster but
+// varies from one call to the next. Struct fields are always written in
+// declaration order.
+func (enc *Encoder) SortKeys(on bool) {
+	enc.unsortedKeys = !on
+}
+
+// NonFinite is how an Encoder writes the floating point values NaN, +Inf and
+// -Inf, which JSON numbers cannot hold.
+type NonFinite int
+
+const (
+	// NonFiniteError fails with an *UnsupportedValueError, this is the
+	// default.
+	NonFiniteError NonFinite = iota
+	// NonFiniteNull writes null.
+	NonFiniteNull
+	// NonFiniteString writes the strings "NaN", "Infinity" and "-Infinity", as
+	// JavaScript spells them.
+	NonFiniteString
+)
+
+// SetNonFinite sets how the Encoder writes NaN and infinite floating point
+// values.
+func (enc *Encoder) SetNonFinite(f NonFinite) {
+	enc.nonFinite = f
+}
+
+// encodeState holds the output and settings of a single Marshal or Encode.
+type encodeState struct {
+	buf        []byte
+	escapeHTML bool
+	nonFinite  NonFinite
+	timeLayout string
+	tagKey     string
+	encoders   map[reflect.Type]func(*Encoder, reflect.Value) error
+	// unsortedKeys leaves the members of maps in iteration order.
+	unsortedKeys       bool
+	stringifyLargeInts bool
+	escapeSolidus      bool
+	// comments writes the comment tags of struct fields ahead of their
+	// members, as / followed by a Go quoted string, for appendIndent to write
+	// as // lines.
+	comments bool
+	ptrLevel int
+	ptrSeen  map[interface{}]struct{}
+}
+
+func (e *encodeState) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	if e.encoders != nil {
+		if fn := e.encoders[v.Type()]; fn != nil {
+			return e.encodeRegistered(v, fn)
+		}
+		if v.Kind() == reflect.Ptr && !v.IsNil() && e.encoders[v.Type().Elem()] != nil {
+			// the type's encoder takes precedence over the pointer's methods
+			return e.encode(v.Elem())
+		}
+	}
+	if e.timeLayout != "" && e.encodeTime(v, "") {
+		return nil
+	}
+	if v.Type() == rawMessageType {
+		return e.encodeRawMessage(v)
+	}
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		e.buf = appendString(e.buf, u.String(), e.escapeHTML, e.escapeSolidus)
+		return nil
+	}
+	if v.Type() == numberType {
+		return e.encodeNumber(v.Interface().(Number))
+	}
+	if isNull(v) {
+		return e.encodeNull(v)
+	}
+	if v.Type() == orderedMapType {
+		return e.encodeOrderedMap(v.Interface().(OrderedMap))
+	}
+	if m, ok := implementation(v, marshalerType); ok && v.Type() != orderedMapPtrType {
+		return e.encodeMarshaler(v, m.(Marshaler))
+	}
+	if m, ok := implementation(v, textMarshalerType); ok {
+		return e.encodeTextMarshaler(v, m.(encoding.TextMarshaler))
+	}
+	if isSQLNull(v.Type()) {
+		return e.encodeSQLNull(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.buf = strconv.AppendBool(e.buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.appendInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.appendUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return e.encodeFloat(v)
+	case reflect.String:
+		e.buf = appendString(e.buf, v.String(), e.escapeHTML, e.escapeSolidus)
+	case reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		return e.withCycleCheck(v, v.Pointer(), func() error {
+			return e.encode(v.Elem())
+		})
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		return e.withCycleCheck(v, v.Pointer(), func() error {
+			return e.encodeMap(v)
+		})
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		// A slice is only a cycle when the same backing array is revisited
+		// with the same length.
+		ptr := struct {
+			ptr interface{}
+			len int
+		}{v.UnsafePointer(), v.Len()}
+		return e.withCycleCheck(v, ptr, func() error {
+			return e.encodeArray(v)
+		})
+	case reflect.Array:
+		return e.encodeArray(v)
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+// implementation returns v as the interface type it, if v or a pointer to v
+// when v is addressable implements it. A nil pointer is not returned, it is
+// encoded as null.
+func implementation(v reflect.Value, it reflect.Type) (interface{}, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	t := v.Type()
+	if t.Implements(it) {
+		if v.Kind() == reflect.Ptr && v.IsNil() || v.Kind() == reflect.Interface && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PointerTo(t).Implements(it) {
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// encodeMarshaler appends the output of the MarshalJSON method of v, which must
+// be valid JSON, compacted.
+func (e *encodeState) encodeMarshaler(v reflect.Value, m Marshaler) error {
+	b, err := m.MarshalJSON()
+	if err == nil {
+		err = checkValid(b)
+	}
+	if err != nil {
+		return &MarshalerError{
+			Type:       v.Type(),
+			Err:        err,
+			sourceFunc: "MarshalJSON",
+		}
+	}
+	e.buf = appendCompact(e.buf, b, e.escapeHTML, e.escapeSolidus)
+	return nil
+}
+
+// encodeRawMessage appends the RawMessage v compacted, as its MarshalJSON method
+// would without converting it to an interface.
+func (e *encodeState) encodeRawMessage(v reflect.Value) error {
+	b := v.Bytes()
+	if b == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	if err := checkValid(b); err != nil {
+		return &MarshalerError{
+			Type:       v.Type(),
+			Err:        err,
+			sourceFunc: "MarshalJSON",
+		}
+	}
+	e.buf = appendCompact(e.buf, b, e.escapeHTML, e.escapeSolidus)
+	return nil
+}
+
+// encodeTextMarshaler appends the output of the MarshalText method of v as a
+// JSON string.
+func (e *encodeState) encodeTextMarshaler(v reflect.Value, m encoding.TextMarshaler) error {
+	b, err := m.MarshalText()
+	if err != nil {
+		return &MarshalerError{
+			Type:       v.Type(),
+			Err:        err,
+			sourceFunc: "MarshalText",
+		}
+	}
+	e.buf = appendString(e.buf, string(b), e.escapeHTML, e.escapeSolidus)
+	return nil
+}
+
+// withCycleCheck calls fn, returning an error instead if the value identified
+// by ptr is already being encoded deep in the call stack.
+func (e *encodeState) withCycleCheck(v reflect.Value, ptr interface{}, fn func() error) error {
+	e.ptrLevel++
+	defer func() { e.ptrLevel-- }()
+	if e.ptrLevel > startDetectingCyclesAfter {
+		if _, ok := e.ptrSeen[ptr]; ok {
+			return &UnsupportedValueError{v, "encountered a cycle via " + v.Type().String()}
+		}
+		if e.ptrSeen == nil {
+			e.ptrSeen = map[interface{}]struct{}{}
+		}
+		e.ptrSeen[ptr] = struct{}{}
+		defer delete(e.ptrSeen, ptr)
+	}
+	return fn()
+}
+
+func (e *encodeState) encodeFloat(v reflect.Value) error {
+	f := v.Float()
+	bits := v.Type().Bits()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		switch e.nonFinite {
+		case NonFiniteNull:
+			e.buf = append(e.buf, "null"...)
+		case NonFiniteString:
+			switch {
+			case math.IsNaN(f):
+				e.buf = append(e.buf, `"NaN"`...)
+			case f > 0:
+				e.buf = append(e.buf, `"Infinity"`...)
+			default:
+				e.buf = append(e.buf, `"-Infinity"`...)
+			}
+		default:
+			return &UnsupportedValueError{v, strconv.FormatFloat(f, 'g', -1, bits)}
+		}
+		return nil
+	}
+	e.buf = appendFloat(e.buf, f, bits)
+	return nil
+}
+
+// appendFloat appends f formatted as encoding/json does, in the shortest
+// representation, using exponents only for very large and very small values.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+func (e *encodeState) encodeBytes(b []byte) {
+	e.buf = append(e.buf, '"')
+	e.buf = base64.StdEncoding.AppendEncode(e.buf, b)
+	e.buf = append(e.buf, '"')
+}
+
+func (e *encodeState) encodeArray(v reflect.Value) error {
+	e.buf = append(e.buf, '[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, ']')
+	return nil
+}
+
+func (e *encodeState) encodeMap(v reflect.Value) error {
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	switch kt := v.Type().Key(); kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !kt.Implements(textMarshalerType) {
+			return &UnsupportedTypeError{v.Type()}
+		}
+	}
+
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return fmt.Errorf("json: encoding error for type %q: %q", v.Type().String(), err.Error())
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	if !e.unsortedKeys {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+	}
+
+	e.buf = append(e.buf, '{')
+	for i, kv := range entries {
+		if i > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = appendString(e.buf, kv.key, e.escapeHTML, e.escapeSolidus)
+		e.buf = append(e.buf, ':')
+		if err := e.encode(kv.val); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
+
+// mapKeyString returns the object key for the map key k, which must be of
+// string or integer kind or implement encoding.TextMarshaler. A string kind is
+// used as it is even if it has a MarshalText method.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return k.String(), nil
+}
+
+func (e *encodeState) encodeStruct(v reflect.Value) error {
+	e.buf = append(e.buf, '{')
+	first := true
+	for _, f := range cachedTypeFields(v.Type(), e.tagKey).list {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) || f.isZero != nil && f.isZero(fv) {
+			continue
+		}
+		start, wasFirst := len(e.buf), first
+		if !first {
+			e.buf = append(e.buf, ',')
+		}
+		first = false
+		if e.comments && f.comment != "" {
+			e.buf = strconv.AppendQuote(append(e.buf, '/'), f.comment)
+		}
+		e.buf = appendString(e.buf, f.name, e.escapeHTML, e.escapeSolidus)
+		e.buf = append(e.buf, ':')
+		if f.format != "" && e.encodeTime(fv, f.format) {
+			continue
+		}
+		if f.quoted {
+			if err := e.encodeQuoted(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		valueStart := len(e.buf)
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+		if f.omitDeep && string(e.buf[valueStart:]) == "{}" {
+			// nothing was left in the value, take back its name too
+			e.buf, first = e.buf[:start], wasFirst
+		}
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
+
+// encodeQuoted encodes v, the value of a field with the string option, inside a
+// JSON string.
+func (e *encodeState) encodeQuoted(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		v = v.Elem()
+	}
+	// values that encode themselves ignore the option
+	if m, ok := implementation(v, marshalerType); ok {
+		return e.encodeMarshaler(v, m.(Marshaler))
+	}
+	if m, ok := implementation(v, textMarshalerType); ok {
+		return e.encodeTextMarshaler(v, m.(encoding.TextMarshaler))
+	}
+	if (v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64) && e.nonFinite != NonFiniteError &&
+		(math.IsInf(v.Float(), 0) || math.IsNaN(v.Float())) {
+		// already null or a string
+		return e.encodeFloat(v)
+	}
+	if v.Kind() == reflect.String {
+		// the string is already escaped, so HTML need not be escaped again
+		e.buf = appendString(e.buf, string(appendString(nil, v.String(), e.escapeHTML, e.escapeSolidus)), false, e.escapeSolidus)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// already quoted, whatever its size
+		e.buf = append(strconv.AppendInt(append(e.buf, '"'), v.Int(), 10), '"')
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf = append(strconv.AppendUint(append(e.buf, '"'), v.Uint(), 10), '"')
+		return nil
+	}
+	e.buf = append(e.buf, '"')
+	if err := e.encode(v); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, '"')
+	return nil
+}
+
+// fieldByIndex returns the field of struct v at index, it returns false if the
+// field is promoted through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+const hex = "0123456789abcdef"
+
+// appendString appends s to b as a JSON string. Invalid UTF-8 is replaced with
+// U+FFFD and U+2028 and U+2029 are escaped so that the output is safe in
+// JavaScript. If escapeHTML is set <, > and & are escaped too, and if
+// escapeSolidus is set / is escaped as \/.
+func appendString(b []byte, s string, escapeHTML, escapeSolidus bool) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!escapeHTML || c != '<' && c != '>' && c != '&') && (!escapeSolidus || c != '/') {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '\\', '"', '/':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,747 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type encodeSimple struct {
+	A string
+	B int `json:"bee"`
+	C bool
+	d string
+	E []int       `json:"-"`
+	F float64     `json:"-,"`
+	G interface{} `json:",omitted"`
+	H string      `json:"h\"quoted"`
+	I *int
+}
+
+type encodeInner struct {
+	X, Y int
+	Z    string `json:"A"`
+}
+
+type encodeOther struct {
+	Y int
+	W int
+}
+
+type encodeEmbedded struct {
+	encodeInner
+	*encodeOther
+	A string
+}
+
+type encodeTaggedEmbed struct {
+	encodeInner `json:"inner"`
+	Name        string
+}
+
+type encodeNamedString string
+type encodeNamedInt int
+
+type encodeInterfaceEmbed struct {
+	error
+	encodeNamedInt
+}
+
+type encodeQuoted struct {
+	I  int               `json:",string"`
+	U  uint8             `json:",string"`
+	F  float64           `json:",string"`
+	B  bool              `json:",string"`
+	S  string            `json:",string"`
+	P  *int              `json:",string"`
+	N  *int              `json:",string"`
+	PP **int             `json:",string"`
+	A  []int             `json:",string"`
+	NS encodeNamedString `json:",string"`
+}
+
+// encodeMarshaler encodes as its own contents.
+type encodeMarshaler string
+
+func (m encodeMarshaler) MarshalJSON() ([]byte, error) {
+	if m == "error" {
+		return nil, errors.New("lol")
+	}
+	return []byte(m), nil
+}
+
+type encodePtrMarshaler struct {
+	A int
+}
+
+func (m *encodePtrMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`"pointer"`), nil
+}
+
+type encodeMarshalers struct {
+	M  encodeMarshaler
+	P  encodePtrMarshaler
+	PP *encodePtrMarshaler
+	N  *encodeMarshaler
+	I  Marshaler
+	Q  encodeMarshaler  `json:",string"`
+	QP *encodeMarshaler `json:",string"`
+}
+
+// encodeText encodes as its own contents in a string.
+type encodeText string
+
+func (m encodeText) MarshalText() ([]byte, error) {
+	if m == "error" {
+		return nil, errors.New("lol")
+	}
+	return []byte("text:" + m), nil
+}
+
+type encodeTextInt int
+
+func (m encodeTextInt) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("int:%d", int(m))), nil
+}
+
+type encodePtrText struct {
+	A int
+}
+
+func (m *encodePtrText) MarshalText() ([]byte, error) {
+	return []byte("<pointer>"), nil
+}
+
+type encodeTexts struct {
+	T  encodeText
+	P  encodePtrText
+	N  *encodeText
+	Q  encodeTextInt `json:",string"`
+	TM encodeTextAndJSON
+}
+
+// encodeTextAndJSON prefers MarshalJSON.
+type encodeTextAndJSON struct{}
+
+func (encodeTextAndJSON) MarshalText() ([]byte, error) {
+	return []byte("text"), nil
+}
+
+func (encodeTextAndJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`"json"`), nil
+}
+
+type encodeTextError struct{}
+
+func (encodeTextError) MarshalText() ([]byte, error) {
+	return nil, errors.New("lol")
+}
+
+type encodeOmit struct {
+	E   string           `json:",omitempty"`
+	EI  int              `json:"ei,omitempty"`
+	EB  bool             `json:",omitempty"`
+	EF  float64          `json:",omitempty"`
+	EP  *int             `json:",omitempty"`
+	ES  []int            `json:",omitempty"`
+	EM  map[string]int   `json:",omitempty"`
+	EA  [0]int           `json:",omitempty"`
+	EX  struct{}         `json:",omitempty"`
+	EN  interface{}      `json:",omitempty"`
+	ZS  []int            `json:",omitzero"`
+	ZT  time.Time        `json:",omitzero"`
+	ZX  struct{ A int }  `json:",omitzero"`
+	ZP  encodeZeroIsOne  `json:",omitzero"`
+	ZPP *encodeZeroIsOne `json:",omitzero"`
+	ZI  isZeroer         `json:",omitzero"`
+	B   int              `json:",omitempty,omitzero"`
+}
+
+// encodeZeroIsOne is zero when N is 1.
+type encodeZeroIsOne struct {
+	N int
+}
+
+func (z *encodeZeroIsOne) IsZero() bool {
+	return z.N == 1
+}
+
+type encodeCycle struct {
+	Next *encodeCycle
+}
+
+func TestMarshal(t *testing.T) {
+	one := 1
+	cycle := &encodeCycle{}
+	cycle.Next = cycle
+	cyclicMap := map[string]interface{}{}
+	cyclicMap["a"] = cyclicMap
+	cyclicSlice := []interface{}{nil}
+	cyclicSlice[0] = cyclicSlice
+
+	tests := map[string]interface{}{
+		"nil":          nil,
+		"true":         true,
+		"false":        false,
+		"int":          -42,
+		"int8":         int8(math.MinInt8),
+		"int64":        int64(math.MinInt64),
+		"uint":         uint(42),
+		"uint64":       uint64(math.MaxUint64),
+		"uintptr":      uintptr(7),
+		"float64":      3.141592654,
+		"float64 int":  float64(1),
+		"float64 -0":   math.Copysign(0, -1),
+		"float64 big":  1e21,
+		"float64 <":    float64(1e20),
+		"float64 tiny": 1e-7,
+		"float64 min":  math.SmallestNonzeroFloat64,
+		"float64 max":  math.MaxFloat64,
+		"float32":      float32(3.14),
+		"float32 big":  float32(1e21),
+		"float32 tiny": float32(1e-7),
+		"NaN":          math.NaN(),
+		"+Inf":         math.Inf(1),
+		"-Inf":         float32(math.Inf(-1)),
+
+		"string":         "hello",
+		"empty string":   "",
+		"escapes":        "quote \" backslash \\ newline \n return \r tab \t backspace \b formfeed \f",
+		"control":        "\x00\x01\x1f\x7f",
+		"html":           "<script>a && b</script>",
+		"unicode":        "I 👏 love 👏 emoji, ümlauts and 漢字",
+		"line separator": "\u2028\u2029",
+		"invalid utf8":   "\xff\xc3\x28 ok \xe2\x82",
+		"named string":   encodeNamedString("named"),
+		"named int":      encodeNamedInt(7),
+
+		"nil slice":     []int(nil),
+		"empty slice":   []int{},
+		"slice":         []interface{}{1, "two", 3.5, nil, true},
+		"array":         [3]int{1, 2, 3},
+		"empty array":   [0]int{},
+		"nested":        [][]string{{"a"}, {}, nil},
+		"bytes":         []byte("hello, world"),
+		"nil bytes":     []byte(nil),
+		"empty bytes":   []byte{},
+		"byte array":    [3]byte{1, 2, 3},
+		"nil pointer":   (*int)(nil),
+		"pointer":       &one,
+		"pointer chain": func() ***int { p := &one; pp := &p; return &pp }(),
+
+		"nil map":       map[string]int(nil),
+		"empty map":     map[string]int{},
+		"map":           map[string]interface{}{"b": 1, "a": []int{1}, "c": map[string]string{"d": "e"}},
+		"map sorted":    map[string]int{"b": 2, "a": 1, "aa": 3, "B": 4, "": 5},
+		"map html key":  map[string]int{"<&>": 1},
+		"int map":       map[int]string{10: "ten", -1: "minus one", 2: "two"},
+		"uint map":      map[uint8]bool{255: true, 0: false},
+		"named key map": map[encodeNamedString]int{"x": 1},
+		"bad key map":   map[bool]int{true: 1},
+		"empty bad key": map[float64]int{},
+
+		"struct":           encodeSimple{A: "a", B: 2, C: true, d: "hidden", E: []int{1}, F: 1.5, G: "g", H: "h", I: &one},
+		"zero struct":      encodeSimple{},
+		"struct pointer":   &encodeSimple{A: "p"},
+		"empty struct":     struct{}{},
+		"anonymous struct": struct{ A, B int }{1, 2},
+		"embedded":         encodeEmbedded{encodeInner{1, 2, "z"}, &encodeOther{3, 4}, "a"},
+		"nil embedded":     encodeEmbedded{encodeInner: encodeInner{1, 2, "z"}},
+		"tagged embed":     encodeTaggedEmbed{encodeInner{1, 2, "z"}, "n"},
+		"interface embed":  encodeInterfaceEmbed{nil, 5},
+		"struct map":       map[string]encodeInner{"k": {1, 2, "3"}},
+		"quoted":           encodeQuoted{I: -1, U: 2, F: 1.5, B: true, S: `"<a>" \`, P: &one, PP: func() **int { p := &one; return &p }(), A: []int{1}, NS: "ns"},
+		"quoted NaN":       encodeQuoted{F: math.NaN()},
+
+		"marshaler":             encodeMarshaler(` {"a" : [1, 2]} `),
+		"marshaler html":        encodeMarshaler(`"<&>\u2028 \u2029"`),
+		"marshaler invalid":     encodeMarshaler(`{`),
+		"marshaler empty":       encodeMarshaler(``),
+		"marshaler trailing":    encodeMarshaler(`1 2`),
+		"marshaler error":       encodeMarshaler(`error`),
+		"nil marshaler":         (*encodeMarshaler)(nil),
+		"marshaler pointer":     func() *encodeMarshaler { m := encodeMarshaler(`true`); return &m }(),
+		"ptr marshaler value":   encodePtrMarshaler{1},
+		"ptr marshaler pointer": &encodePtrMarshaler{1},
+		"marshaler map":         map[string]interface{}{"m": encodeMarshaler(`[]`), "p": encodePtrMarshaler{}},
+		"marshaler slice":       []encodePtrMarshaler{{1}},
+		"marshaler fields":      encodeMarshalers{M: "1", Q: `"q"`, I: encodeMarshaler(`{}`)},
+		"marshaler fields ptr":  &encodeMarshalers{M: "1", Q: `2`, QP: func() *encodeMarshaler { m := encodeMarshaler(`3`); return &m }()},
+		"raw message":           RawMessage(`{ "a": [1, "<"] }`),
+		"nil raw message":       RawMessage(nil),
+		"empty raw message":     RawMessage{},
+		"invalid raw message":   RawMessage(`{"a" 1}`),
+		"raw message pointer":   &RawMessage{'1'},
+		"nil raw message ptr":   (*RawMessage)(nil),
+		"raw message fields":    struct{ A, B RawMessage }{A: RawMessage("[ true ]")},
+
+		"text marshaler":       encodeText("<a>"),
+		"text marshaler error": encodeText("error"),
+		"nil text marshaler":   (*encodeText)(nil),
+		"ptr text marshaler":   &encodePtrText{},
+		"text marshalers":      encodeTexts{T: "t", Q: 3},
+		"text marshalers ptr":  &encodeTexts{T: "t", N: func() *encodeText { t := encodeText("n"); return &t }()},
+		"text map":             map[encodeTextInt]int{2: 2, 1: 1, -3: 3},
+		"text string map":      map[encodeText]int{"b": 1, "a": 2},
+		"text ptr map":         map[*encodeTextInt]int{nil: 1, func() *encodeTextInt { i := encodeTextInt(1); return &i }(): 2},
+		"text map error":       map[encodeTextError]int{{}: 1},
+		"ptr text map key":     map[encodePtrText]int{{}: 1},
+		"time":                 time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC),
+		"time map":             map[time.Time]int{time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC): 1},
+		"netip map":            map[netip.Addr]bool{netip.MustParseAddr("::1"): true, netip.MustParseAddr("10.0.0.1"): false},
+		"netip addr":           netip.MustParseAddr("fe80::1%eth0"),
+		"netip zero addr":      netip.Addr{},
+		"netip prefix":         netip.MustParsePrefix("10.0.0.0/8"),
+		"netip addr port":      netip.MustParseAddrPort("[::1]:80"),
+		"net ip":               net.ParseIP("1.2.3.4"),
+		"nil net ip":           net.IP(nil),
+		"invalid net ip":       net.IP{1},
+
+		"omit zero value": encodeOmit{},
+		"omit empty": encodeOmit{
+			ES:  []int{},
+			EM:  map[string]int{},
+			ZS:  []int{},
+			ZT:  time.Time{}.Add(1),
+			ZX:  struct{ A int }{1},
+			ZP:  encodeZeroIsOne{1},
+			ZPP: &encodeZeroIsOne{1},
+			ZI:  (*encodeZeroIsOne)(nil),
+		},
+		"omit set": &encodeOmit{
+			E: "e", EI: 1, EB: true, EF: 0.5, EP: new(int), ES: []int{1}, EN: 0,
+			ZP:  encodeZeroIsOne{2},
+			ZPP: &encodeZeroIsOne{2},
+			ZI:  &encodeZeroIsOne{2},
+			B:   3,
+		},
+
+		"chan":          make(chan int),
+		"func":          func() {},
+		"complex":       complex(1, 2),
+		"chan in slice": []interface{}{1, make(chan int)},
+		"cycle":         cycle,
+		"cyclic map":    cyclicMap,
+		"cyclic slice":  cyclicSlice,
+	}
+	for name, v := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected, errJ := json.Marshal(v)
+			actual, err := Marshal(v)
+			t.Log("Expected: ", string(expected))
+			t.Log("Actual  : ", string(actual))
+			assert.Equal(t, string(expected), string(actual))
+			if errJ == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, errJ.Error())
+			assert.IsType(t, map[string]error{
+				"*json.UnsupportedTypeError":  &UnsupportedTypeError{},
+				"*json.UnsupportedValueError": &UnsupportedValueError{},
+				"*json.MarshalerError":        &MarshalerError{},
+				"*errors.errorString":         errors.New(""),
+			}[fmt.Sprintf("%T", errJ)], err)
+		})
+	}
+}
+
+type deepTLS struct {
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+type deepServer struct {
+	Host string   `json:"host,omitempty"`
+	TLS  deepTLS  `json:"tls,omitempty,deep"`
+	Peer *deepTLS `json:"peer,omitempty,deep"`
+}
+
+type deepConfig struct {
+	Name    string                 `json:"name,omitempty"`
+	Server  deepServer             `json:"server,omitempty,deep"`
+	Shallow deepTLS                `json:"shallow,omitempty"`
+	Extra   map[string]interface{} `json:"extra,omitempty,deep"`
+	Raw     RawMessage             `json:"raw,omitempty,deep"`
+	Last    deepTLS                `json:"last,omitempty,deep"`
+}
+
+func TestMarshalOmitEmptyDeep(t *testing.T) {
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"empty":         {deepConfig{}, `{"shallow":{}}`},
+		"leaf set":      {deepConfig{Server: deepServer{TLS: deepTLS{Key: "k"}}}, `{"server":{"tls":{"key":"k"}},"shallow":{}}`},
+		"sibling set":   {deepConfig{Server: deepServer{Host: "h"}}, `{"server":{"host":"h"},"shallow":{}}`},
+		"empty pointer": {deepConfig{Server: deepServer{Peer: &deepTLS{}}}, `{"shallow":{}}`},
+		"last set":      {deepConfig{Name: "n", Last: deepTLS{Cert: "c"}}, `{"name":"n","shallow":{},"last":{"cert":"c"}}`},
+		"empty encoding": {
+			deepConfig{Extra: map[string]interface{}{"a": deepTLS{}}, Raw: RawMessage(` { } `)},
+			`{"shallow":{},"extra":{"a":{}}}`,
+		},
+		"only deep": {struct {
+			A deepTLS `json:"a,omitempty,deep"`
+			B deepTLS `json:"b,deep"`
+		}{}, `{"b":{}}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+
+	b, err := MarshalIndent(deepConfig{Name: "n"}, "", "  ")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"n\",\n  \"shallow\": {}\n}", string(b))
+}
+
+func TestMarshalURL(t *testing.T) {
+	u, err := url.Parse("https://user@example.com:8080/a%20b?q=1&r=<x>#frag")
+	require.NoError(t, err)
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"url":         {*u, `"https://user@example.com:8080/a%20b?q=1\u0026r=\u003cx\u003e#frag"`},
+		"pointer":     {u, `"https://user@example.com:8080/a%20b?q=1\u0026r=\u003cx\u003e#frag"`},
+		"nil pointer": {(*url.URL)(nil), `null`},
+		"zero":        {url.URL{}, `""`},
+		"field":       {struct{ U url.URL }{url.URL{Scheme: "http", Host: "a"}}, `{"U":"http://a"}`},
+		"map":         {map[string]*url.URL{"a": {Path: "/p"}}, `{"a":"/p"}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	require.NoError(t, enc.Encode(map[string]string{"html": "<a>"}))
+	require.NoError(t, enc.Encode(1))
+	require.Error(t, enc.Encode(math.NaN()))
+	enc.SetEscapeHTML(false)
+	require.NoError(t, enc.Encode("<a>"))
+	require.NoError(t, enc.Encode(RawMessage(` "<a>" `)))
+	assert.Equal(t, "{\"html\":\"\\u003ca\\u003e\"}\n1\n\"<a>\"\n\"<a>\"\n", buf.String())
+}
+
+type solidusValue struct {
+	Path   string            `json:"a/b"`
+	Quoted string            `json:",string"`
+	Links  map[string]string `json:"links"`
+	Raw    RawMessage        `json:"raw"`
+	Custom solidusCustom     `json:"custom"`
+}
+
+// solidusCustom is always encoded as "c/d".
+type solidusCustom string
+
+func TestEncoderSetEscapeSolidus(t *testing.T) {
+	v := solidusValue{
+		Path:   "</script>",
+		Quoted: "x/y",
+		Links:  map[string]string{"/": "https://example.com/"},
+		Raw:    RawMessage(`{"p": "a/b\/c\\/"}`),
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.RegisterEncoder(reflect.TypeOf(solidusCustom("")), func(enc *Encoder, v reflect.Value) error {
+		return enc.Encode("c/d")
+	})
+	require.NoError(t, enc.Encode(v))
+	enc.SetEscapeSolidus(true)
+	require.NoError(t, enc.Encode(v))
+	require.NoError(t, enc.Encode(&url.URL{Scheme: "https", Host: "example.com", Path: "/a"}))
+	enc.SetEscapeSolidus(false)
+	require.NoError(t, enc.Encode("/"))
+
+	lines := strings.Split(buf.String(), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, `{"a/b":"\u003c/script\u003e","Quoted":"\"x/y\"","links":{"/":"https://example.com/"},"raw":{"p":"a/b\/c\\/"},"custom":"c/d"}`, lines[0])
+	assert.Equal(t, `{"a\/b":"\u003c\/script\u003e","Quoted":"\"x\\\/y\"","links":{"\/":"https:\/\/example.com\/"},"raw":{"p":"a\/b\/c\\\/"},"custom":"c\/d"}`, lines[1])
+	assert.Equal(t, `"https:\/\/example.com\/a"`, lines[2])
+	assert.Equal(t, `"/"`, lines[3])
+
+	var actual solidusValue
+	require.NoError(t, Unmarshal([]byte(lines[1]), &actual))
+	v.Raw, v.Custom = RawMessage(`{"p":"a\/b\/c\\\/"}`), "c/d"
+	assert.Equal(t, v, actual)
+
+	var std solidusValue
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &std))
+	assert.Equal(t, v, std)
+}
+
+func TestEncoderEncodeRaw(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	require.NoError(t, enc.EncodeRaw([]byte(`{ "a": ["<b>"] }`)))
+	require.NoError(t, enc.EncodeRaw([]byte(`1`)))
+	assert.EqualError(t, enc.EncodeRaw([]byte(`[1,]`)), "invalid character ']' looking for beginning of value")
+	assert.EqualError(t, enc.EncodeRaw(nil), "unexpected end of JSON input")
+	enc.OpenArray()
+	assert.EqualError(t, enc.EncodeRaw([]byte(`2`)), "json: EncodeRaw called with an array open, use EncodeElement")
+	require.NoError(t, enc.EncodeElement(RawMessage(` 2 `)))
+	require.NoError(t, enc.CloseArray())
+	assert.Equal(t, "{ \"a\": [\"<b>\"] }\n1\n[\n  2\n]\n", buf.String())
+}
+
+func TestMarshalIndent(t *testing.T) {
+	values := map[string]interface{}{
+		"scalar":       1,
+		"string":       "a, [b]: {c}",
+		"empty":        []interface{}{[]int{}, map[string]int{}, struct{}{}},
+		"nested":       map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": nil}}, "c": "\"]"},
+		"struct":       encodeEmbedded{encodeInner{1, 2, "z"}, &encodeOther{3, 4}, "a"},
+		"nested empty": [][]int{{}, {1}},
+	}
+	indents := map[string][2]string{
+		"none":   {"", ""},
+		"tab":    {"", "\t"},
+		"prefix": {"> ", "  "},
+	}
+	for name, v := range values {
+		for indentName, indent := range indents {
+			t.Run(name+" "+indentName, func(t *testing.T) {
+				expected, err := json.MarshalIndent(v, indent[0], indent[1])
+				require.NoError(t, err)
+				actual, err := MarshalIndent(v, indent[0], indent[1])
+				require.NoError(t, err)
+				assert.Equal(t, string(expected), string(actual))
+			})
+		}
+	}
+
+	_, err := MarshalIndent(math.NaN(), "", "  ")
+	assert.EqualError(t, err, "json: unsupported value: NaN")
+}
+
+func TestEncoderSetIndent(t *testing.T) {
+	var buf, bufJ bytes.Buffer
+	enc := NewEncoder(&buf)
+	encJ := json.NewEncoder(&bufJ)
+	enc.SetIndent(">", " ")
+	encJ.SetIndent(">", " ")
+	for _, v := range []interface{}{map[string][]int{"a": {1, 2}}, "b", []int{}} {
+		require.NoError(t, enc.Encode(v))
+		require.NoError(t, encJ.Encode(v))
+	}
+	enc.SetIndent("", "")
+	encJ.SetIndent("", "")
+	require.NoError(t, enc.Encode([]int{1}))
+	require.NoError(t, encJ.Encode([]int{1}))
+	assert.Equal(t, bufJ.String(), buf.String())
+}
+
+type commentedServer struct {
+	Host string `json:"host" comment:"The host to listen on."`
+	Port int    `json:"port,omitempty" comment:"The port to listen on, 80 if unset."`
+}
+
+type commentedTLS struct {
+	Cert string `json:"cert,omitempty" comment:"Path to the certificate."`
+}
+
+type commentedConfig struct {
+	Name    string            `json:"name" comment:"Name of the service.\nIt appears in logs."`
+	Servers []commentedServer `json:"servers" comment:"Each server started."`
+	Labels  map[string]string `json:"labels,omitempty" comment:"Extra labels."`
+	TLS     commentedTLS      `json:"tls,omitempty,deep" comment:"Not written."`
+	Quote   string            `json:"quote" comment:"A \"*/\" and a \\."`
+	Plain   bool              `json:"plain" comment:""`
+}
+
+func TestEncoderSetComments(t *testing.T) {
+	config := commentedConfig{
+		Name:    "api",
+		Servers: []commentedServer{{Host: "a", Port: 8080}, {Host: "b"}},
+		Labels:  map[string]string{"team": "x"},
+	}
+	tests := map[string]struct {
+		prefix, indent string
+		expected       string
+	}{
+		"indented": {
+			indent: "  ",
+			expected: `{
+  // Name of the service.
+  // It appears in logs.
+  "name": "api",
+  // Each server started.
+  "servers": [
+    {
+      // The host to listen on.
+      "host": "a",
+      // The port to listen on, 80 if unset.
+      "port": 8080
+    },
+    {
+      // The host to listen on.
+      "host": "b"
+    }
+  ],
+  // Extra labels.
+  "labels": {
+    "team": "x"
+  },
+  // A "*/" and a \.
+  "quote": "",
+  "plain": false
+}
+`,
+		},
+		"not indented": {
+			expected: "{\n// Name of the service.\n// It appears in logs.\n\"name\": \"api\",\n// Each server started.\n\"servers\": [\n{\n// The host to listen on.\n\"host\": \"a\",\n" +
+				"// The port to listen on, 80 if unset.\n\"port\": 8080\n},\n{\n// The host to listen on.\n\"host\": \"b\"\n}\n],\n// Extra labels.\n\"labels\": {\n\"team\": \"x\"\n},\n" +
+				"// A \"*/\" and a \\.\n\"quote\": \"\",\n\"plain\": false\n}\n",
+		},
+		"prefix": {
+			prefix:   " ",
+			indent:   "\t",
+			expected: "{\n \t// The host to listen on.\n \t\"host\": \"b\"\n }\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetIndent(tt.prefix, tt.indent)
+			enc.SetComments(true)
+			var v interface{} = config
+			if tt.prefix != "" {
+				v = config.Servers[1]
+			}
+			require.NoError(t, enc.Encode(v))
+			assert.Equal(t, tt.expected, buf.String())
+
+			dec := NewDecoder(&buf)
+			dec.AllowComments()
+			actual := reflect.New(reflect.TypeOf(v))
+			require.NoError(t, dec.Decode(actual.Interface()))
+			assert.Equal(t, v, actual.Elem().Interface())
+		})
+	}
+}
+
+func TestEncoderSetCommentsOff(t *testing.T) {
+	v := commentedServer{Host: "a"}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetComments(true)
+	enc.SetComments(false)
+	require.NoError(t, enc.Encode(v))
+	enc.SetComments(true)
+	enc.SetCanonical(true)
+	require.NoError(t, enc.Encode(v))
+	assert.Equal(t, `{"host":"a"}`+"\n"+`{"host":"a"}`+"\n", buf.String())
+
+	b, err := MarshalIndent(v, "", "  ")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"host\": \"a\"\n}", string(b))
+}
+
+func TestEncoderSetNonFinite(t *testing.T) {
+	type quoted struct {
+		F float64 `json:",string"`
+	}
+	values := []interface{}{
+		[]float64{math.NaN(), math.Inf(1), math.Inf(-1), 1.5},
+		float32(math.Inf(-1)),
+		map[string]interface{}{"a": math.NaN()},
+		quoted{F: math.Inf(1)},
+	}
+	tests := map[NonFinite]string{
+		NonFiniteNull: "[null,null,null,1.5]\nnull\n{\"a\":null}\n{\"F\":null}\n",
+		NonFiniteString: "[\"NaN\",\"Infinity\",\"-Infinity\",1.5]\n\"-Infinity\"\n" +
+			"{\"a\":\"NaN\"}\n{\"F\":\"Infinity\"}\n",
+	}
+	for nonFinite, expected := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetNonFinite(nonFinite)
+		for _, v := range values {
+			require.NoError(t, enc.Encode(v))
+		}
+		assert.Equal(t, expected, buf.String())
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetNonFinite(NonFiniteNull)
+	enc.SetNonFinite(NonFiniteError)
+	assert.EqualError(t, enc.Encode(math.NaN()), "json: unsupported value: NaN")
+	assert.Empty(t, buf.String())
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	w := &mockWriter{}
+	w.Test(t)
+	w.On("Write", []byte("true\n")).Return(0, errors.New("lol")).Once()
+	assert.EqualError(t, NewEncoder(w).Encode(true), "lol")
+	w.AssertExpectations(t)
+}
+
+type configTagged struct {
+	Name  string `config:"name" json:"jsonName"`
+	Port  int    `config:"port,omitempty"`
+	Debug bool   `config:"-"`
+	Other string
+}
+
+func TestEncoderSetTagKey(t *testing.T) {
+	v := configTagged{Name: "n", Debug: true, Other: "o"}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTagKey("config")
+	require.NoError(t, enc.Encode(v))
+	enc.SetTagKey("")
+	require.NoError(t, enc.Encode(v))
+	assert.Equal(t, `{"name":"n","Other":"o"}`+"\n"+`{"jsonName":"n","Port":0,"Debug":true,"Other":"o"}`+"\n", buf.String())
+}
+
+func TestEncoderSortKeys(t *testing.T) {
+	m := map[string]int{}
+	for i := 0; i < 50; i++ {
+		m[fmt.Sprint(i)] = i
+	}
+	sorted, err := Marshal(m)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SortKeys(true)
+	require.NoError(t, enc.Encode(m))
+	assert.Equal(t, string(sorted)+"\n", buf.String())
+
+	buf.Reset()
+	enc.SortKeys(false)
+	require.NoError(t, enc.Encode(m))
+	var actual map[string]int
+	require.NoError(t, Unmarshal(buf.Bytes(), &actual))
+	assert.Equal(t, m, actual)
+	// iteration order of 50 keys is practically never the sorted order
+	assert.NotEqual(t, string(sorted)+"\n", buf.String())
+}
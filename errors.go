@@ -1,10 +1,21 @@
 package json
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// ErrMaxDepthExceeded matches any *MaxDepthError when used with errors.Is.
+var ErrMaxDepthExceeded = errors.New("json: exceeded max depth")
+
+// ErrTooLarge matches any *MaxBytesError when used with errors.Is.
+var ErrTooLarge = errors.New("json: input too large")
+
 type InvalidUnmarshalError struct {
 	Type reflect.Type
 }
@@ -19,25 +30,93 @@ func (i *InvalidUnmarshalError) Error() string {
 type SyntaxError struct {
 	msg    string
 	Offset int64
+	// Line and Column are the 1-based position of the error, the column
+	// counts bytes.
+	Line, Column int64
+	position     bool
+	context      string
 }
 
 func (d *Decoder) syntaxErrorf(format string, a ...interface{}) *SyntaxError {
+	line, column := d.position()
 	return &SyntaxError{
-		msg:    fmt.Sprintf(format, a...),
-		Offset: d.offset,
+		msg:      fmt.Sprintf(format, a...),
+		Offset:   d.offset,
+		Line:     line,
+		Column:   column,
+		position: d.reportPosition,
+		context:  d.errorContext(),
 	}
 }
 
 func (s *SyntaxError) Error() string {
+	if s.position {
+		return fmt.Sprintf("%s at line %d, column %d", s.msg, s.Line, s.Column)
+	}
 	return s.msg
 }
 
+// Context returns the input on the line of the error up to and including the
+// offending byte, followed by a second line with a caret under that byte. At
+// most contextWidth bytes before it are shown, it is empty if the Decoder had
+// read nothing.
+func (s *SyntaxError) Context() string {
+	return s.context
+}
+
+// contextWidth is the most input before a syntax error that its context shows,
+// the Decoder keeps this much input when it fills its buffer.
+const contextWidth = 32
+
+// errorContext returns the context of an error at the last byte read, see
+// SyntaxError.Context.
+func (d *Decoder) errorContext() string {
+	if d.pos == 0 {
+		return ""
+	}
+	at := d.pos - 1
+	start := max(at-contextWidth, 0)
+	if i := bytes.LastIndexByte(d.buf[start:at], '\n'); i >= 0 {
+		start += i + 1
+	}
+	// don't show a partial character
+	for start < at && !utf8.RuneStart(d.buf[start]) {
+		start++
+	}
+
+	var b strings.Builder
+	b.Write(d.buf[start:at])
+	if c := d.buf[at]; c != '\n' && c != '\r' {
+		b.WriteByte(c)
+	} else {
+		b.WriteByte(' ')
+	}
+	b.WriteByte('\n')
+	for _, r := range string(d.buf[start:at]) {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+	return b.String()
+}
+
+// UnmarshalTypeError describes a JSON value that was not appropriate for the
+// Go type it was decoded into. If it happened in a struct field, Struct is the
+// innermost struct type and Field is the path to the field from the outermost
+// struct, as in encoding/json, except that the indexes of arrays and the keys
+// of maps are included, as in items[3].price.
 type UnmarshalTypeError struct {
 	Value  string
 	Type   reflect.Type
 	Offset int64
 	Struct string
 	Field  string
+	// index is the path of array indexes and map keys, such as [3], within the
+	// field that has yet to be added to Field.
+	index string
 }
 
 func (d *Decoder) unmarshalTypeError(value string, t reflect.Type) *UnmarshalTypeError {
@@ -49,5 +128,277 @@ func (d *Decoder) unmarshalTypeError(value string, t reflect.Type) *UnmarshalTyp
 }
 
 func (u *UnmarshalTypeError) Error() string {
+	if u.Struct != "" || u.Field != "" {
+		return "json: cannot unmarshal " + u.Value + " into Go struct field " + u.Struct + "." + u.Field + " of type " + u.Type.String()
+	}
 	return "json: cannot unmarshal " + u.Value + " into Go value of type " + u.Type.String()
 }
+
+// addErrorContext records in err, if it is an *UnmarshalTypeError, that it
+// happened in the field f of the struct type t. As in encoding/json, Struct is
+// the innermost struct and Field is the path of field names from the outermost,
+// including embedded structs. Each name is followed by the indexes and keys
+// recorded by addErrorIndex within the field.
+func addErrorContext(err error, t reflect.Type, f *field) {
+	u, ok := err.(*UnmarshalTypeError)
+	if !ok {
+		return
+	}
+	path := make([]string, 0, len(f.index)+1)
+	for et, i := t, 0; i < len(f.index)-1; i++ {
+		sf := et.Field(f.index[i])
+		path = append(path, sf.Name)
+		if et = sf.Type; et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+	}
+	path = append(path, f.name+u.index)
+	u.index = ""
+	if u.Field == "" {
+		// this is the innermost struct
+		u.Struct = t.Name()
+	} else {
+		path = append(path, u.Field)
+	}
+	u.Field = strings.Join(path, ".")
+}
+
+// addErrorIndex records in err, if it is an *UnmarshalTypeError, that it
+// happened in the element of an array or the member of a map at index.
+// Indexes that are not within a struct field are not reported.
+func addErrorIndex(err error, index string) {
+	if u, ok := err.(*UnmarshalTypeError); ok {
+		u.index = "[" + index + "]" + u.index
+	}
+}
+
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (u *UnsupportedTypeError) Error() string {
+	return "json: unsupported type: " + u.Type.String()
+}
+
+type UnsupportedValueError struct {
+	Value reflect.Value
+	Str   string
+}
+
+func (u *UnsupportedValueError) Error() string {
+	return "json: unsupported value: " + u.Str
+}
+
+// MarshalerError is returned by Marshal when a MarshalJSON method returns an
+// error or invalid JSON.
+type MarshalerError struct {
+	Type       reflect.Type
+	Err        error
+	sourceFunc string
+}
+
+func (e *MarshalerError) Error() string {
+	return "json: error calling " + e.sourceFunc + " for type " + e.Type.String() + ": " + e.Err.Error()
+}
+
+func (e *MarshalerError) Unwrap() error {
+	return e.Err
+}
+
+// MaxDepthError is returned by the Decoder when the input nests objects and
+// arrays deeper than the maximum depth.
+type MaxDepthError struct {
+	MaxDepth int
+	// Offset is the number of bytes read up to and including the opening
+	// bracket that exceeded the maximum depth.
+	Offset int64
+}
+
+func (m *MaxDepthError) Error() string {
+	return fmt.Sprintf("json: exceeded max depth of %d at offset %d", m.MaxDepth, m.Offset)
+}
+
+func (m *MaxDepthError) Is(target error) bool {
+	return target == ErrMaxDepthExceeded
+}
+
+// MaxBytesError is returned by the Decoder when its input is longer than the
+// limit set by SetMaxBytes or LimitedDecoder.
+type MaxBytesError struct {
+	MaxBytes int64
+}
+
+func (m *MaxBytesError) Error() string {
+	return fmt.Sprintf("json: input exceeds %d bytes", m.MaxBytes)
+}
+
+func (m *MaxBytesError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// MaxStringLenError is returned by the Decoder when a string or number literal
+// is longer than the limit set by SetMaxStringLen.
+type MaxStringLenError struct {
+	MaxStringLen int
+	// Offset is the number of bytes read when the limit was exceeded.
+	Offset int64
+}
+
+func (m *MaxStringLenError) Error() string {
+	return fmt.Sprintf("json: literal exceeds %d bytes at offset %d", m.MaxStringLen, m.Offset)
+}
+
+// MaxNumberLenError is returned by the Decoder when a number literal is longer
+// than the limit set by SetMaxNumberLen.
+type MaxNumberLenError struct {
+	MaxNumberLen int
+	// Offset is the number of bytes read when the limit was exceeded.
+	Offset int64
+}
+
+func (m *MaxNumberLenError) Error() string {
+	return fmt.Sprintf("json: number literal exceeds %d bytes at offset %d", m.MaxNumberLen, m.Offset)
+}
+
+// DuplicateKeyError is returned by the Decoder when an object repeats a key and
+// duplicates are disallowed.
+type DuplicateKeyError struct {
+	Key string
+	// Offset is the number of bytes read up to and including the opening
+	// quote of the repeated key.
+	Offset int64
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("json: duplicate object key %q at offset %d", e.Key, e.Offset)
+}
+
+// MissingFieldError is returned by the Decoder when an object decoded into a
+// struct has no key for fields with the required tag option.
+type MissingFieldError struct {
+	// Type is the struct type being decoded into.
+	Type reflect.Type
+	// Fields are the names of the missing fields as they appear in JSON, in
+	// the order they are declared.
+	Fields []string
+	// Offset is the number of bytes read up to and including the closing
+	// brace of the object.
+	Offset int64
+}
+
+func (e *MissingFieldError) Error() string {
+	quoted := make([]string, len(e.Fields))
+	for i, name := range e.Fields {
+		quoted[i] = strconv.Quote(name)
+	}
+	plural := ""
+	if len(e.Fields) > 1 {
+		plural = "s"
+	}
+	return fmt.Sprintf("json: missing required field%s %s for Go value of type %v", plural, strings.Join(quoted, ", "), e.Type)
+}
+
+// UnionError is returned by the Decoder when an object decoded into an
+// interface registered with RegisterUnion has no member naming its type, or
+// names a type that is not registered.
+type UnionError struct {
+	// Type is the interface type being decoded into.
+	Type reflect.Type
+	// Key is the name of the member naming the type.
+	Key string
+	// Value is the unregistered type name, empty if the member is missing.
+	Value string
+}
+
+func (e *UnionError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("json: cannot unmarshal object with no %q member into Go value of type %v", e.Key, e.Type)
+	}
+	return fmt.Sprintf("json: cannot unmarshal object with %q %q into Go value of type %v", e.Key, e.Value, e.Type)
+}
+
+// PointerError is returned by DecodePointer when the JSON Pointer is malformed
+// or does not name a value in the input.
+type PointerError struct {
+	Pointer string
+	msg     string
+}
+
+func (e *PointerError) Error() string {
+	return fmt.Sprintf("json: pointer %q %s", e.Pointer, e.msg)
+}
+
+// PatchError is returned by ApplyPatch when an operation of the patch cannot be
+// applied.
+type PatchError struct {
+	// Index is the 0-based index of the operation in the patch.
+	Index int
+	Op    string
+	msg   string
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("json: patch operation %d (%s): %s", e.Index, e.Op, e.msg)
+}
+
+// LineError is returned by LinesDecoder when a line cannot be read or decoded.
+type LineError struct {
+	// Line is the 1-based number of the line.
+	Line int64
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("json: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by the Decoder when reading its input fails, other
+// than by reaching the end of it, or is stopped by DecodeContext. It wraps the
+// reader's or the context's error.
+type DecodeError struct {
+	// Kind is the kind of JSON value that was being read, one of "object",
+	// "array", "string", "number", "bool" or "null", or empty if the error
+	// happened between values.
+	Kind string
+	// Offset is the number of bytes read before the error.
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("json: read error at offset %d: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("json: read error in %s at offset %d: %v", e.Kind, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// setErrorKind records in err, if it is a *DecodeError without a kind, that it
+// happened while reading the value beginning with c.
+func setErrorKind(err error, c byte) {
+	e, ok := err.(*DecodeError)
+	if !ok || e.Kind != "" {
+		return
+	}
+	switch c {
+	case '{':
+		e.Kind = "object"
+	case '[':
+		e.Kind = "array"
+	case '"', '\'':
+		e.Kind = "string"
+	case 't', 'f':
+		e.Kind = "bool"
+	case 'n':
+		e.Kind = "null"
+	default:
+		e.Kind = "number"
+	}
+}
@@ -0,0 +1,406 @@
+package json
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field is a struct field visible to JSON, possibly promoted from an embedded
+// struct.
+type field struct {
+	name   string
+	tagged bool
+	index  []int
+	typ    reflect.Type
+	opts   tagOptions
+	// quoted is set for fields with the string option whose values are
+	// encoded inside JSON strings.
+	quoted bool
+	// omitEmpty is set for fields with the omitempty option, which are not
+	// encoded when empty.
+	omitEmpty bool
+	// omitDeep is set for fields with the omitempty and deep options, which are
+	// also not encoded when they encode as {}, as a struct does when its own
+	// fields are all omitted.
+	omitDeep bool
+	// isZero is set for fields with the omitzero option, which are not encoded
+	// when it returns true.
+	isZero func(reflect.Value) bool
+	// format is the value of the format option, the layout of a time.Time or
+	// "units" for a time.Duration written as a string.
+	format string
+	// defaultValue is the JSON decoded into the field when its key is absent
+	// or null, from the default option, or nil if it has none.
+	defaultValue []byte
+	// required is set for fields with the required option, whose keys must be
+	// present when decoding.
+	required bool
+	// comment is the comment struct tag, written above the member by an
+	// Encoder with SetComments.
+	comment string
+}
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
+// isEmptyValue reports whether v is empty as the omitempty option means it,
+// false, 0, a nil pointer or interface, an empty array, slice, map or string,
+// a Null that is neither Valid nor Set, or a database/sql nullable type that is
+// not Valid.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		if isSQLNull(v.Type()) {
+			return !v.Field(1).Bool()
+		}
+		return isNull(v) && !v.FieldByName("Valid").Bool() && !v.FieldByName("Set").Bool()
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+// zeroFunc returns the function that reports whether a value of type t is zero
+// as the omitzero option means it, using its IsZero method if it has one.
+func zeroFunc(t reflect.Type) func(reflect.Value) bool {
+	switch {
+	case t.Kind() == reflect.Interface && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			// IsZero can't be called on nil
+			return v.IsNil() ||
+				v.Elem().Kind() == reflect.Ptr && v.Elem().IsNil() ||
+				v.Interface().(isZeroer).IsZero()
+		}
+	case t.Kind() == reflect.Ptr && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			return v.IsNil() || v.Interface().(isZeroer).IsZero()
+		}
+	case t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			return v.Interface().(isZeroer).IsZero()
+		}
+	case reflect.PointerTo(t).Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			if !v.CanAddr() {
+				// copy v so that it has an address
+				v2 := reflect.New(v.Type()).Elem()
+				v2.Set(v)
+				v = v2
+			}
+			return v.Addr().Interface().(isZeroer).IsZero()
+		}
+	}
+	return reflect.Value.IsZero
+}
+
+// structFields is the plan for decoding and encoding a struct type, it is
+// computed once per type and shared by all Decoders and Encoders.
+type structFields struct {
+	list []field
+	// byName maps the exact name of each field to its index in list.
+	byName map[string]int
+	// defaults is set if any field has a default.
+	defaults bool
+	// required is set if any field is required.
+	required bool
+}
+
+// The presence of a field's key in an object being decoded, for defaults and
+// required fields.
+const (
+	fieldAbsent = iota
+	fieldNull
+	fieldSet
+)
+
+// fieldCacheKey is a struct type and the tag key its fields were named by.
+type fieldCacheKey struct {
+	typ    reflect.Type
+	tagKey string
+}
+
+// fieldCache holds the *structFields of each struct type seen so far.
+var fieldCache sync.Map // map[fieldCacheKey]*structFields
+
+// cachedTypeFields returns the fields of the struct type t, see typeFields.
+func cachedTypeFields(t reflect.Type, tagKey string) *structFields {
+	key := fieldCacheKey{typ: t, tagKey: tagKey}
+	if f, ok := fieldCache.Load(key); ok {
+		return f.(*structFields)
+	}
+	list := typeFields(t, tagKey)
+	fields := &structFields{
+		list:   list,
+		byName: make(map[string]int, len(list)),
+	}
+	for i, f := range list {
+		fields.byName[f.name] = i
+		fields.defaults = fields.defaults || f.defaultValue != nil
+		fields.required = fields.required || f.required
+	}
+	f, _ := fieldCache.LoadOrStore(key, fields)
+	return f.(*structFields)
+}
+
+// byKey returns the field named by the object key, an exact match of the name
+// is preferred over a case insensitive one unless caseSensitive is set. It
+// returns nil if there is no such field.
+func (s *structFields) byKey(key string, caseSensitive bool) *field {
+	if i, ok := s.byName[key]; ok {
+		return &s.list[i]
+	}
+	if caseSensitive {
+		return nil
+	}
+	for i := range s.list {
+		if strings.EqualFold(s.list[i].name, key) {
+			return &s.list[i]
+		}
+	}
+	return nil
+}
+
+// typeFields returns the fields of the struct type t that JSON should
+// recognise, following the same visibility rules as Go for embedded structs,
+// with a JSON tag breaking ties between fields at the same depth. The fields are
+// returned in the order they are declared, depth first. Tags are read from the
+// tagKey key of the struct tag, or json if it is empty.
+func typeFields(t reflect.Type, tagKey string) []field {
+	if tagKey == "" {
+		tagKey = "json"
+	}
+	type visit struct {
+		typ   reflect.Type
+		index []int
+	}
+	var (
+		current []visit
+		next    = []visit{{typ: t}}
+		count   = map[reflect.Type]int{}
+		visited = map[reflect.Type]bool{}
+		fields  []field
+	)
+
+	for len(next) > 0 {
+		current, next = next, nil
+		nextCount := map[reflect.Type]int{}
+
+		for _, v := range current {
+			if visited[v.typ] {
+				continue
+			}
+			visited[v.typ] = true
+
+			for i := 0; i < v.typ.NumField(); i++ {
+				sf := v.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous {
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+					// unexported embedded structs may still hold exported fields
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get(tagKey)
+				if tag == "-" {
+					continue
+				}
+				name, opts := parseTag(tag)
+				if !isValidTag(name) {
+					name = ""
+				}
+				index := make([]int, len(v.index)+1)
+				copy(index, v.index)
+				index[len(v.index)] = i
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					f := field{
+						name:   name,
+						tagged: name != "",
+						index:  index,
+						typ:    sf.Type,
+						opts:   opts,
+					}
+					if f.name == "" {
+						f.name = sf.Name
+					}
+					f.omitEmpty = opts.Contains("omitempty")
+					f.omitDeep = f.omitEmpty && opts.Contains("deep")
+					if opts.Contains("omitzero") {
+						f.isZero = zeroFunc(sf.Type)
+					}
+					f.format, _ = opts.Get("format")
+					f.required = opts.Contains("required")
+					f.comment = sf.Tag.Get("comment")
+					if value, ok := opts.Get("default"); ok {
+						f.defaultValue = defaultJSON(sf.Type, value)
+					}
+					if opts.Contains("string") {
+						qt := sf.Type
+						if qt.Name() == "" && qt.Kind() == reflect.Ptr {
+							qt = qt.Elem()
+						}
+						// only strings, numbers and bools may be quoted
+						switch qt.Kind() {
+						case reflect.Bool,
+							reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64,
+							reflect.String:
+							f.quoted = true
+						}
+					}
+					fields = append(fields, f)
+					if count[v.typ] > 1 {
+						// The struct holding this field was embedded more
+						// than once at this depth, record the field twice so
+						// that it has no dominant field below.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, visit{typ: ft, index: index})
+				}
+			}
+		}
+		count = nextCount
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		if fields[i].tagged != fields[j].tagged {
+			return fields[i].tagged
+		}
+		return indexLess(fields[i].index, fields[j].index)
+	})
+
+	// Of the fields sharing a name keep the dominant one, if there is one.
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != name {
+				break
+			}
+		}
+		if f, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, f)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return indexLess(out[i].index, out[j].index)
+	})
+	return out
+}
+
+// dominantField returns the field that hides the others with the same name, the
+// fields must be sorted by depth and then tag presence. There is no dominant
+// field if the shallowest fields are equally tagged.
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+	return fields[0], true
+}
+
+func indexLess(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// tagOptions is the comma separated list of options following the name in a
+// JSON struct tag.
+type tagOptions string
+
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opts)
+}
+
+// Contains reports whether the option name is set.
+func (o tagOptions) Contains(name string) bool {
+	s := string(o)
+	for s != "" {
+		var opt string
+		opt, s = nextTagOption(s)
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the value of the option written name:value.
+func (o tagOptions) Get(name string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var opt string
+		opt, s = nextTagOption(s)
+		if k, v, ok := strings.Cut(opt, ":"); ok && k == name {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// nextTagOption splits the first option from the options s. The value of the
+// format option runs to the end of the tag, so that layouts such as
+// "2,Jan,2006" may contain commas, it must be the last option.
+func nextTagOption(s string) (opt, rest string) {
+	if strings.HasPrefix(s, "format:") {
+		return s, ""
+	}
+	opt, rest, _ = strings.Cut(s, ",")
+	return opt, rest
+}
+
+// isValidTag reports whether s may be used as a field name, names using other
+// characters are ignored as they are by encoding/json.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any
+			// punctuation chars are allowed in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package json
+
+import (
+	"strconv"
+)
+
+// float64pow10 are the powers of ten that a float64 holds exactly.
+var float64pow10 = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11,
+	1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20, 1e21, 1e22,
+}
+
+// parseFloat returns the float nearest to the number literal raw, which has
+// been read by readNumber, as strconv.ParseFloat does with bitSize, so that a
+// float32 is rounded once rather than by way of a float64. An error is returned
+// if raw is out of range.
+//
+// The fast path is deliberately limited to the literals parseFloatExact takes,
+// rather than rounding any mantissa and exponent as Eisel-Lemire does, leaving
+// the general case to strconv.ParseFloat, which uses that algorithm itself.
+// The fallback costs an allocation only for literals longer than 32 bytes,
+// which the conversion to a string cannot keep on the stack.
+func parseFloat(raw []byte, bitSize int) (float64, error) {
+	if bitSize == 64 {
+		if f, ok := parseFloatExact(raw); ok {
+			return f, nil
+		}
+	}
+	return strconv.ParseFloat(string(raw), bitSize)
+}
+
+// parseFloatExact parses the decimal literal raw when its digits and power of
+// ten are both held exactly by a float64, as is the case for most numbers in
+// practice, so that a single correctly rounded multiplication or division gives
+// the nearest float64. It reports false for any other literal.
+func parseFloatExact(raw []byte) (float64, bool) {
+	var (
+		i        int
+		negative bool
+		mantissa uint64
+		digits   int
+		exp      int
+	)
+	if i < len(raw) && raw[i] == '-' {
+		negative = true
+		i++
+	}
+	start := i
+	for ; i < len(raw) && raw[i] >= '0' && raw[i] <= '9'; i++ {
+		if mantissa != 0 || raw[i] != '0' {
+			if digits++; digits > 19 {
+				return 0, false
+			}
+		}
+		mantissa = mantissa*10 + uint64(raw[i]-'0')
+	}
+	if i == start {
+		// NaN or Infinity
+		return 0, false
+	}
+	if i < len(raw) && raw[i] == '.' {
+		for i++; i < len(raw) && raw[i] >= '0' && raw[i] <= '9'; i++ {
+			if mantissa != 0 || raw[i] != '0' {
+				if digits++; digits > 19 {
+					return 0, false
+				}
+			}
+			mantissa = mantissa*10 + uint64(raw[i]-'0')
+			exp--
+		}
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		i++
+		expNegative := false
+		if i < len(raw) && (raw[i] == '-' || raw[i] == '+') {
+			expNegative = raw[i] == '-'
+			i++
+		}
+		e := 0
+		for ; i < len(raw) && raw[i] >= '0' && raw[i] <= '9'; i++ {
+			if e = e*10 + int(raw[i]-'0'); e > 1000 {
+				return 0, false
+			}
+		}
+		if expNegative {
+			e = -e
+		}
+		exp += e
+	}
+	if i != len(raw) || mantissa > 1<<53 {
+		return 0, false
+	}
+
+	f := float64(mantissa)
+	switch {
+	case exp == 0:
+	case exp < 0 && exp >= -22:
+		f /= float64pow10[-exp]
+	case exp > 0 && exp <= 22:
+		f *= float64pow10[exp]
+	case exp > 22 && exp <= 22+15:
+		// move the excess power of ten into the mantissa if it stays exact
+		scale := uint64(float64pow10[exp-22])
+		if mantissa > 1<<53/scale {
+			return 0, false
+		}
+		f = float64(mantissa*scale) * 1e22
+	default:
+		return 0, false
+	}
+	if negative {
+		f = -f
+	}
+	return f, true
+}
@@ -0,0 +1,76 @@
+package json
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFloat(t *testing.T) {
+	literals := []string{
+		"0", "-0", "0.0", "-0.000", "1", "-1", "1.5", "1.50", "0.1", "0.3", "123.456",
+		"1e0", "1E+2", "1e-2", "2.5e-3", "-7e22", "1e22", "1e23", "1e-22", "1e-23",
+		"9007199254740992", "9007199254740993", "-9007199254740993.5",
+		"1234567890123456789", "12345678901234567890", "0.00000000000000000001",
+		"12345678e30", "123456789012345e23", "9e37", "1e37", "9007199254740991e15",
+		"1e308", "1.7976931348623157e308", "2e308", "5e-324", "1e-400", "1e1001", "0e5000",
+		"00.5", "-Infinity", "NaN",
+		// rounds to 1 by way of a float64, whose nearest value is halfway
+		// between two float32s
+		"1.0000000596046447753906251", "16777217", "3.4028235677973366e38", "1e39",
+	}
+	for _, bitSize := range []int{64, 32} {
+		for _, literal := range literals {
+			expected, expectedErr := strconv.ParseFloat(literal, bitSize)
+			actual, err := parseFloat([]byte(literal), bitSize)
+			assert.Equal(t, expectedErr, err, literal)
+			if math.IsNaN(expected) {
+				assert.True(t, math.IsNaN(actual), literal)
+				continue
+			}
+			assert.Equal(t, math.Float64bits(expected), math.Float64bits(actual), "%s: %v != %v", literal, expected, actual)
+		}
+	}
+}
+
+func TestParseFloatRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(320))
+	for i := 0; i < 100000; i++ {
+		var literal []byte
+		if r.Intn(2) == 0 {
+			literal = append(literal, '-')
+		}
+		literal = strconv.AppendUint(literal, r.Uint64()>>r.Intn(64), 10)
+		if r.Intn(2) == 0 {
+			literal = append(literal, '.')
+			literal = strconv.AppendUint(literal, r.Uint64()>>r.Intn(64), 10)
+		}
+		if r.Intn(2) == 0 {
+			literal = append(literal, 'e')
+			literal = strconv.AppendInt(literal, int64(r.Intn(80)-40), 10)
+		}
+		expected, _ := strconv.ParseFloat(string(literal), 64)
+		actual, _ := parseFloat(literal, 64)
+		if math.Float64bits(expected) != math.Float64bits(actual) {
+			t.Fatalf("%s: %v != %v", literal, expected, actual)
+		}
+	}
+}
+
+// BenchmarkParseFloat compares typical literals, which take the exact path.
+func BenchmarkParseFloat(b *testing.B) {
+	literals := [][]byte{[]byte("-19.60924"), []byte("6293.7"), []byte("123"), []byte("-0.5")}
+	b.Run("parseFloat", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = parseFloat(literals[i%len(literals)], 64)
+		}
+	})
+	b.Run("strconv", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = strconv.ParseFloat(string(literals[i%len(literals)]), 64)
+		}
+	})
+}
@@ -1,9 +1,15 @@
 module github.com/brackendawson/json
 
-go 1.14
+go 1.23
 
 require (
 	github.com/intel-go/fastjson v0.0.0-20170329170629-f846ae58a1ab
 	github.com/stretchr/testify v1.6.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
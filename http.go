@@ -0,0 +1,230 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMaxBytes is the request body size limit used by LimitsHandler
+	// when Limits.MaxBytes is zero.
+	DefaultMaxBytes = 1 << 20
+	// DefaultMaxDepth is the nesting limit used by the Decoder unless
+	// SetMaxDepth is called, and by LimitsHandler when Limits.MaxDepth is
+	// zero.
+	DefaultMaxDepth = 1000
+	// DefaultMaxNumberLen is the longest number literal read by the Decoder
+	// unless SetMaxNumberLen is called. It is far longer than any number
+	// that can be decoded without losing precision.
+	DefaultMaxNumberLen = 10000
+)
+
+// Limits configures the checks made by LimitsHandler.
+type Limits struct {
+	// MaxBytes is the largest request body accepted, zero means
+	// DefaultMaxBytes.
+	MaxBytes int64
+	// MaxDepth is the deepest nesting of objects and arrays accepted, zero
+	// means DefaultMaxDepth.
+	MaxDepth int
+	// MaxStringLen is the longest string or number literal accepted in bytes,
+	// zero means no limit.
+	MaxStringLen int
+	// MaxNumberLen is the longest number literal accepted, zero means
+	// DefaultMaxNumberLen and a negative value means no limit.
+	MaxNumberLen int
+}
+
+// LimitsHandler returns a handler that checks the JSON body of each request
+// before calling h. Requests with a body must have a JSON Content-Type, must
+// not exceed the body size, nesting or literal length limits and must hold
+// exactly one well-formed JSON value, which is checked by skipping it with a
+// Decoder rather than decoding it. Requests failing the checks are answered
+// with an RFC 7807 problem response and h is not called. Requests without a
+// body are passed to h unchecked.
+//
+// The body is read in full before h is called, so h sees a replayed copy.
+func LimitsHandler(h http.Handler, l Limits) http.Handler {
+	if l.MaxBytes == 0 {
+		l.MaxBytes = DefaultMaxBytes
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultMaxDepth
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			writeProblem(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, l.MaxBytes+1))
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "error reading body: "+err.Error())
+			return
+		}
+		if int64(len(body)) > l.MaxBytes {
+			writeProblem(w, http.StatusRequestEntityTooLarge, "body exceeds "+strconv.FormatInt(l.MaxBytes, 10)+" bytes")
+			return
+		}
+		d := &Decoder{
+			buf:          body,
+			maxDepth:     l.MaxDepth,
+			maxStringLen: l.MaxStringLen,
+			maxNumberLen: l.MaxNumberLen,
+		}
+		var (
+			depthErr  *MaxDepthError
+			stringErr *MaxStringLenError
+			numberErr *MaxNumberLenError
+		)
+		switch err = d.checkEnd(d.Skip()); {
+		case err == nil:
+		case errors.As(err, &depthErr):
+			writeProblem(w, http.StatusBadRequest, "body exceeds nesting depth of "+strconv.Itoa(depthErr.MaxDepth))
+			return
+		case errors.As(err, &stringErr):
+			writeProblem(w, http.StatusBadRequest, "body exceeds literal length of "+strconv.Itoa(stringErr.MaxStringLen)+" bytes")
+			return
+		case errors.As(err, &numberErr):
+			writeProblem(w, http.StatusBadRequest, "body exceeds number length of "+strconv.Itoa(numberErr.MaxNumberLen)+" bytes")
+			return
+		default:
+			writeProblem(w, http.StatusBadRequest, "malformed JSON: "+err.Error())
+			return
+		}
+
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RequestError is returned by DecodeRequest when the request body cannot be
+// decoded. It wraps the reason, such as a *SyntaxError or a *MaxBytesError.
+type RequestError struct {
+	// Status is the HTTP status code to answer the request with:
+	// http.StatusUnsupportedMediaType, http.StatusRequestEntityTooLarge or
+	// http.StatusBadRequest.
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeRequest decodes the JSON body of r into v. The request must have a JSON
+// Content-Type and its body must hold exactly one JSON value of at most
+// DefaultMaxBytes. The Decoder is then passed to each of opts, in order, which
+// may change its options, for example:
+//
+//	err := json.DecodeRequest(r, &v, (*json.Decoder).DisallowUnknownFields, func(d *json.Decoder) {
+//		d.SetMaxBytes(4 << 10)
+//	})
+//
+// Any error is a *RequestError holding the status to answer the request with.
+// Decoding stops if the request's context is done.
+func DecodeRequest(r *http.Request, v interface{}, opts ...func(*Decoder)) error {
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		return &RequestError{
+			Status: http.StatusUnsupportedMediaType,
+			Err:    errors.New("json: Content-Type must be application/json"),
+		}
+	}
+	body := r.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	d := LimitedDecoder(body, DefaultMaxBytes)
+	d.DisallowTrailingData()
+	for _, opt := range opts {
+		opt(d)
+	}
+	err := d.DecodeContext(r.Context(), v)
+	switch {
+	case err == nil:
+		return nil
+	case err == io.EOF:
+		err = errors.New("json: request body is empty")
+	case errors.Is(err, ErrTooLarge):
+		return &RequestError{Status: http.StatusRequestEntityTooLarge, Err: err}
+	}
+	return &RequestError{Status: http.StatusBadRequest, Err: err}
+}
+
+// EncodeResponse writes v to w as a JSON response with the status code status.
+// The Content-Type header is set to application/json unless it has already been
+// set, and X-Content-Type-Options to nosniff. The header is not written if v
+// cannot be encoded, so that the error can still be answered. Channels and
+// iterators are streamed as Encode does, and an error while streaming them ends
+// a response that has already begun.
+func EncodeResponse(w http.ResponseWriter, status int, v interface{}) error {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return NewEncoder(&responseWriter{w: w, status: status}).Encode(v)
+}
+
+// responseWriter writes the status code of a response on the first Write.
+type responseWriter struct {
+	w           http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.w.WriteHeader(w.status)
+		w.wroteHeader = true
+	}
+	return w.w.Write(p)
+}
+
+// Flush sends what has been written to the client, if the ResponseWriter is an
+// http.Flusher, so that streamed values are sent as they are encoded.
+func (w *responseWriter) Flush() {
+	if f, ok := w.w.(flusher); ok && w.wroteHeader {
+		f.Flush()
+	}
+}
+
+// isJSONContentType reports whether ct names application/json or a media type
+// with the +json structured syntax suffix.
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// writeProblem writes an RFC 7807 problem response.
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	b := []byte(`{"type":"about:blank","title":`)
+	b = appendString(b, http.StatusText(status), false, false)
+	b = append(b, `,"status":`...)
+	b = strconv.AppendInt(b, int64(status), 10)
+	b = append(b, `,"detail":`...)
+	b = appendString(b, detail, false, false)
+	b = append(b, "}\n"...)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,194 @@
+package json
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsHandler(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+		limits      Limits
+		status      int
+		detail      string
+	}{
+		"valid":           {"application/json", `{"a":[1,2]}`, Limits{}, http.StatusOK, ""},
+		"charset":         {"application/json; charset=utf-8", `true`, Limits{}, http.StatusOK, ""},
+		"suffix":          {"application/merge-patch+json", `{}`, Limits{}, http.StatusOK, ""},
+		"no body":         {"", ``, Limits{}, http.StatusOK, ""},
+		"spaced":          {"application/json", " [] \n", Limits{}, http.StatusOK, ""},
+		"text":            {"text/plain", `{}`, Limits{}, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		"no type":         {"", `{}`, Limits{}, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		"at max bytes":    {"application/json", `"abc"`, Limits{MaxBytes: 5}, http.StatusOK, ""},
+		"over max bytes":  {"application/json", `"abcd"`, Limits{MaxBytes: 5}, http.StatusRequestEntityTooLarge, "body exceeds 5 bytes"},
+		"at max depth":    {"application/json", `[{"a":[]}]`, Limits{MaxDepth: 3}, http.StatusOK, ""},
+		"over max depth":  {"application/json", `[{"a":[[]]}]`, Limits{MaxDepth: 3}, http.StatusBadRequest, "body exceeds nesting depth of 3"},
+		"string depth":    {"application/json", `["[[[\"[["]`, Limits{MaxDepth: 1}, http.StatusOK, ""},
+		"default depth":   {"application/json", strings.Repeat("[", 1001) + strings.Repeat("]", 1001), Limits{}, http.StatusBadRequest, "body exceeds nesting depth of 1000"},
+		"raised depth":    {"application/json", strings.Repeat("[", 1500) + strings.Repeat("]", 1500), Limits{MaxDepth: 2000}, http.StatusOK, ""},
+		"truncated depth": {"application/json", `[[[[`, Limits{MaxDepth: 3}, http.StatusBadRequest, "body exceeds nesting depth of 3"},
+		"at max string":   {"application/json", `{"abc":"abc"}`, Limits{MaxStringLen: 3}, http.StatusOK, ""},
+		"over max string": {"application/json", `["abcd"]`, Limits{MaxStringLen: 3}, http.StatusBadRequest, "body exceeds literal length of 3 bytes"},
+		"over max key":    {"application/json", `{"abcd":1}`, Limits{MaxStringLen: 3}, http.StatusBadRequest, "body exceeds literal length of 3 bytes"},
+		"at max number":   {"application/json", `[1e10]`, Limits{MaxNumberLen: 4}, http.StatusOK, ""},
+		"over max number": {"application/json", `[-1e10]`, Limits{MaxNumberLen: 4}, http.StatusBadRequest, "body exceeds number length of 4 bytes"},
+		"default number":  {"application/json", strings.Repeat("1", 10001), Limits{}, http.StatusBadRequest, "body exceeds number length of 10000 bytes"},
+		"no number limit": {"application/json", strings.Repeat("1", 10001), Limits{MaxNumberLen: -1}, http.StatusOK, ""},
+		"whitespace":      {"application/json", ` `, Limits{}, http.StatusBadRequest, "malformed JSON: unexpected end of JSON input"},
+		"truncated":       {"application/json", `{"a":`, Limits{}, http.StatusBadRequest, "malformed JSON: unexpected end of JSON input"},
+		"invalid":         {"application/json", `{"a"}`, Limits{}, http.StatusBadRequest, "malformed JSON: invalid character '}' after object key"},
+		"trailing":        {"application/json", `{} {}`, Limits{}, http.StatusBadRequest, "malformed JSON: invalid character '{' after top-level value"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var called bool
+			h := LimitsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.body, string(body))
+				assert.Equal(t, int64(len(tt.body)), r.ContentLength)
+			}), tt.limits)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.status == http.StatusOK, called)
+			if tt.status == http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			var problem interface{}
+			require.NoError(t, NewDecoder(w.Body).Decode(&problem))
+			assert.Equal(t, map[string]interface{}{
+				"type":   "about:blank",
+				"title":  http.StatusText(tt.status),
+				"status": float64(tt.status),
+				"detail": tt.detail,
+			}, problem)
+		})
+	}
+}
+
+type requestBody struct {
+	Name string `json:"name"`
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+		opts        []func(*Decoder)
+		expected    requestBody
+		status      int
+		err         string
+	}{
+		"valid":          {contentType: "application/json", body: `{"name": "a"}`, expected: requestBody{Name: "a"}},
+		"suffix":         {contentType: "application/merge-patch+json; charset=utf-8", body: ` {"name": "a"} `, expected: requestBody{Name: "a"}},
+		"unknown field":  {contentType: "application/json", body: `{"name": "a", "age": 1}`, expected: requestBody{Name: "a"}},
+		"text":           {contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType, err: "json: Content-Type must be application/json"},
+		"no type":        {body: `{}`, status: http.StatusUnsupportedMediaType, err: "json: Content-Type must be application/json"},
+		"no body":        {contentType: "application/json", status: http.StatusBadRequest, err: "json: request body is empty"},
+		"whitespace":     {contentType: "application/json", body: " \n", status: http.StatusBadRequest, err: "json: request body is empty"},
+		"syntax":         {contentType: "application/json", body: `{"name"}`, status: http.StatusBadRequest, err: "invalid character '}' after object key"},
+		"truncated":      {contentType: "application/json", body: `{"name": "a"`, status: http.StatusBadRequest, err: "unexpected EOF"},
+		"type":           {contentType: "application/json", body: `{"name": 1}`, status: http.StatusBadRequest, err: "json: cannot unmarshal number into Go struct field requestBody.name of type string"},
+		"trailing":       {contentType: "application/json", body: `{} {}`, status: http.StatusBadRequest, err: "invalid character '{' after top-level value"},
+		"default limit":  {contentType: "application/json", body: `"` + strings.Repeat("x", DefaultMaxBytes) + `"`, status: http.StatusRequestEntityTooLarge, err: "json: input exceeds 1048576 bytes"},
+		"at limit":       {contentType: "application/json", body: `{"name": "a"}`, opts: []func(*Decoder){func(d *Decoder) { d.SetMaxBytes(13) }}, expected: requestBody{Name: "a"}},
+		"over limit":     {contentType: "application/json", body: `{"name": "ab"}`, opts: []func(*Decoder){func(d *Decoder) { d.SetMaxBytes(13) }}, status: http.StatusRequestEntityTooLarge, err: "json: input exceeds 13 bytes"},
+		"strict":         {contentType: "application/json", body: `{"name": "a", "age": 1}`, opts: []func(*Decoder){(*Decoder).DisallowUnknownFields}, status: http.StatusBadRequest, err: `json: unknown field "age"`},
+		"strict allowed": {contentType: "application/json", body: `{"name": "a"}`, opts: []func(*Decoder){(*Decoder).DisallowUnknownFields}, expected: requestBody{Name: "a"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			var actual requestBody
+			err := DecodeRequest(r, &actual, tt.opts...)
+			if tt.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, actual)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+			var requestErr *RequestError
+			require.True(t, errors.As(err, &requestErr))
+			assert.Equal(t, tt.status, requestErr.Status)
+		})
+	}
+}
+
+func TestDecodeRequestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	err := DecodeRequest(r, new(requestBody))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestEncodeResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, EncodeResponse(w, http.StatusCreated, requestBody{Name: "<a>"}))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `{"name":"\u003ca\u003e"}`+"\n", w.Body.String())
+
+	// a Content-Type already set is kept
+	w = httptest.NewRecorder()
+	w.Header().Set("Content-Type", "application/merge-patch+json")
+	require.NoError(t, EncodeResponse(w, http.StatusOK, nil))
+	assert.Equal(t, "application/merge-patch+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "null\n", w.Body.String())
+}
+
+func TestEncodeResponseError(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := EncodeResponse(w, http.StatusOK, math.Inf(1))
+	assert.EqualError(t, err, "json: unsupported value: +Inf")
+
+	// nothing was written, so the error can be answered
+	http.Error(w, "error", http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "error\n", w.Body.String())
+}
+
+func TestEncodeResponseStream(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	w := httptest.NewRecorder()
+	require.NoError(t, EncodeResponse(w, http.StatusAccepted, ch))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "[1,2,3]\n", w.Body.String())
+}
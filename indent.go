@@ -0,0 +1,192 @@
+package json
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Compact appends to dst the JSON-encoded src with insignificant whitespace
+// removed. If src is not valid JSON an error is returned and dst is unchanged.
+func Compact(dst *bytes.Buffer, src []byte) error {
+	if err := checkValid(src); err != nil {
+		return err
+	}
+	dst.Write(appendCompact(dst.AvailableBuffer(), src, false, false))
+	return nil
+}
+
+// Indent appends to dst an indented form of the JSON-encoded src, with each
+// element of an array or object on a new line as Encoder.SetIndent describes.
+// Leading whitespace in src is dropped and trailing whitespace is kept, so that
+// Indent may be used within other formatted JSON. If src is not valid JSON an
+// error is returned and dst is unchanged.
+func Indent(dst *bytes.Buffer, src []byte, prefix, indent string) error {
+	if err := checkValid(src); err != nil {
+		return err
+	}
+	value := bytes.TrimRight(src, " \t\r\n")
+	b := appendIndent(dst.AvailableBuffer(), value, prefix, indent)
+	dst.Write(append(b, src[len(value):]...))
+	return nil
+}
+
+// HTMLEscape appends to dst the JSON-encoded src with the characters <, > and &
+// in strings escaped as \u003c, \u003e and \u0026, so that the JSON is safe to
+// embed in HTML <script> tags. U+2028 and U+2029 are escaped too, so that it is
+// safe in JavaScript.
+func HTMLEscape(dst *bytes.Buffer, src []byte) {
+	dst.Grow(len(src))
+	b := dst.AvailableBuffer()
+	start := 0
+	for i, c := range src {
+		if c == '<' || c == '>' || c == '&' {
+			b = append(b, src[start:i]...)
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			start = i + 1
+		}
+		// U+2028 and U+2029 are E2 80 A8 and E2 80 A9
+		if c == 0xE2 && i+2 < len(src) && src[i+1] == 0x80 && src[i+2]&^1 == 0xA8 {
+			b = append(b, src[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[src[i+2]&0xF])
+			start = i + 3
+		}
+	}
+	dst.Write(append(b, src[start:]...))
+}
+
+// appendCompact appends the valid JSON src to dst with insignificant whitespace
+// removed. If escapeHTML is set <, >, &, U+2028 and U+2029 are escaped as
+// HTMLEscape does, and if escapeSolidus is set / is escaped as \/ in strings
+// where it is not already.
+func appendCompact(dst, src []byte, escapeHTML, escapeSolidus bool) []byte {
+	inString, escaped := false, false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '/' && escapeSolidus:
+				dst = append(dst, '\\', c)
+				continue
+			}
+		} else {
+			switch c {
+			case ' ', '\t', '\r', '\n':
+				continue
+			case '"':
+				inString = true
+			}
+		}
+		if escapeHTML {
+			if c == '<' || c == '>' || c == '&' {
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+				continue
+			}
+			if c == 0xE2 && i+2 < len(src) && src[i+1] == 0x80 && src[i+2]&^1 == 0xA8 {
+				dst = append(dst, '\\', 'u', '2', '0', '2', hex[src[i+2]&0xF])
+				i += 2
+				continue
+			}
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// appendIndent appends the valid JSON src to dst with each element of an array
+// or object on a new line, starting with prefix followed by one copy of indent
+// for each level of nesting. Empty arrays and objects are kept on one line.
+// Comments written into src by an Encoder with SetComments are written as //
+// lines.
+func appendIndent(dst, src []byte, prefix, indent string) []byte {
+	var (
+		depth      int
+		needIndent bool
+		inString   bool
+		escaped    bool
+	)
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			dst = append(dst, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		if needIndent && c != ']' && c != '}' {
+			// the array or object is not empty
+			needIndent = false
+			depth++
+			dst = appendNewline(dst, prefix, indent, depth)
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			dst = append(dst, c)
+		case '{', '[':
+			needIndent = true
+			dst = append(dst, c)
+		case ',':
+			dst = append(dst, c)
+			dst = appendNewline(dst, prefix, indent, depth)
+		case ':':
+			dst = append(dst, c, ' ')
+		case '/':
+			// a comment, as / followed by a Go quoted string
+			end := i + 2
+			for src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			text, _ := strconv.Unquote(string(src[i+1 : end+1]))
+			for _, line := range strings.Split(text, "\n") {
+				dst = append(dst, "//"...)
+				if line != "" {
+					dst = append(append(dst, ' '), line...)
+				}
+				dst = appendNewline(dst, prefix, indent, depth)
+			}
+			i = end
+		case '}', ']':
+			if needIndent {
+				needIndent = false
+			} else {
+				depth--
+				dst = appendNewline(dst, prefix, indent, depth)
+			}
+			dst = append(dst, c)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+func appendNewline(dst []byte, prefix, indent string, depth int) []byte {
+	dst = append(dst, '\n')
+	dst = append(dst, prefix...)
+	for i := 0; i < depth; i++ {
+		dst = append(dst, indent...)
+	}
+	return dst
+}
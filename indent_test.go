@@ -0,0 +1,75 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var indentTests = map[string]string{
+	"empty":              ``,
+	"literal":            `true`,
+	"spaced":             " \t\r\n1 \n",
+	"string":             `"a, [b]: {c} \" \\"`,
+	"array":              `[1, "two", null]`,
+	"object":             `{"a": 1, "b": [true, {"c": {}}], "d": []}`,
+	"nested empty":       `[[], {}, [[]], [{}]]`,
+	"spaced containers":  "[ ] { \n}",
+	"html":               `{"<a>": "&"}`,
+	"line separator":     "\"  \"",
+	"invalid":            `lol`,
+	"trailing value":     `1 2`,
+	"truncated":          `{"a": [1,`,
+	"bad escape":         `"\x"`,
+	"trailing comma":     `[1,]`,
+	"whitespace in keys": `{ "a" : 1 , "b" :2 }`,
+}
+
+func TestCompact(t *testing.T) {
+	for name, input := range indentTests {
+		t.Run(name, func(t *testing.T) {
+			expected := bytes.NewBufferString("dst:")
+			errJ := json.Compact(expected, []byte(input))
+			actual := bytes.NewBufferString("dst:")
+			err := Compact(actual, []byte(input))
+			assert.Equal(t, expected.String(), actual.String())
+			if errJ == nil {
+				assert.NoError(t, err)
+				return
+			}
+			// encoding/json doesn't count the offset of errors here
+			assert.EqualError(t, err, errJ.Error())
+		})
+	}
+}
+
+func TestIndent(t *testing.T) {
+	for name, input := range indentTests {
+		t.Run(name, func(t *testing.T) {
+			expected := bytes.NewBufferString("dst:")
+			errJ := json.Indent(expected, []byte(input), ">", "\t")
+			actual := bytes.NewBufferString("dst:")
+			err := Indent(actual, []byte(input), ">", "\t")
+			assert.Equal(t, expected.String(), actual.String())
+			if errJ == nil {
+				assert.NoError(t, err)
+				return
+			}
+			// encoding/json doesn't count the offset of errors here
+			assert.EqualError(t, err, errJ.Error())
+		})
+	}
+}
+
+func TestHTMLEscape(t *testing.T) {
+	for name, input := range indentTests {
+		t.Run(name, func(t *testing.T) {
+			var expected, actual bytes.Buffer
+			json.HTMLEscape(&expected, []byte(input))
+			HTMLEscape(&actual, []byte(input))
+			assert.Equal(t, expected.String(), actual.String())
+		})
+	}
+}
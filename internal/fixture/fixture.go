@@ -0,0 +1,147 @@
+// Package fixture generates JSON documents of different shapes and sizes for
+// benchmarking the decoder.
+package fixture
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+)
+
+// Kind is a shape of document.
+type Kind int
+
+const (
+	// Strings is an array of strings of mixed lengths, some holding escapes
+	// and multi-byte UTF-8.
+	Strings Kind = iota
+	// Numbers is an array of integers and floats, some with exponents.
+	Numbers
+	// Deep is an array of objects and arrays nested MaxDepth deep.
+	Deep
+	// Wide is a single object of many members of different kinds.
+	Wide
+)
+
+// Kinds holds every Kind.
+var Kinds = []Kind{Strings, Numbers, Deep, Wide}
+
+// MaxDepth is the nesting depth of the values in a Deep document.
+const MaxDepth = 100
+
+func (k Kind) String() string {
+	switch k {
+	case Strings:
+		return "strings"
+	case Numbers:
+		return "numbers"
+	case Deep:
+		return "deep"
+	case Wide:
+		return "wide"
+	default:
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Generate returns a document of kind k that is at least size bytes long, and
+// not much longer. The same arguments always return the same document.
+func Generate(k Kind, size int) []byte {
+	g := generator{
+		rand: rand.New(rand.NewSource(int64(k) + 1)),
+	}
+	g.buf.Grow(size + 64)
+	open, close := byte('['), byte(']')
+	if k == Wide {
+		open, close = '{', '}'
+	}
+	g.buf.WriteByte(open)
+	for i := 0; g.buf.Len()+1 < size || i == 0; i++ {
+		if i > 0 {
+			g.buf.WriteByte(',')
+		}
+		switch k {
+		case Strings:
+			g.string()
+		case Numbers:
+			g.number()
+		case Deep:
+			g.nested(MaxDepth)
+		case Wide:
+			g.buf.WriteString(`"key`)
+			g.buf.WriteString(strconv.Itoa(i))
+			g.buf.WriteString(`":`)
+			g.scalar()
+		}
+	}
+	g.buf.WriteByte(close)
+	return g.buf.Bytes()
+}
+
+type generator struct {
+	rand *rand.Rand
+	buf  bytes.Buffer
+}
+
+// stringRunes are the characters strings are made of, weighted towards ASCII.
+var stringRunes = []string{
+	"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m",
+	"n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+	"A", "B", "C", "0", "1", "2", " ", " ", " ", ".", ",", "-",
+	`\n`, `\t`, `\"`, `\\`, `\/`, `é`, `😀`,
+	"é", "ß", "世", "界", "😀",
+}
+
+func (g *generator) string() {
+	g.buf.WriteByte('"')
+	for n := g.rand.Intn(64); n > 0; n-- {
+		g.buf.WriteString(stringRunes[g.rand.Intn(len(stringRunes))])
+	}
+	g.buf.WriteByte('"')
+}
+
+func (g *generator) number() {
+	b := g.buf.AvailableBuffer()
+	switch g.rand.Intn(4) {
+	case 0:
+		b = strconv.AppendInt(b, int64(g.rand.Intn(1000)), 10)
+	case 1:
+		b = strconv.AppendInt(b, g.rand.Int63()-1<<62, 10)
+	case 2:
+		b = strconv.AppendFloat(b, g.rand.NormFloat64()*1000, 'f', g.rand.Intn(6), 64)
+	default:
+		b = strconv.AppendFloat(b, g.rand.NormFloat64()*1e100, 'e', -1, 64)
+	}
+	g.buf.Write(b)
+}
+
+// scalar writes a string, number, bool or null.
+func (g *generator) scalar() {
+	switch g.rand.Intn(4) {
+	case 0:
+		g.string()
+	case 1:
+		g.number()
+	case 2:
+		g.buf.WriteString([]string{"true", "false"}[g.rand.Intn(2)])
+	default:
+		g.buf.WriteString("null")
+	}
+}
+
+// nested writes alternating objects and arrays depth deep.
+func (g *generator) nested(depth int) {
+	if depth == 0 {
+		g.scalar()
+		return
+	}
+	if depth%2 == 0 {
+		g.buf.WriteString(`{"a":`)
+		g.nested(depth - 1)
+		g.buf.WriteByte('}')
+		return
+	}
+	g.buf.WriteByte('[')
+	g.nested(depth - 1)
+	g.buf.WriteByte(']')
+}
@@ -0,0 +1,55 @@
+package fixture
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, k := range Kinds {
+		for _, size := range []int{0, 100, 10000, 1 << 20} {
+			t.Run(k.String(), func(t *testing.T) {
+				b := Generate(k, size)
+				require.True(t, json.Valid(b), "%s", b)
+				assert.GreaterOrEqual(t, len(b), size)
+				assert.Less(t, len(b), size+1000)
+				assert.Equal(t, b, Generate(k, size), "not deterministic")
+
+				var v interface{}
+				require.NoError(t, json.Unmarshal(b, &v))
+				switch k {
+				case Wide:
+					assert.IsType(t, map[string]interface{}{}, v)
+				default:
+					assert.IsType(t, []interface{}{}, v)
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateDeep(t *testing.T) {
+	var v interface{}
+	require.NoError(t, json.Unmarshal(Generate(Deep, 0), &v))
+	depth := 0
+	for {
+		switch x := v.(type) {
+		case []interface{}:
+			v = x[0]
+		case map[string]interface{}:
+			v = x["a"]
+		default:
+			assert.Equal(t, MaxDepth+1, depth)
+			return
+		}
+		depth++
+	}
+}
+
+func TestKindString(t *testing.T) {
+	assert.Equal(t, "numbers", Numbers.String())
+	assert.Equal(t, "Kind(9)", Kind(9).String())
+}
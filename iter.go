@@ -0,0 +1,53 @@
+package json
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// errStopIteration ends DecodeArray when the consumer of an iterator stops.
+var errStopIteration = errors.New("json: iteration stopped")
+
+// Values returns an iterator over the successive top-level values read by dec,
+// each decoded into a T. Iteration ends when the input is exhausted, or after
+// the first error is yielded.
+func Values[T any](dec *Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			var v T
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// DecodeSeq returns an iterator over the elements of the next value read by dec,
+// which must be an array, each decoded into a T. Only one element is held in
+// memory at once. Iteration ends at the end of the array, or after the first
+// error is yielded. If the loop is broken the Decoder is left inside the array,
+// where Decode and More may continue reading elements. Use Values to iterate
+// over a stream of top-level values.
+func DecodeSeq[T any](dec *Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := dec.DecodeArray(func(dec *Decoder) error {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			if !yield(v, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && err != errStopIteration {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
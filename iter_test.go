@@ -0,0 +1,114 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValues(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []interface{}
+		err      error
+	}{
+		"empty":      {input: ``},
+		"whitespace": {input: " \n\t "},
+		"one":        {input: `1`, expected: []interface{}{float64(1)}},
+		"concatenated": {
+			input:    `{"a":1}[true]"s"`,
+			expected: []interface{}{map[string]interface{}{"a": float64(1)}, []interface{}{true}, "s"},
+		},
+		"separated": {
+			input:    "1\n2 3\t null \n",
+			expected: []interface{}{float64(1), float64(2), float64(3), nil},
+		},
+		"error": {
+			input:    `1 ~ 2`,
+			expected: []interface{}{float64(1)},
+			err:      &SyntaxError{msg: "invalid character '~' looking for beginning of value", Offset: 3, Line: 1, Column: 3, context: "1 ~\n  ^"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				values []interface{}
+				err    error
+			)
+			for v, vErr := range Values[interface{}](NewDecoder(strings.NewReader(tt.input))) {
+				if vErr != nil {
+					err = vErr
+					continue
+				}
+				values = append(values, v)
+			}
+			assert.Equal(t, tt.expected, values)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValuesBreak(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`1 2 3`))
+	for v, err := range Values[float64](dec) {
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), v)
+		break
+	}
+	var v float64
+	assert.NoError(t, dec.Decode(&v))
+	assert.Equal(t, float64(2), v)
+}
+
+func TestDecodeSeq(t *testing.T) {
+	type order struct {
+		ID    int
+		Items []string
+	}
+	tests := map[string]struct {
+		input    string
+		expected []order
+		err      string
+	}{
+		"empty":     {input: `[]`},
+		"orders":    {input: `[{"ID": 1, "Items": ["a"]}, {"ID": 2}]`, expected: []order{{ID: 1, Items: []string{"a"}}, {ID: 2}}},
+		"not array": {input: `{"ID": 1}`, err: "invalid character '{' looking for beginning of array"},
+		"bad type":  {input: `[{"ID": 1}, 2]`, expected: []order{{ID: 1}}, err: "json: cannot unmarshal number into Go value of type json.order"},
+		"truncated": {input: `[{"ID": 1},`, expected: []order{{ID: 1}}, err: "unexpected EOF"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				orders []order
+				err    error
+			)
+			for o, oErr := range DecodeSeq[order](NewDecoder(strings.NewReader(tt.input))) {
+				if oErr != nil {
+					err = oErr
+					continue
+				}
+				orders = append(orders, o)
+			}
+			assert.Equal(t, tt.expected, orders)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestDecodeSeqBreak(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[1, 2, 3] 4`))
+	for v, err := range DecodeSeq[int](dec) {
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		break
+	}
+	var v int
+	assert.True(t, dec.More())
+	assert.NoError(t, dec.Decode(&v))
+	assert.Equal(t, 2, v)
+}
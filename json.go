@@ -5,10 +5,22 @@
 package json
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 var (
@@ -26,6 +38,7 @@ var (
 		'r':  '\r',
 		't':  '\t',
 		'\\': '\\',
+		'/':  '/',
 		'"':  '"',
 	}
 	boolMap = map[byte]bool{
@@ -39,15 +52,329 @@ var (
 	}
 )
 
+var errUnreadByte = errors.New("json: no byte to unread")
+
+var (
+	float64Type            = reflect.TypeOf(float64(0))
+	int64Type              = reflect.TypeOf(int64(0))
+	mapStringInterfaceType = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+const (
+	// maxInterned is the number of distinct object keys and object shapes a
+	// Decoder remembers.
+	maxInterned = 1024
+	// maxInternLen is the length of the longest object key a Decoder interns.
+	maxInternLen = 64
+)
+
+// maxScratch is the capacity of the largest string buffer a Decoder keeps for
+// reuse, so that one huge string doesn't pin its memory.
+const maxScratch = 64 << 10
+
+// readChunk is the amount of input the Decoder asks its reader for at once.
+const readChunk = 4096
+
+// teeChunk is the amount of consumed input the Decoder holds before copying it
+// to the TeeRaw writer.
+const teeChunk = 4096
+
+// A Decoder reads and decodes JSON values from an input stream. The zero value
+// has no input, Reset must be called before it is used.
 type Decoder struct {
-	in     *bufio.Reader
-	offset int64
+	in                    io.Reader
+	buf                   []byte
+	pos                   int
+	readErr               error
+	offset                int64
+	strictNumbers         bool
+	disallowUnknownFields bool
+	matchCaseSensitive    bool
+	disallowDuplicateKeys bool
+	allowComments         bool
+	disallowTrailingData  bool
+	mergePatch            bool
+	json5                 bool
+	allowNonFinite        bool
+	invalidUTF8           InvalidUTF8
+	timeLayout            string
+	tagKey                string
+	decoders              map[reflect.Type]func(*Decoder, reflect.Value) error
+	tee                   io.Writer
+	teeing                bool
+	teeBuf                []byte
+	teeErr                error
+	arena                 *Arena
+	keys                  map[string]string
+	shapes                map[string]int
+	objects               []string
+	eofIn                 string
+	capturing             bool
+	raw                   []byte
+	scratch               []byte
+	num                   []byte
+	tokenState            int
+	tokenStack            []int
+	line                  int64
+	lineStart             int64
+	prevLineStart         int64
+	reportPosition        bool
+	maxDepth              int
+	maxBytes              int64
+	maxStringLen          int
+	maxNumberLen          int
+	orderedObjects        bool
+	rawNumber             bool
+	allowQuotedInts       bool
+	presence              map[string]bool
+	presencePath          []string
+	ctx                   context.Context
+	timings               *Timings
+	stats                 Stats
+	valueAt               int64
+	depth                 int
 }
 
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
-		in: bufio.NewReader(r),
+		in: r,
+	}
+}
+
+// LimitedDecoder returns a new Decoder that reads from r and returns a
+// *MaxBytesError, which matches ErrTooLarge, if r holds more than maxBytes, as
+// if SetMaxBytes had been called. This takes the place of
+// wrapping r in an http.MaxBytesReader and mapping its error.
+func LimitedDecoder(r io.Reader, maxBytes int64) *Decoder {
+	d := NewDecoder(r)
+	d.SetMaxBytes(maxBytes)
+	return d
+}
+
+// Reset discards the Decoder's state and makes it read from r, as if it was
+// newly returned by NewDecoder but reusing its buffers. Options such as
+// StrictNumbers and SetMaxDepth are kept. Any buffered input not yet decoded is
+// lost.
+func (d *Decoder) Reset(r io.Reader) {
+	if d.in == nil {
+		// the buffer is the caller's input
+		d.buf = nil
+	}
+	d.in = r
+	d.buf, d.pos, d.readErr = d.buf[:0], 0, nil
+	d.offset = 0
+	d.line, d.lineStart, d.prevLineStart = 0, 0, 0
+	d.depth = 0
+	d.stats, d.valueAt = Stats{}, 0
+	d.eofIn = ""
+	d.tokenState = tokenTopValue
+	d.tokenStack = d.tokenStack[:0]
+	d.teeBuf = d.teeBuf[:0]
+	d.teeErr = nil
+}
+
+// Unmarshal decodes the JSON value in data into the value pointed to by v. It is
+// an error for data to hold anything other than whitespace after the value.
+//
+// As in encoding/json, a string is decoded into a TextUnmarshaler, such as a
+// netip.Addr or net.IP, by its UnmarshalText method. A url.URL may also be
+// decoded from a string, as url.Parse parses it.
+//
+// A struct field with the default tag option, as in `json:"port,default:8080"`,
+// is decoded from its default when its key is absent from the object or null.
+// One with the required option, as in `json:"host,required"`, causes a
+// *MissingFieldError if its key is absent. DecodeMergePatch does not use
+// defaults or check required fields.
+func Unmarshal(data []byte, v interface{}) error {
+	d := &Decoder{
+		buf: data,
+	}
+	err := d.unmarshal(v)
+	if _, ok := err.(*SyntaxError); err != nil && !ok {
+		// encoding/json reports a syntax error anywhere in data before an
+		// error decoding it
+		if syntaxErr := checkValid(data); syntaxErr != nil {
+			return syntaxErr
+		}
+	}
+	return err
+}
+
+// UnmarshalAs decodes the JSON value in data into a new T and returns it, as
+// Unmarshal does. On error, as much of the value as was decoded is returned.
+func UnmarshalAs[T any](data []byte) (T, error) {
+	var v T
+	err := Unmarshal(data, &v)
+	return v, err
+}
+
+// unmarshal decodes the only value in the Decoder's input into v.
+func (d *Decoder) unmarshal(v interface{}) error {
+	return d.checkEnd(d.Decode(v))
+}
+
+// Valid reports whether data is a single valid JSON value, surrounded by
+// nothing but whitespace.
+func Valid(data []byte) bool {
+	return checkValid(data) == nil
+}
+
+// ValidReader reads r to its end and reports whether it held a single valid
+// JSON value surrounded by nothing but whitespace. It returns nil if so, the
+// error Unmarshal would return if not, or the error from reading r. Nothing is
+// decoded, and only a small buffer of r is held at once.
+func ValidReader(r io.Reader) error {
+	d := NewDecoder(r)
+	return d.checkEnd(d.Skip())
+}
+
+// checkValid returns the error Unmarshal would for data, without decoding it.
+func checkValid(data []byte) error {
+	d := &Decoder{
+		buf: data,
+	}
+	return d.checkEnd(d.Skip())
+}
+
+// checkEnd returns err, the result of reading the only value in the Decoder's
+// input, or an error if anything but whitespace follows the value. Running out
+// of input is reported as a SyntaxError, as encoding/json does.
+func (d *Decoder) checkEnd(err error) error {
+	if err == nil {
+		err = d.readEnd()
 	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if d.eofIn != "" {
+			// encoding/json treats the end of input as a space here
+			return d.syntaxErrorf("invalid character ' ' %s", d.eofIn)
+		}
+		return d.syntaxErrorf("unexpected end of JSON input")
+	}
+	return err
+}
+
+// StrictNumbers causes the Decoder to return an error when a number cannot be
+// stored in a floating point destination without losing precision, that is
+// when the stored value would not format back to the number in the input.
+func (d *Decoder) StrictNumbers() {
+	d.strictNumbers = true
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when an object
+// being decoded into a struct has a key that does not match any exported
+// field.
+func (d *Decoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+}
+
+// SetTagKey makes the Decoder match object keys to struct fields, and read the
+// fields' options, using the given key of their struct tags rather than json.
+// Fields without that key are named as if they had no tag.
+func (d *Decoder) SetTagKey(key string) {
+	d.tagKey = key
+}
+
+// MatchCaseSensitive causes object keys to only match struct fields whose name
+// is exactly the same. By default a key that matches no field exactly may match
+// one case insensitively, as encoding/json does. Combine it with
+// DisallowUnknownFields to reject keys that only differ from a field in case.
+func (d *Decoder) MatchCaseSensitive() {
+	d.matchCaseSensitive = true
+}
+
+// Buffered returns a reader of the data that the Decoder has read from its
+// input but not yet decoded. The reader is only valid until the next call to
+// Decode or Token.
+func (d *Decoder) Buffered() io.Reader {
+	return bytes.NewReader(d.buf[d.pos:])
+}
+
+// SetMaxDepth sets the deepest nesting of objects and arrays that the Decoder
+// will read before returning a *MaxDepthError, protecting the stack from
+// hostile input. Zero, the default, means DefaultMaxDepth.
+func (d *Decoder) SetMaxDepth(n int) {
+	d.maxDepth = n
+}
+
+// SetMaxBytes sets the number of bytes the Decoder will read from its input
+// before returning a *MaxBytesError, across all calls to Decode. Zero, the
+// default, means no limit.
+func (d *Decoder) SetMaxBytes(n int64) {
+	d.maxBytes = n
+}
+
+// SetMaxStringLen sets the longest string, object key or number literal that
+// the Decoder will read before returning a *MaxStringLenError. The length is of
+// the unescaped string in bytes. Zero, the default, means no limit.
+func (d *Decoder) SetMaxStringLen(n int) {
+	d.maxStringLen = n
+}
+
+// SetMaxNumberLen sets the longest number literal that the Decoder will read
+// before returning a *MaxNumberLenError, so that a stream of digits cannot use
+// unbounded memory. Zero, the default, means DefaultMaxNumberLen, a negative n
+// means no limit. Numbers are also limited by SetMaxStringLen.
+func (d *Decoder) SetMaxNumberLen(n int) {
+	d.maxNumberLen = n
+}
+
+// ReportPosition causes the messages of syntax errors to include the line and
+// column of the error, which are always available in SyntaxError.Line and
+// SyntaxError.Column.
+func (d *Decoder) ReportPosition() {
+	d.reportPosition = true
+}
+
+// DisallowDuplicateKeys causes the Decoder to return a *DuplicateKeyError when
+// an object repeats a key, rather than keeping the last value. Keys are
+// compared after unescaping, so "a" and "\u0061" are the same key.
+func (d *Decoder) DisallowDuplicateKeys() {
+	d.disallowDuplicateKeys = true
+}
+
+// DisallowTrailingData causes Decode and Skip to return an error if anything
+// other than whitespace follows a top-level value, for inputs that should hold
+// a single value. The input is read to its end to check. Use More to find
+// whether a stream holds another value instead.
+func (d *Decoder) DisallowTrailingData() {
+	d.disallowTrailingData = true
+}
+
+// AllowComments causes the Decoder to accept // line comments and /* block */
+// comments anywhere whitespace is allowed, as in JSONC configuration files.
+// Comments are not accepted inside literals.
+func (d *Decoder) AllowComments() {
+	d.allowComments = true
+}
+
+// AllowJSON5 causes the Decoder to accept the JSON5 extensions of unquoted
+// object keys, single quoted strings, hexadecimal numbers, numbers with a
+// leading plus sign, NaN and Infinity, and comments as AllowComments does. An
+// Unmarshaler or RawMessage is given the value as it was written.
+func (d *Decoder) AllowJSON5() {
+	d.json5 = true
+	d.allowComments = true
+	d.allowNonFinite = true
+}
+
+// AllowNonFinite causes the Decoder to accept the literals NaN, Infinity and
+// -Infinity, as written by Python and JavaScript, as numbers. They may only be
+// decoded into floating point values or an empty interface.
+func (d *Decoder) AllowNonFinite() {
+	d.allowNonFinite = true
+}
+
+// TeeRaw causes the Decoder to copy the exact input bytes of each value it
+// decodes to w, excluding the whitespace between top-level values. The bytes of
+// a value that fails to decode are copied up to the point of failure. Passing
+// a nil w stops copying.
+func (d *Decoder) TeeRaw(w io.Writer) {
+	d.tee = w
 }
 
 func (d *Decoder) Decode(v interface{}) error {
@@ -56,59 +383,625 @@ func (d *Decoder) Decode(v interface{}) error {
 		return &InvalidUnmarshalError{reflect.TypeOf(v)}
 	}
 
-	c, err := d.readByte()
-	if err != nil {
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !d.tokenValueAllowed() {
+		return d.syntaxErrorf("not at beginning of value")
+	}
+	if ok, err := d.decodeScalar(v); !ok {
+		err = d.decode(vv)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return d.valueEnd()
+}
+
+// valueEnd records that a whole value has been read by Decode or Skip, and
+// checks that nothing follows it if trailing data is disallowed.
+func (d *Decoder) valueEnd() error {
+	if d.disallowTrailingData && d.tokenState == tokenTopValue {
+		if err := d.readEnd(); err != nil {
+			return err
+		}
+	}
+	d.tokenValueEnd()
+	return nil
+}
+
+// decodeScalar decodes a string, number or literal into v without reflection,
+// if v points to one of the common scalar types that can hold it. It reports
+// whether it did, leaving the input unread if not.
+func (d *Decoder) decodeScalar(v interface{}) (_ bool, err error) {
+	if d.tee != nil || len(d.decoders) > 0 || d.mergePatch || d.timings != nil {
+		return false, nil
+	}
+	c, err := d.peek()
+	if err != nil {
+		// left for decode to report
+		return false, nil
+	}
+	number := c == '-' || c >= '0' && c <= '9' || d.json5 && c == '+' || d.allowNonFinite && (c == 'I' || c == 'N')
+	str := c == '"' || d.json5 && c == '\''
+	switch p := v.(type) {
+	case *interface{}:
+		if !str && c != 't' && c != 'f' && c != 'n' && !number {
+			return false, nil
+		}
+		if number && d.rawNumber {
+			return false, nil
+		}
+		if e := reflect.ValueOf(*p); e.Kind() == reflect.Ptr && !e.IsNil() {
+			// decode into what it points to
+			return false, nil
+		}
+	case *string:
+		if !str && c != 'n' {
+			return false, nil
+		}
+	case *bool:
+		if c != 't' && c != 'f' && c != 'n' {
+			return false, nil
+		}
+	case *int64, *float64:
+		if !number && c != 'n' {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+
+	_, _ = d.readByte()
+	d.countValue()
+	defer func() {
+		if err != nil {
+			setErrorKind(err, c)
+		}
+	}()
+	switch c {
+	case 'n':
+		if err = d.readLiteral(c); err != nil {
+			return true, err
+		}
+		if p, ok := v.(*interface{}); ok {
+			*p = nil
+		}
+	case 't', 'f':
+		if err = d.readLiteral(c); err != nil {
+			return true, err
+		}
+		switch p := v.(type) {
+		case *interface{}:
+			*p = c == 't'
+		case *bool:
+			*p = c == 't'
+		}
+	case '"', '\'':
+		buf, err := d.readStringBytes(c)
+		if err != nil {
+			return true, err
+		}
+		switch p := v.(type) {
+		case *interface{}:
+			*p = d.makeString(buf)
+		case *string:
+			*p = d.makeString(buf)
+		}
+	default:
+		raw, err := d.readNumber(c)
+		if err != nil {
+			return true, err
+		}
+		if p, ok := v.(*int64); ok {
+			n, err := strconv.ParseInt(string(raw), 10, 64)
+			if err != nil {
+				return true, d.unmarshalTypeError("number "+string(raw), int64Type)
+			}
+			*p = n
+			break
+		}
+		num, err := parseFloat(raw, 64)
+		if err != nil {
+			typeErr := d.unmarshalTypeError("number "+string(raw), float64Type)
+			if _, ok := v.(*interface{}); ok {
+				// encoding/json reads one more byte before converting
+				// numbers for interfaces
+				typeErr.Offset++
+			}
+			return true, typeErr
+		}
+		if err = d.checkPrecision(raw, num, float64Type); err != nil {
+			return true, err
+		}
+		switch p := v.(type) {
+		case *interface{}:
+			*p = num
+		case *float64:
+			*p = num
+		}
+	}
+	return true, nil
+}
+
+// decode reads the next value into the pointer v, or skips it if v is the zero
+// Value.
+func (d *Decoder) decode(v reflect.Value) error {
+	d.objects = d.objects[:0]
+	d.presencePath = d.presencePath[:0]
+	c, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	if d.tee == nil {
+		return d.readValue(c, v)
+	}
+
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	d.teeing = true
+	d.teeBuf = append(d.teeBuf[:0], c)
+	err = d.readValue(c, v)
+	d.teeing = false
+	d.flushTee(len(d.teeBuf))
+	if err == nil {
+		err = d.teeErr
+	}
+	d.teeErr = nil
+	return err
+}
+
+// Skip reads the next value from the input and discards it. Like Decode it may
+// be mixed with calls to Token, to skip the parts of a stream that aren't
+// wanted.
+func (d *Decoder) Skip() error {
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !d.tokenValueAllowed() {
+		return d.syntaxErrorf("not at beginning of value")
+	}
+	if err := d.decode(reflect.Value{}); err != nil {
+		return err
+	}
+	return d.valueEnd()
+}
+
+// flushTee writes the first n bytes of the tee buffer to the tee writer. The
+// first write error is kept and later writes are dropped.
+func (d *Decoder) flushTee(n int) {
+	if d.teeErr == nil {
+		_, d.teeErr = d.tee.Write(d.teeBuf[:n])
+	}
+	d.teeBuf = d.teeBuf[:copy(d.teeBuf, d.teeBuf[n:])]
+}
+
+// readEnd consumes the remainder of the input, which must be whitespace.
+func (d *Decoder) readEnd() error {
+	c, err := d.readNonSpace()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	return d.syntaxErrorf("invalid character %q after top-level value", c)
+}
+
+// Unmarshaler is implemented by types that decode themselves from JSON. The
+// input is a single valid JSON value, which UnmarshalJSON must copy if it wishes
+// to keep it after returning.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+// RawMessage is a raw encoded JSON value, it can be used to delay decoding part
+// of a document or to precompute part of an encoding.
+type RawMessage []byte
+
+// MarshalJSON returns m as the JSON encoding of m.
+func (m RawMessage) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	return m, nil
+}
+
+// UnmarshalJSON sets *m to a copy of data.
+func (m *RawMessage) UnmarshalJSON(data []byte) error {
+	if m == nil {
+		return errors.New("json.RawMessage: UnmarshalJSON on nil pointer")
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
+
+// readValue reads the value beginning with c into the pointer v, if v is the
+// zero Value the value is skipped.
+func (d *Decoder) readValue(c byte, v reflect.Value) (err error) {
+	if !v.IsValid() {
+		return d.skipValue(c)
+	}
+
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	d.countValue()
+	if d.timings != nil {
+		defer d.recordTiming(c, time.Now())
+	}
+	defer func() {
+		if err != nil {
+			setErrorKind(err, c)
+		}
+	}()
+	if d.timeLayout != "" && c == '"' && isTimePtr(v.Type()) && d.decoders[timeType] == nil {
+		return d.readTime(c, v, d.timeLayout)
+	}
+
+	// Follow pointers down to the value to decode into, allocating any that
+	// are nil. A null stops at the last pointer so that it can be set to nil.
+	outer := v
+	for {
+		if fn := d.decoders[v.Elem().Type()]; fn != nil {
+			return d.readRegistered(c, v, fn)
+		}
+		if isNull(v.Elem()) && v.CanInterface() {
+			return d.readNullable(c, v)
+		}
+		if v.Type().NumMethod() > 0 && v.CanInterface() && v.Type() != orderedMapPtrType {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				raw, err := d.readRaw(c)
+				if err != nil {
+					return err
+				}
+				return u.UnmarshalJSON(raw)
+			}
+		}
+		if isSQLNull(v.Elem().Type()) {
+			return d.readSQLNull(c, v)
+		}
+		if c != 'n' && v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+				// as in encoding/json, a value of the wrong kind is reported
+				// against the type before following pointers, which at the
+				// top level is the pointer passed to Decode
+				t := outer.Elem().Type()
+				if d.depth == 0 {
+					t = outer.Type()
+				}
+				return d.readText(c, t, u)
+			}
+		}
+		if e, ok := concreteElem(v, c == 'n'); ok {
+			v = e
+			continue
+		}
+		if v.Elem().Kind() != reflect.Ptr || c == 'n' {
+			break
+		}
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(v.Elem().Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch c {
+	case '{':
+		return d.readObject(c, v)
+	case '[':
+		return d.readArray(c, v)
+	case '\'':
+		if !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '"':
+		return d.readString(c, v)
+	case 't', 'f':
+		return d.readBool(c, v)
+	case 'n':
+		return d.readNull(v)
+	case '+', 'I', 'N':
+		if !d.allowNonFinite || c == '+' && !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		raw, err := d.readNumber(c)
+		if err != nil {
+			return err
+		}
+		return d.setNumber(raw, v)
+	default:
+		return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+	}
+}
+
+// concreteElem returns the pointer held in the interface pointed to by v, so
+// that a value is decoded into what the interface already holds rather than
+// replacing it. A null only follows the pointer when it points to another
+// pointer that can be set to nil, otherwise the interface itself is cleared.
+func concreteElem(v reflect.Value, null bool) (reflect.Value, bool) {
+	if v.Elem().Kind() != reflect.Interface || v.Elem().IsNil() {
+		return reflect.Value{}, false
+	}
+	e := v.Elem().Elem()
+	if e.Kind() != reflect.Ptr || e.IsNil() || null && e.Elem().Kind() != reflect.Ptr {
+		return reflect.Value{}, false
+	}
+	return e, true
+}
+
+// skipValue reads the value beginning with c and discards it, the input is
+// checked as thoroughly as when it is decoded.
+func (d *Decoder) skipValue(c byte) (err error) {
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	d.countValue()
+	defer func() {
+		if err != nil {
+			setErrorKind(err, c)
+		}
+	}()
+
+	switch c {
+	case '{':
+		return d.skipObject()
+	case '[':
+		return d.skipArray()
+	case '\'':
+		if !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '"':
+		_, err = d.readStringBytes(c)
+		return err
+	case 't', 'f', 'n':
+		return d.readLiteral(c)
+	case '+', 'I', 'N':
+		if !d.allowNonFinite || c == '+' && !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		_, err = d.readNumber(c)
+		return err
+	default:
+		return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+	}
+}
+
+// skipObject reads an object whose opening brace has been consumed and discards
+// it.
+func (d *Decoder) skipObject() error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c        = byte('{')
+		err      error
+		firstKey = true
+		seen     map[string]struct{}
+	)
+	for {
+		switch c {
+		case ',', '{':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if firstKey && c == '}' {
+				return nil
+			}
+			firstKey = false
+
+			key, keyOffset, err := d.readObjectKey(c)
+			if err != nil {
+				return err
+			}
+			if d.disallowDuplicateKeys {
+				if _, ok := seen[key]; ok {
+					return &DuplicateKeyError{
+						Key:    key,
+						Offset: keyOffset,
+					}
+				}
+				if seen == nil {
+					seen = map[string]struct{}{}
+				}
+				seen[key] = struct{}{}
+			}
+			if err = d.readObjectSeparator(); err != nil {
+				return err
+			}
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if err = d.skipValue(c); err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case '}':
+			return nil
+		default:
+			return d.syntaxErrorf("invalid character %q after object key:value pair", c)
+		}
+	}
+}
+
+// skipArray reads an array whose opening bracket has been consumed and discards
+// it.
+func (d *Decoder) skipArray() error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c         = byte('[')
+		err       error
+		firstElem = true
+	)
+	for {
+		switch c {
+		case ',', '[':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if firstElem && c == ']' {
+				return nil
+			}
+			firstElem = false
+			if err = d.skipValue(c); err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case ']':
+			return nil
+		default:
+			return d.syntaxErrorf("invalid character %q after array element", c)
+		}
+	}
+}
+
+// enter records the start of an object or array, returning an error if it is
+// nested too deeply.
+func (d *Decoder) enter() error {
+	maxDepth := d.maxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if d.depth >= maxDepth {
+		return &MaxDepthError{
+			MaxDepth: maxDepth,
+			Offset:   d.offset,
+		}
+	}
+	if err := d.checkContext(); err != nil {
 		return err
 	}
-	return d.readValue(c, vv)
+	d.depth++
+	if d.depth > d.stats.MaxDepth {
+		d.stats.MaxDepth = d.depth
+	}
+	return nil
 }
 
-func (d *Decoder) readValue(c byte, v reflect.Value) error {
-	var err error
+// leave records the end of an object or array.
+func (d *Decoder) leave() {
+	d.depth--
+}
 
-	for {
-		switch c {
-		case '{':
-			return d.readObject(c, v)
-		case '[':
-			return d.readArray(c, v)
-		case '"':
-			return d.readString(v)
-		case 't', 'f':
-			return d.readBool(c, v)
-		case 'n':
-			return d.readNull()
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			return d.readUint(c, v)
-		case '-':
-			return d.readInt(v)
-		case ' ', '\t', '\r', '\n':
-		default:
-			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
-		}
-		if c, err = d.readByte(); err != nil {
-			return err
-		}
-	}
+// readRaw reads the value beginning with c and returns its bytes, which are
+// only valid until the next call.
+func (d *Decoder) readRaw(c byte) ([]byte, error) {
+	d.capturing = true
+	d.raw = append(d.raw[:0], c)
+	err := d.skipValue(c)
+	d.capturing = false
+	return d.raw, err
 }
 
 func (d *Decoder) readObject(c byte, v reflect.Value) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
 	var (
-		obj, val reflect.Value
-		key      string
-		err      error
-		firstKey = true
+		obj, val  reflect.Value
+		fields    *structFields
+		key       string
+		keyOffset int64
+		err       error
+		firstKey  = true
+		seen      map[string]struct{}
+		ordered   *OrderedMap
+		present   []byte
 	)
-	switch v.Elem().Kind() {
+
+	kind := v.Elem().Kind()
+	into := v.Type() == orderedMapPtrType
+	if into {
+		// the members are set in the OrderedMap, as if decoding into an
+		// interface, and objects in it are ordered too
+		kind = reflect.Interface
+		ordered = v.Interface().(*OrderedMap)
+		if !d.orderedObjects {
+			d.orderedObjects = true
+			defer func() { d.orderedObjects = false }()
+		}
+	}
+	if !into && kind == reflect.Interface && d.mergePatch {
+		if m := v.Elem().Elem(); m.IsValid() && m.Type() == mapStringInterfaceType {
+			// merge into the map the interface holds
+			kind = reflect.Map
+			v = reflect.New(m.Type())
+			v.Elem().Set(m)
+		}
+	}
+	switch kind {
 	case reflect.Interface:
-		obj = reflect.ValueOf(&map[string]interface{}{})
+		if !into && v.Elem().NumMethod() != 0 {
+			return d.unmarshalTypeError("object", v.Elem().Type())
+		}
+	case reflect.Map:
+		switch kt := v.Elem().Type().Key(); kt.Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+				return d.unmarshalTypeError("object", v.Elem().Type())
+			}
+		}
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.MakeMap(v.Elem().Type()))
+		}
+		obj = v
+	case reflect.Struct:
+		obj = v
+		fields = cachedTypeFields(v.Elem().Type(), d.tagKey)
+		if (fields.defaults || fields.required) && !d.mergePatch {
+			present = make([]byte, len(fields.list))
+		}
+	default:
+		return d.unmarshalTypeError("object", v.Elem().Type())
 	}
 
 objLoop:
 	for {
 		switch c {
 		case ',', '{':
-			if c, err = d.readByte(); err != nil {
+			if c, err = d.readNonSpace(); err != nil {
 				if err == io.EOF {
 					return io.ErrUnexpectedEOF
 				}
@@ -119,30 +1012,137 @@ objLoop:
 			}
 			firstKey = false
 
-			if key, err = d.readObjectKey(c); err != nil {
+			if key, keyOffset, err = d.readObjectKey(c); err != nil {
 				return err
 			}
+			if d.disallowDuplicateKeys {
+				if _, ok := seen[key]; ok {
+					return &DuplicateKeyError{
+						Key:    key,
+						Offset: keyOffset,
+					}
+				}
+				if seen == nil {
+					seen = map[string]struct{}{}
+				}
+				seen[key] = struct{}{}
+			}
 
 			if err = d.readObjectSeparator(); err != nil {
 				return err
 			}
 
-			val = reflect.ValueOf(new(interface{}))
+			var (
+				f      *field
+				quoted bool
+				layout string
+			)
+			switch kind {
+			case reflect.Struct:
+				if val, f, err = structField(obj.Elem(), fields, key, d.matchCaseSensitive); err != nil {
+					return err
+				}
+				if f == nil && d.disallowUnknownFields {
+					return fmt.Errorf("json: unknown field %q", key)
+				}
+				quoted = f != nil && f.quoted
+				if f != nil && f.format != "" && isTimePtr(f.typ) {
+					layout = f.format
+				}
+			case reflect.Map:
+				val = reflect.New(obj.Elem().Type().Elem())
+				if d.mergePatch {
+					if kv, err := d.mapKey(key, obj.Elem().Type().Key(), keyOffset); err == nil {
+						if e := obj.Elem().MapIndex(kv); e.IsValid() {
+							val.Elem().Set(e)
+						}
+					}
+				}
+			default:
+				if into || d.orderedObjects && !d.mergePatch {
+					if ordered == nil {
+						ordered = &OrderedMap{}
+					}
+				} else if !obj.IsValid() {
+					obj = d.makeObject(key)
+				}
+				val = reflect.ValueOf(new(interface{}))
+				if into && d.mergePatch {
+					if e, ok := ordered.Get(key); ok {
+						val.Elem().Set(reflect.ValueOf(&e).Elem())
+					}
+				}
+			}
+
 			if c, err = d.readByte(); err != nil {
 				if err == io.EOF {
 					return io.ErrUnexpectedEOF
 				}
 				return err
 			}
-			if err = d.readValue(c, val); err != nil {
+			if d.mergePatch || present != nil || d.presence != nil {
+				if c, err = d.skipSpace(c); err != nil {
+					return err
+				}
+			}
+			if d.presence != nil {
+				d.pushPresence(key, f, c)
+			}
+			if present != nil && f != nil {
+				i := fields.byName[f.name]
+				if c != 'n' {
+					present[i] = fieldSet
+				} else if present[i] == fieldAbsent {
+					present[i] = fieldNull
+				}
+			}
+			switch {
+			case layout != "":
+				d.countValue()
+				err = d.readTime(c, val, layout)
+			case quoted:
+				d.countValue()
+				err = d.readQuoted(c, val)
+			default:
+				err = d.readValue(c, val)
+			}
+			if err != nil {
+				switch {
+				case f != nil:
+					addErrorContext(err, obj.Elem().Type(), f)
+				case kind == reflect.Map:
+					addErrorIndex(err, key)
+				}
 				return err
 			}
+			if d.presence != nil {
+				d.presencePath = d.presencePath[:len(d.presencePath)-1]
+			}
 
-			obj.Elem().SetMapIndex(reflect.ValueOf(key), val.Elem())
+			var elem reflect.Value
+			if kind != reflect.Struct && (!d.mergePatch || c != 'n') {
+				// a null in a merge patch removes the member
+				elem = val.Elem()
+			}
+			switch kind {
+			case reflect.Map:
+				kv, err := d.mapKey(key, obj.Elem().Type().Key(), keyOffset)
+				if err != nil {
+					return err
+				}
+				obj.Elem().SetMapIndex(kv, elem)
+			case reflect.Interface:
+				switch {
+				case ordered != nil && !elem.IsValid():
+					ordered.Delete(key)
+				case ordered != nil:
+					ordered.Set(key, elem.Interface())
+				default:
+					obj.Elem().SetMapIndex(reflect.ValueOf(key), elem)
+				}
+			}
 
-			fallthrough
-		case ' ', '\t', '\r', '\n':
-			if c, err = d.readByte(); err != nil {
+			if c, err = d.readNonSpace(); err != nil {
 				if err == io.EOF {
 					return io.ErrUnexpectedEOF
 				}
@@ -155,61 +1155,344 @@ objLoop:
 		}
 	}
 
+	if present != nil {
+		if err := d.checkRequired(obj, fields, present); err != nil {
+			return err
+		}
+		return d.setDefaults(obj, fields, present)
+	}
+	if kind != reflect.Interface || into {
+		return nil
+	}
+	if d.orderedObjects && !d.mergePatch {
+		if ordered == nil {
+			ordered = &OrderedMap{}
+		}
+		v.Elem().Set(reflect.ValueOf(ordered))
+		return nil
+	}
+	if !obj.IsValid() {
+		obj = reflect.ValueOf(&map[string]interface{}{})
+	} else {
+		d.recordShape(obj.Elem())
+	}
 	v.Elem().Set(obj.Elem())
 	return nil
 }
 
-func (d *Decoder) readObjectKey(c byte) (string, error) {
-	var (
-		key string
-		err error
-	)
+// mapKey returns the key for a map with key type kt from the object key read at
+// offset.
+func (d *Decoder) mapKey(key string, kt reflect.Type, offset int64) (reflect.Value, error) {
+	kv := reflect.New(kt)
+	if u, ok := kv.Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return kv.Elem(), nil
+	}
+
+	kv = kv.Elem()
+	switch kt.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || kt.OverflowInt(n) {
+			return reflect.Value{}, &UnmarshalTypeError{Value: "number " + key, Type: kt, Offset: offset}
+		}
+		kv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || kt.OverflowUint(n) {
+			return reflect.Value{}, &UnmarshalTypeError{Value: "number " + key, Type: kt, Offset: offset}
+		}
+		kv.SetUint(n)
+	}
+	return kv, nil
+}
+
+// structField returns a pointer to the field of struct v named by key, fields
+// must be the cachedTypeFields of v. Only an exact match is made if
+// caseSensitive is set. If there is no such field the zero Value is
+// returned, so that the value is skipped, and f is nil. Nil pointers to embedded
+// structs that the field is promoted through are allocated.
+func structField(v reflect.Value, fields *structFields, key string, caseSensitive bool) (fv reflect.Value, f *field, err error) {
+	if f = fields.byKey(key, caseSensitive); f == nil {
+		return reflect.Value{}, nil, nil
+	}
+
+	fv = v
+	for i, x := range f.index {
+		if i > 0 && fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				if !fv.CanSet() {
+					return reflect.Value{}, nil, fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", fv.Type().Elem())
+				}
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		fv = fv.Field(x)
+	}
+	return fv.Addr(), f, nil
+}
+
+// readQuoted reads the value beginning with c into the pointer v to a field
+// with the string option, where the value should be a JSON string holding a
+// literal of the field's type.
+func (d *Decoder) readQuoted(c byte, v reflect.Value) error {
+	var err error
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	switch c {
+	case 'n':
+		return d.readValue(c, v)
+	case '"':
+	case '\'':
+		if d.json5 {
+			break
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal unquoted value into %v", v.Elem().Type())
+	}
 
-keyLoop:
+	item, err := d.readStringBytes(c)
+	if err != nil {
+		return err
+	}
+	if len(item) == 0 {
+		return invalidQuoted(item, v.Elem().Type())
+	}
+	// follow pointers as readValue does
 	for {
-		switch c {
-		case '"':
-			if err = d.readString(reflect.ValueOf(&key)); err != nil {
-				return "", err
+		if v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				return u.UnmarshalJSON(item)
 			}
-			break keyLoop
-		case ' ', '\t', '\r', '\n':
-			if c, err = d.readByte(); err != nil {
-				return "", err
+		}
+		if e, ok := concreteElem(v, item[0] == 'n'); ok {
+			v = e
+			continue
+		}
+		if v.Elem().Kind() != reflect.Ptr || item[0] == 'n' {
+			break
+		}
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(v.Elem().Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	v = v.Elem()
+
+	switch c := item[0]; c {
+	case 'n':
+		if string(item) != "null" {
+			return invalidQuoted(item, v.Type())
+		}
+		switch v.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+	case 't', 'f':
+		if string(item) != "true" && string(item) != "false" {
+			return invalidQuoted(item, v.Type())
+		}
+		switch {
+		case v.Kind() == reflect.Bool:
+			v.SetBool(c == 't')
+		case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+			v.Set(reflect.ValueOf(c == 't'))
+		default:
+			return invalidQuoted(item, v.Type())
+		}
+	case '"':
+		inner := &Decoder{buf: item[1:]}
+		s, err := inner.readStringBytes('"')
+		if err != nil || inner.offset != int64(len(item)-1) {
+			return invalidQuoted(item, v.Type())
+		}
+		return d.setString(s, v.Addr())
+	default:
+		if c != '-' && (c < '0' || c > '9') {
+			return invalidQuoted(item, v.Type())
+		}
+		num := string(item)
+		switch v.Kind() {
+		case reflect.Interface:
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return d.unmarshalTypeError("number "+num, reflect.TypeOf(n))
+			}
+			if v.NumMethod() != 0 {
+				return d.unmarshalTypeError("number", v.Type())
+			}
+			v.Set(reflect.ValueOf(n))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(num, 10, 64)
+			if err != nil || v.OverflowInt(n) {
+				return d.unmarshalTypeError("number "+num, v.Type())
+			}
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, err := strconv.ParseUint(num, 10, 64)
+			if err != nil || v.OverflowUint(n) {
+				return d.unmarshalTypeError("number "+num, v.Type())
 			}
+			v.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(num, v.Type().Bits())
+			if err != nil || v.OverflowFloat(n) {
+				return d.unmarshalTypeError("number "+num, v.Type())
+			}
+			v.SetFloat(n)
 		default:
-			return "", d.syntaxErrorf("invalid character %q looking for beginning of object key string", c)
+			return invalidQuoted(item, v.Type())
 		}
 	}
+	return nil
+}
 
-	return key, nil
+func invalidQuoted(item []byte, t reflect.Type) error {
+	return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", item, t)
 }
 
-func (d *Decoder) readObjectSeparator() error {
-	var (
-		c   byte
-		err error
-	)
-separatorLoop:
+// makeObject returns a pointer to a new map for an object decoded into an
+// interface{}, sized for the last object that began with the same key.
+func (d *Decoder) makeObject(firstKey string) reflect.Value {
+	m := make(map[string]interface{}, d.shapes[firstKey])
+	d.objects = append(d.objects, firstKey)
+	return reflect.ValueOf(&m)
+}
+
+// recordShape remembers the size of obj, the completed map from the most recent
+// makeObject call, for the next object beginning with the same key.
+func (d *Decoder) recordShape(obj reflect.Value) {
+	firstKey := d.objects[len(d.objects)-1]
+	d.objects = d.objects[:len(d.objects)-1]
+	if _, ok := d.shapes[firstKey]; !ok && len(d.shapes) >= maxInterned {
+		return
+	}
+	if d.shapes == nil {
+		d.shapes = map[string]int{}
+	}
+	d.shapes[firstKey] = obj.Len()
+}
+
+// internKey returns a string with the contents of b, objects of the same
+// shape share the strings of their keys rather than allocating them again.
+func (d *Decoder) internKey(b []byte) string {
+	if len(b) > maxInternLen {
+		return d.makeString(b)
+	}
+	if key, ok := d.keys[string(b)]; ok {
+		return key
+	}
+	key := d.makeString(b)
+	if len(d.keys) < maxInterned {
+		if d.keys == nil {
+			d.keys = map[string]string{}
+		}
+		d.keys[key] = key
+	}
+	return key
+}
+
+// readObjectKey reads an object key beginning with c, it returns the key and
+// the offset just inside its opening quote, or of an unquoted key.
+func (d *Decoder) readObjectKey(c byte) (string, int64, error) {
+	buf, offset, err := d.readObjectKeyBytes(c)
+	if err != nil {
+		return "", 0, err
+	}
+	return d.internKey(buf), offset, nil
+}
+
+// readObjectKeyBytes is readObjectKey returning the key's bytes, which are only
+// valid until the Decoder next reads.
+func (d *Decoder) readObjectKeyBytes(c byte) ([]byte, int64, error) {
+	var err error
+
 	for {
-		if c, err = d.readByte(); err != nil {
+		switch {
+		case c == '"' || c == '\'' && d.json5:
+			offset := d.offset
+			buf, err := d.readStringBytes(c)
+			if err != nil {
+				return nil, 0, err
+			}
+			return buf, offset, nil
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			if c, err = d.readByte(); err != nil {
+				return nil, 0, err
+			}
+		case d.json5 && isIdentByte(c) && (c < '0' || c > '9'):
+			offset := d.offset - 1
+			buf, err := d.readIdentifier(c)
+			if err != nil {
+				return nil, 0, err
+			}
+			return buf, offset, nil
+		default:
+			return nil, 0, d.syntaxErrorf("invalid character %q looking for beginning of object key string", c)
+		}
+	}
+}
+
+// readIdentifier reads an unquoted object key beginning with c and returns it,
+// it is only valid until the Decoder next reads.
+func (d *Decoder) readIdentifier(c byte) ([]byte, error) {
+	buf := append(d.scratch[:0], c)
+	for {
+		c, err := d.readByte()
+		if err != nil {
 			if err == io.EOF {
-				return io.ErrUnexpectedEOF
+				return nil, io.ErrUnexpectedEOF
 			}
-			return err
+			return nil, err
 		}
-		switch c {
-		case ':':
-			break separatorLoop
-		case ' ', '\t', '\r', '\n':
-		default:
-			return d.syntaxErrorf("invalid character %q after object key", c)
+		if !isIdentByte(c) {
+			break
+		}
+		buf = append(buf, c)
+		if err = d.checkLiteralLen(len(buf)); err != nil {
+			return nil, err
+		}
+	}
+	if cap(buf) <= maxScratch {
+		d.scratch = buf
+	}
+	return buf, d.unreadByte()
+}
+
+// isIdentByte reports whether c may be part of an unquoted object key. Any
+// byte of a multi-byte UTF-8 character is accepted.
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '$' || c >= utf8.RuneSelf
+}
+
+func (d *Decoder) readObjectSeparator() error {
+	c, err := d.readNonSpace()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
 		}
+		return err
+	}
+	if c != ':' {
+		return d.syntaxErrorf("invalid character %q after object key", c)
 	}
 	return nil
 }
 
 func (d *Decoder) readArray(c byte, v reflect.Value) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
 	var (
 		i         = 0
 		arr, elem reflect.Value
@@ -230,7 +1513,7 @@ arrLoop:
 	for {
 		switch c {
 		case ',', '[':
-			if c, err = d.readByte(); err != nil {
+			if c, err = d.readNonSpace(); err != nil {
 				if err == io.EOF {
 					return io.ErrUnexpectedEOF
 				}
@@ -247,19 +1530,24 @@ arrLoop:
 					elem = arr.Elem().Index(i).Addr()
 				} else {
 					// The Array v has no more space, but we must read the values to be able to proceed
-					elem = reflect.ValueOf(new(interface{}))
+					elem = reflect.Value{}
 				}
 			} else {
 				elem = arr.Elem().Index(i).Addr()
 			}
+			if d.presence != nil {
+				d.presencePath = append(d.presencePath, strconv.Itoa(i))
+			}
 			if err = d.readValue(c, elem); err != nil {
+				addErrorIndex(err, strconv.Itoa(i))
 				return err
 			}
+			if d.presence != nil {
+				d.presencePath = d.presencePath[:len(d.presencePath)-1]
+			}
 			i++
 
-			fallthrough
-		case ' ', '\t', '\r', '\n':
-			if c, err = d.readByte(); err != nil {
+			if c, err = d.readNonSpace(); err != nil {
 				if err == io.EOF {
 					return io.ErrUnexpectedEOF
 				}
@@ -274,46 +1562,208 @@ arrLoop:
 
 	if arr.Elem().Kind() == reflect.Slice {
 		arr.Elem().SetLen(i)
+		if i == 0 {
+			arr.Elem().Set(reflect.MakeSlice(arr.Elem().Type(), 0, 0))
+		}
 	}
 	v.Elem().Set(arr.Elem())
 	return nil
 }
 
-func (d *Decoder) readString(v reflect.Value) error {
+// readString reads the string literal opened by the quote q into the value
+// pointed to by v.
+func (d *Decoder) readString(q byte, v reflect.Value) error {
+	buf, err := d.readStringBytes(q)
+	if err != nil {
+		return err
+	}
+	return d.setString(buf, v)
+}
+
+// readText reads the value beginning with c into the TextUnmarshaler u, which
+// can only be decoded from a string. Other values are reported as errors
+// decoding into t.
+func (d *Decoder) readText(c byte, t reflect.Type, u encoding.TextUnmarshaler) error {
+	switch c {
+	case '"':
+	case '\'':
+		if !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+	case '{':
+		return d.unmarshalTypeError("object", t)
+	case '[':
+		return d.unmarshalTypeError("array", t)
+	case 't', 'f':
+		if err := d.readLiteral(c); err != nil {
+			return err
+		}
+		return d.unmarshalTypeError("bool", t)
+	default:
+		if _, err := d.readNumber(c); err != nil {
+			return err
+		}
+		return d.unmarshalTypeError("number", t)
+	}
+	buf, err := d.readStringBytes(c)
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalText(buf)
+}
+
+// setString stores the unescaped string buf in the value pointed to by v.
+func (d *Decoder) setString(buf []byte, v reflect.Value) error {
+	switch v.Elem().Kind() {
+	case reflect.String:
+		if v.Elem().Type() == numberType && !isValidNumber(string(buf)) {
+			return fmt.Errorf("json: invalid number literal, trying to unmarshal %q into Number", strconv.Quote(string(buf)))
+		}
+		v.Elem().SetString(d.makeString(buf))
+	case reflect.Interface:
+		if v.Elem().NumMethod() != 0 {
+			return d.unmarshalTypeError("string", v.Elem().Type())
+		}
+		v.Elem().Set(reflect.ValueOf(d.makeString(buf)))
+	case reflect.Slice:
+		// []byte is encoded as a base64 string
+		if v.Elem().Type().Elem().Kind() != reflect.Uint8 {
+			return d.unmarshalTypeError("string", v.Elem().Type())
+		}
+		b := make([]byte, base64.StdEncoding.DecodedLen(len(buf)))
+		n, err := base64.StdEncoding.Decode(b, buf)
+		if err != nil {
+			return err
+		}
+		v.Elem().SetBytes(b[:n])
+	case reflect.Struct:
+		if v.Elem().Type() != urlType {
+			return d.unmarshalTypeError("string", v.Elem().Type())
+		}
+		u, err := url.Parse(string(buf))
+		if err != nil {
+			return d.unmarshalTypeError("string "+strconv.Quote(string(buf)), v.Elem().Type())
+		}
+		v.Elem().Set(reflect.ValueOf(*u))
+	case reflect.Int64:
+		if v.Elem().Type() != durationType {
+			if d.allowQuotedInts {
+				return d.setQuotedInt(buf, v)
+			}
+			return d.unmarshalTypeError("string", v.Elem().Type())
+		}
+		dur, err := time.ParseDuration(string(buf))
+		if err != nil {
+			return d.unmarshalTypeError("string "+strconv.Quote(string(buf)), v.Elem().Type())
+		}
+		v.Elem().SetInt(int64(dur))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !d.allowQuotedInts {
+			return d.unmarshalTypeError("string", v.Elem().Type())
+		}
+		return d.setQuotedInt(buf, v)
+	default:
+		return d.unmarshalTypeError("string", v.Elem().Type())
+	}
+	return nil
+}
+
+// readStringBytes reads a string literal whose opening quote q has been
+// consumed and returns its unescaped contents, which are only valid until the
+// Decoder next reads.
+func (d *Decoder) readStringBytes(q byte) ([]byte, error) {
 	var (
-		buf = []byte{}
+		buf = d.scratch[:0]
 		c   byte
 		err error
+		// start is where the bytes read since the last escape begin in buf
+		start int
 	)
 	for {
+		run := d.readStringRun(len(buf), q)
+		if len(buf) > 0 || d.pos == len(d.buf) {
+			// the run is lost when the buffer is filled
+			buf = append(buf, run...)
+			run = nil
+		}
+		if err = d.checkLiteralLen(len(buf) + len(run)); err != nil {
+			return nil, err
+		}
 		c, err = d.readByte()
 		switch {
 		case err != nil:
 			if err == io.EOF {
-				return io.ErrUnexpectedEOF
+				return nil, io.ErrUnexpectedEOF
 			}
-			return err
-		case c == '"':
-			if v.Elem().Kind() != reflect.String && v.Elem().Kind() != reflect.Interface {
-				return d.unmarshalTypeError("string", v.Elem().Type())
+			return nil, err
+		case c == q:
+			if run != nil {
+				// the whole string was in the buffer and needs no copy
+				return d.checkUTF8(run, 0)
 			}
-			v.Elem().Set(reflect.ValueOf(string(buf)))
-			return nil
+			if buf, err = d.checkUTF8(buf, start); err != nil {
+				return nil, err
+			}
+			if cap(buf) <= maxScratch {
+				d.scratch = buf
+			}
+			return buf, nil
 		case c == '\\':
-			if c, err = d.unEscape(); err != nil {
-				return err
+			if buf, err = d.checkUTF8(append(buf, run...), start); err != nil {
+				return nil, err
+			}
+			if buf, err = d.unEscape(buf); err != nil {
+				if err == io.EOF {
+					return nil, io.ErrUnexpectedEOF
+				}
+				return nil, err
 			}
-			buf = append(buf, c)
+			start = len(buf)
 		default:
 			if invalidS[c] {
-				return d.syntaxErrorf("invalid character %q in string literal", c)
+				return nil, d.syntaxErrorf("invalid character %q in string literal", c)
 			}
-			buf = append(buf, c)
+			buf = append(append(buf, run...), c)
 		}
+		if err = d.checkLiteralLen(len(buf)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *Decoder) readBool(b byte, v reflect.Value) error {
+	if err := d.readLiteral(b); err != nil {
+		return err
+	}
+	if v.Elem().Kind() != reflect.Bool && v.Elem().Kind() != reflect.Interface {
+		return d.unmarshalTypeError("bool", v.Elem().Type())
+	}
+	v.Elem().Set(reflect.ValueOf(boolMap[b]))
+	return nil
+}
+
+func (d *Decoder) readNull(v reflect.Value) error {
+	if err := d.readLiteral('n'); err != nil {
+		return err
+	}
+	if d.mergePatch {
+		// null removes the value from a merge patch's target
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		return nil
 	}
+	// null only means something to types that can be nil, others are left
+	// untouched
+	switch v.Elem().Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	return nil
 }
 
-func (d *Decoder) readBool(b byte, v reflect.Value) error {
+// readLiteral reads the rest of the literal true, false or null beginning with
+// b.
+func (d *Decoder) readLiteral(b byte) error {
 	var (
 		c   byte
 		err error
@@ -321,222 +1771,731 @@ func (d *Decoder) readBool(b byte, v reflect.Value) error {
 	for i := range endOf[b] {
 		if c, err = d.readByte(); err != nil {
 			if err == io.EOF {
+				d.eofIn = fmt.Sprintf("in literal %c%s (expecting %q)", b, endOf[b], endOf[b][i])
 				return io.ErrUnexpectedEOF
 			}
 			return err
 		}
 		if c != endOf[b][i] {
-			return d.syntaxErrorf("invalid character %q in literal %v (expecting %q)", c, boolMap[b], endOf[b][i])
+			return d.syntaxErrorf("invalid character %q in literal %c%s (expecting %q)", c, b, endOf[b], endOf[b][i])
 		}
 	}
-	if v.Elem().Kind() != reflect.Bool && v.Elem().Kind() != reflect.Interface {
-		return d.unmarshalTypeError("bool", v.Elem().Type())
-	}
-	v.Elem().Set(reflect.ValueOf(boolMap[b]))
 	return nil
 }
 
-func (d *Decoder) readNull() error {
+// readNumber reads the number literal beginning with b, which is a digit or a
+// minus sign, or may be I or N if non-finite numbers are allowed and a plus
+// sign if JSON5 is. The literal is only valid until the next call.
+func (d *Decoder) readNumber(b byte) ([]byte, error) {
 	var (
-		c   byte
+		raw []byte
 		err error
 	)
-	for i := range endOf['n'] {
-		if c, err = d.readByte(); err != nil {
+	switch {
+	case d.json5:
+		raw, err = d.readJSON5Number(b)
+	case b == 'I' || b == 'N':
+		raw, err = d.readNonFinite('+', b)
+	case b == '-':
+		raw, err = d.readInt()
+	default:
+		raw, err = d.readUint(b)
+	}
+	if cap(raw) <= maxScratch {
+		d.num = raw
+	}
+	return raw, err
+}
+
+// readJSON5Number reads a number literal beginning with b, which may also be
+// signed with a plus, hexadecimal, NaN or Infinity. Hexadecimal numbers are
+// returned in decimal.
+func (d *Decoder) readJSON5Number(b byte) ([]byte, error) {
+	sign := b
+	if sign == '-' || sign == '+' {
+		c, err := d.readByte()
+		if err != nil {
 			if err == io.EOF {
-				return io.ErrUnexpectedEOF
+				d.eofIn = "in numeric literal"
+				return nil, io.ErrUnexpectedEOF
 			}
-			return err
+			return nil, err
+		}
+		b = c
+	}
+
+	switch {
+	case b == 'I' || b == 'N':
+		return d.readNonFinite(sign, b)
+	case b < '0' || b > '9':
+		return nil, d.syntaxErrorf("invalid character %q in numeric literal", b)
+	case b == '0':
+		c, err := d.readByte()
+		if err == nil && (c == 'x' || c == 'X') {
+			return d.readHexNumber(sign == '-')
 		}
-		if c != endOf['n'][i] {
-			return d.syntaxErrorf("invalid character %q in literal null (expecting %q)", c, endOf['n'][i])
+		if err == nil {
+			err = d.unreadByte()
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
 		}
 	}
-	return nil
+
+	raw, err := d.readUint(b)
+	if sign == '-' {
+		raw = append(raw, 0)
+		copy(raw[1:], raw)
+		raw[0] = '-'
+	}
+	return raw, err
 }
 
-func (d *Decoder) readUint(b byte, v reflect.Value) error {
-	var (
-		rawNumber = []byte{b}
-		c         byte
-		err       error
-		num       float64
-	)
-	for {
-		if c, err = d.readByte(); err != nil {
+// readNonFinite reads the literal NaN or Infinity beginning with b, following
+// the sign, and returns it as strconv parses it.
+func (d *Decoder) readNonFinite(sign, b byte) ([]byte, error) {
+	literal := "Infinity"
+	if b == 'N' {
+		literal = "NaN"
+	}
+	for i := 1; i < len(literal); i++ {
+		c, err := d.readByte()
+		if err != nil {
 			if err == io.EOF {
-				break
+				d.eofIn = fmt.Sprintf("in literal %s (expecting %q)", literal, literal[i])
+				return nil, io.ErrUnexpectedEOF
 			}
-			return err
+			return nil, err
 		}
-		if c == '.' || c == 'e' || c == 'E' {
-			return d.readFloat(rawNumber, c, v)
+		if c != literal[i] {
+			return nil, d.syntaxErrorf("invalid character %q in literal %s (expecting %q)", c, literal, literal[i])
 		}
-		if c < '0' || c > '9' {
-			if err = d.unreadByte(); err != nil {
-				return err
+	}
+	raw := d.num[:0]
+	if sign == '-' && b == 'I' {
+		// strconv accepts no sign on NaN
+		raw = append(raw, '-')
+	}
+	return append(raw, literal...), nil
+}
+
+// readHexNumber reads the digits of a hexadecimal number whose 0x prefix has
+// been consumed and returns the number in decimal.
+func (d *Decoder) readHexNumber(negative bool) ([]byte, error) {
+	digits := d.num[:0]
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			if len(digits) == 0 {
+				d.eofIn = "in numeric literal"
+				return nil, io.ErrUnexpectedEOF
 			}
 			break
 		}
-		// Number must be minimally encoded
-		if rawNumber[0] == '0' {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			if len(digits) == 0 {
+				return nil, d.syntaxErrorf("invalid character %q in numeric literal", c)
+			}
+			if err = d.unreadByte(); err != nil {
+				return nil, err
+			}
 			break
 		}
-		rawNumber = append(rawNumber, c)
+		digits = append(digits, c)
+		if err = d.checkNumberLen(len(digits) + 2); err != nil {
+			return nil, err
+		}
+	}
+
+	// the decimal number replaces the digits
+	n, _ := new(big.Int).SetString(string(digits), 16)
+	raw := d.num[:0]
+	if negative {
+		raw = append(raw, '-')
 	}
-	num, _ = strconv.ParseFloat(string(rawNumber), 64)
+	return n.Append(raw, 10), nil
+}
+
+// setNumber stores the number literal raw in the value pointed to by v.
+func (d *Decoder) setNumber(raw []byte, v reflect.Value) error {
 	switch v.Elem().Kind() {
 	case reflect.Interface:
+		if v.Elem().NumMethod() != 0 {
+			return d.unmarshalTypeError("number", v.Elem().Type())
+		}
+		if d.rawNumber {
+			v.Elem().Set(reflect.ValueOf(Number(raw)))
+			break
+		}
+		num, err := parseFloat(raw, 64)
+		if err != nil {
+			typeErr := d.unmarshalTypeError("number "+string(raw), float64Type)
+			// encoding/json reads one more byte before converting numbers for
+			// interfaces
+			typeErr.Offset++
+			return typeErr
+		}
+		if err := d.checkPrecision(raw, num, float64Type); err != nil {
+			return err
+		}
 		v.Elem().Set(reflect.ValueOf(num))
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v.Elem().SetUint(uint64(num))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(string(raw), 10, 64)
+		if err != nil || v.Elem().OverflowUint(n) {
+			return d.unmarshalTypeError("number "+string(raw), v.Elem().Type())
+		}
+		v.Elem().SetUint(n)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v.Elem().SetInt(int64(num))
+		n, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || v.Elem().OverflowInt(n) {
+			return d.unmarshalTypeError("number "+string(raw), v.Elem().Type())
+		}
+		v.Elem().SetInt(n)
 	case reflect.Float32, reflect.Float64:
+		num, err := parseFloat(raw, v.Elem().Type().Bits())
+		if err != nil {
+			return d.unmarshalTypeError("number "+string(raw), v.Elem().Type())
+		}
+		if err := d.checkPrecision(raw, num, v.Elem().Type()); err != nil {
+			return err
+		}
 		v.Elem().SetFloat(num)
+	case reflect.String:
+		if v.Elem().Type() != numberType {
+			return d.unmarshalTypeError("number", v.Elem().Type())
+		}
+		v.Elem().SetString(string(raw))
 	default:
 		return d.unmarshalTypeError("number", v.Elem().Type())
 	}
 	return nil
 }
 
-func (d *Decoder) readInt(v reflect.Value) error {
-	var (
-		rawNumber []byte
-		c         byte
-		err       error
-		num       float64
-		expectEOF = false
-	)
+// readUint reads a number whose first digit b has been consumed.
+func (d *Decoder) readUint(b byte) ([]byte, error) {
+	return d.readIntPart(append(d.num[:0], b))
+}
+
+// readInt reads a negative number whose minus sign has been consumed.
+func (d *Decoder) readInt() ([]byte, error) {
+	c, err := d.readByte()
+	if err != nil {
+		if err == io.EOF {
+			d.eofIn = "in numeric literal"
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if c == 'I' && d.allowNonFinite {
+		return d.readNonFinite('-', c)
+	}
+	if c < '0' || c > '9' {
+		return nil, d.syntaxErrorf("invalid character %q in numeric literal", c)
+	}
+	return d.readIntPart(append(d.num[:0], '-', c))
+}
+
+// readIntPart reads the rest of a number whose integer part begins with raw,
+// which ends with the first digit. Numbers must be minimally encoded, so a
+// leading zero is the whole integer part. As in encoding/json the number ends
+// there and a digit following it is left unread, to be reported as an
+// invalid character after the value.
+func (d *Decoder) readIntPart(raw []byte) ([]byte, error) {
+	if err := d.checkNumberLen(len(raw)); err != nil {
+		return raw, err
+	}
+	leadingZero := raw[len(raw)-1] == '0'
 	for {
-		if c, err = d.readByte(); err != nil {
+		c, err := d.readByte()
+		if err != nil {
 			if err == io.EOF {
-				if expectEOF {
-					break
-				}
-				return io.ErrUnexpectedEOF
+				return raw, nil
 			}
-			return err
+			return raw, err
 		}
 		if c == '.' || c == 'e' || c == 'E' {
-			if len(rawNumber) == 0 {
-				return d.syntaxErrorf("invalid character '.' in numeric literal")
+			return d.readFloat(raw, c)
+		}
+		if c < '0' || c > '9' || leadingZero {
+			return raw, d.unreadByte()
+		}
+		raw = append(raw, c)
+		if err = d.checkNumberLen(len(raw)); err != nil {
+			return raw, err
+		}
+	}
+}
+
+// The parts of a number that readFloat reads, after the integer part.
+const (
+	// floatPoint follows the decimal point, a digit must come next.
+	floatPoint = iota
+	// floatFraction follows a digit of the fraction.
+	floatFraction
+	// floatE follows the e of the exponent, a sign or digit must come next.
+	floatE
+	// floatSign follows the sign of the exponent, a digit must come next.
+	floatSign
+	// floatExponent follows a digit of the exponent.
+	floatExponent
+)
+
+// readFloat reads the rest of the number b, whose integer part has been read
+// and followed by e, which is the decimal point or the e of the exponent. The
+// number ends at the first byte that cannot continue it, which is left unread,
+// but it must not end straight after the decimal point, the e, or the sign of
+// the exponent.
+func (d *Decoder) readFloat(b []byte, e byte) ([]byte, error) {
+	state := floatPoint
+	if e != '.' {
+		state = floatE
+	}
+	b = append(b, e)
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			if err != io.EOF {
+				return b, err
 			}
-			rawNumber = append([]byte{'-'}, rawNumber...)
-			return d.readFloat(rawNumber, c, v)
+			switch state {
+			case floatPoint:
+				d.eofIn = "after decimal point in numeric literal"
+				return b, io.ErrUnexpectedEOF
+			case floatE, floatSign:
+				d.eofIn = "in exponent of numeric literal"
+				return b, io.ErrUnexpectedEOF
+			}
+			return b, nil
 		}
-		if c < '0' || c > '9' {
-			if len(rawNumber) == 0 {
-				return d.syntaxErrorf("invalid character %q in numeric literal", c)
+
+		digit := c >= '0' && c <= '9'
+		switch state {
+		case floatPoint:
+			if !digit {
+				return b, d.syntaxErrorf("invalid character %q after decimal point in numeric literal", c)
 			}
-			if err = d.unreadByte(); err != nil {
-				return err
+			state = floatFraction
+		case floatFraction:
+			switch {
+			case c == 'e' || c == 'E':
+				state = floatE
+			case !digit:
+				return b, d.unreadByte()
+			}
+		case floatE:
+			switch {
+			case c == '-' || c == '+':
+				state = floatSign
+			case digit:
+				state = floatExponent
+			default:
+				return b, d.syntaxErrorf("invalid character %q in exponent of numeric literal", c)
+			}
+		case floatSign:
+			if !digit {
+				return b, d.syntaxErrorf("invalid character %q in exponent of numeric literal", c)
+			}
+			state = floatExponent
+		case floatExponent:
+			if !digit {
+				return b, d.unreadByte()
 			}
-			break
 		}
-		// Number must be minimally encoded
-		if len(rawNumber) > 0 && rawNumber[0] == '0' {
-			break
+		b = append(b, c)
+		if err = d.checkNumberLen(len(b)); err != nil {
+			return b, err
 		}
-		rawNumber = append(rawNumber, c)
-		expectEOF = true
 	}
-	num, _ = strconv.ParseFloat("-"+string(rawNumber), 64)
-	switch v.Elem().Kind() {
-	case reflect.Interface:
-		v.Elem().Set(reflect.ValueOf(num))
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return d.unmarshalTypeError("number -"+string(rawNumber), v.Elem().Type())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v.Elem().SetInt(int64(num))
-	case reflect.Float32, reflect.Float64:
-		v.Elem().SetFloat(num)
-	default:
-		return d.unmarshalTypeError("number", v.Elem().Type())
+}
+
+// checkPrecision returns an error in strict numbers mode if num does not hold
+// the exact value of the literal raw when stored in a value of type t.
+func (d *Decoder) checkPrecision(raw []byte, num float64, t reflect.Type) error {
+	if !d.strictNumbers || math.IsNaN(num) || bytes.HasSuffix(raw, []byte("Infinity")) {
+		// NaN and Infinity literals are exact
+		return nil
+	}
+	s := string(raw)
+	bitSize := t.Bits()
+	if bitSize == 32 {
+		num = float64(float32(num))
+	}
+	// Overflow and underflow are caught before the exact comparison, which
+	// would otherwise be expensive for literals with huge exponents.
+	if math.IsInf(num, 0) || num == 0 && strings.ContainsAny(strings.SplitN(strings.ToLower(s), "e", 2)[0], "123456789") {
+		return d.unmarshalTypeError("number "+s, t)
+	}
+	exact, _ := new(big.Rat).SetString(s)
+	stored, _ := new(big.Rat).SetString(strconv.FormatFloat(num, 'g', -1, bitSize))
+	if exact.Cmp(stored) != 0 {
+		return d.unmarshalTypeError("number "+s, t)
 	}
 	return nil
 }
 
-func (d *Decoder) readFloat(b []byte, e byte, v reflect.Value) error {
-	var (
-		c          byte
-		err        error
-		num        float64
-		expo       = false
-		signedExpo = false
-	)
-	b = append(b, e)
-	if e == 'e' || e == 'E' {
-		expo = true
+// position returns the line and column of the last byte read. A newline is the
+// last column of the line it ends.
+func (d *Decoder) position() (line, column int64) {
+	if d.offset == d.lineStart && d.line > 0 {
+		return d.line, d.offset - d.prevLineStart
 	}
-floatLoop:
-	for {
-		if c, err = d.readByte(); err != nil {
-			if err == io.EOF {
-				break
+	return d.line + 1, d.offset - d.lineStart
+}
+
+// checkLiteralLen returns an error if a string or number literal of n bytes is
+// longer than the Decoder allows.
+func (d *Decoder) checkLiteralLen(n int) error {
+	if d.maxStringLen > 0 && n > d.maxStringLen {
+		return &MaxStringLenError{
+			MaxStringLen: d.maxStringLen,
+			Offset:       d.offset,
+		}
+	}
+	return nil
+}
+
+// checkNumberLen returns an error if a number literal of n bytes is longer than
+// the Decoder allows.
+func (d *Decoder) checkNumberLen(n int) error {
+	if err := d.checkLiteralLen(n); err != nil {
+		return err
+	}
+	maxNumberLen := d.maxNumberLen
+	if maxNumberLen == 0 {
+		maxNumberLen = DefaultMaxNumberLen
+	}
+	if maxNumberLen > 0 && n > maxNumberLen {
+		return &MaxNumberLenError{
+			MaxNumberLen: maxNumberLen,
+			Offset:       d.offset,
+		}
+	}
+	return nil
+}
+
+// fill reads more input into the buffer once all of it has been consumed. The
+// last bytes consumed are kept so that one can still be unread, and for the
+// context of syntax errors.
+func (d *Decoder) fill() error {
+	if d.readErr != nil {
+		return d.readError()
+	}
+	if d.in == nil {
+		return io.EOF
+	}
+	if err := d.checkContext(); err != nil {
+		return err
+	}
+	if d.pos > contextWidth {
+		d.buf = d.buf[:copy(d.buf, d.buf[d.pos-contextWidth-1:])]
+		d.pos = len(d.buf)
+	}
+	if cap(d.buf) < readChunk {
+		d.buf = append(make([]byte, 0, readChunk), d.buf...)
+	}
+	// give up on readers that make no progress, as bufio does
+	for i := 0; i < 100; i++ {
+		n, err := d.in.Read(d.buf[len(d.buf):cap(d.buf)])
+		d.buf = d.buf[:len(d.buf)+n]
+		if n > 0 {
+			// an error is returned once the buffered input is consumed
+			d.readErr = err
+			return nil
+		}
+		if err != nil {
+			d.readErr = err
+			return d.readError()
+		}
+	}
+	return &DecodeError{Offset: d.offset, Err: io.ErrNoProgress}
+}
+
+// readError returns the error that reading the input failed with, wrapped in a
+// *DecodeError unless it is io.EOF.
+func (d *Decoder) readError() error {
+	if d.readErr == io.EOF {
+		return io.EOF
+	}
+	return &DecodeError{Offset: d.offset, Err: d.readErr}
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	if d.pos == len(d.buf) {
+		if err := d.fill(); err != nil {
+			return 0, err
+		}
+	}
+	if d.maxBytes > 0 && d.offset >= d.maxBytes {
+		return 0, &MaxBytesError{MaxBytes: d.maxBytes}
+	}
+	c := d.buf[d.pos]
+	d.pos++
+	d.offset++
+	if c == '\n' {
+		d.line++
+		d.prevLineStart, d.lineStart = d.lineStart, d.offset
+	}
+	if d.capturing {
+		d.raw = append(d.raw, c)
+	}
+	if d.teeing {
+		d.teeBuf = append(d.teeBuf, c)
+		if len(d.teeBuf) >= teeChunk {
+			// hold back the last byte in case it is unread
+			d.flushTee(len(d.teeBuf) - 1)
+		}
+	}
+	return c, nil
+}
+
+// readStringRun consumes the bytes of a string literal that need no unescaping,
+// up to the next quote q, backslash or invalid character in the buffer, and
+// returns them. The run is only valid until the buffer is next filled. n is the
+// length of the literal before the run.
+func (d *Decoder) readStringRun(n int, q byte) []byte {
+	end := d.readableEnd()
+	if d.maxStringLen > 0 && end-d.pos > d.maxStringLen-n+1 {
+		// stop one byte over the limit so that checkLiteralLen reports it
+		end = d.pos + d.maxStringLen - n + 1
+	}
+	i := scanStringWords(d.buf, d.pos, end, q)
+scan:
+	for ; i < end; i++ {
+		switch c := d.buf[i]; c {
+		case '\\', '\b', '\f', '\n', '\r', '\t':
+			break scan
+		default:
+			if c == q {
+				break scan
 			}
-			return err
 		}
-		switch {
-		case c == 'e', c == 'E':
-			if expo {
-				return d.syntaxErrorf("invalid character %q in exponent of numeric literal", c)
+	}
+	return d.consume(i - d.pos)
+}
+
+// readableEnd returns the end of the buffered input that may be read without
+// exceeding SetMaxBytes.
+func (d *Decoder) readableEnd() int {
+	end := len(d.buf)
+	if d.maxBytes > 0 && int64(end-d.pos) > d.maxBytes-d.offset {
+		end = d.pos + int(d.maxBytes-d.offset)
+	}
+	return end
+}
+
+// consume reads the next n buffered bytes, which hold no newline, as readByte
+// would and returns them. They are only valid until the buffer is next filled.
+func (d *Decoder) consume(n int) []byte {
+	run := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	d.offset += int64(n)
+	if d.capturing {
+		d.raw = append(d.raw, run...)
+	}
+	if d.teeing {
+		d.teeBuf = append(d.teeBuf, run...)
+		if len(d.teeBuf) >= teeChunk {
+			d.flushTee(len(d.teeBuf) - 1)
+		}
+	}
+	return run
+}
+
+// readNonSpace reads the next byte that isn't whitespace, or part of a comment
+// if they are allowed.
+func (d *Decoder) readNonSpace() (byte, error) {
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			return c, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			d.skipSpaceWords()
+		case '/':
+			if !d.allowComments {
+				return c, nil
 			}
-			expo = true
-		case c == '-', c == '+':
-			if signedExpo {
-				return d.syntaxErrorf("invalid character %q in exponent of numeric literal", c)
+			if err = d.skipComment(); err != nil {
+				return 0, err
 			}
-			signedExpo = true
-		case c >= '0' && c <= '9':
 		default:
-			if err = d.unreadByte(); err != nil {
-				return err
-			}
-			break floatLoop
+			return c, nil
 		}
-		b = append(b, c)
 	}
-	num, _ = strconv.ParseFloat(string(b), 64)
-	switch v.Elem().Kind() {
-	case reflect.Interface:
-		v.Elem().Set(reflect.ValueOf(num))
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return d.unmarshalTypeError("number "+string(b), v.Elem().Type())
-	case reflect.Float32, reflect.Float64:
-		v.Elem().SetFloat(num)
+}
+
+// skipSpace returns c if it is neither whitespace nor the start of an allowed
+// comment, otherwise it returns the next byte that is.
+func (d *Decoder) skipSpace(c byte) (byte, error) {
+	switch {
+	case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+	case c == '/' && d.allowComments:
+		if err := d.skipComment(); err != nil {
+			return 0, err
+		}
 	default:
-		return d.unmarshalTypeError("number", v.Elem().Type())
+		return c, nil
 	}
-	return nil
+	return d.readNonSpace()
 }
 
-func (d *Decoder) readByte() (byte, error) {
-	c, err := d.in.ReadByte()
+// skipComment consumes a comment whose opening slash has been read. A line
+// comment may be ended by the end of the input.
+func (d *Decoder) skipComment() error {
+	c, err := d.readByte()
 	if err != nil {
-		return 0, err
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
 	}
-	d.offset++
-	return c, nil
+	switch c {
+	case '/':
+		for c != '\n' {
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	case '*':
+		for star := false; ; star = c == '*' {
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if star && c == '/' {
+				break
+			}
+		}
+	default:
+		return d.syntaxErrorf("invalid character %q looking for beginning of comment", c)
+	}
+	return nil
 }
 
 func (d *Decoder) unreadByte() error {
-	if err := d.in.UnreadByte(); err != nil {
-		return err
+	if d.pos == 0 {
+		return errUnreadByte
+	}
+	d.pos--
+	if d.offset == d.lineStart && d.line > 0 {
+		// the newline is being unread
+		d.line--
+		d.lineStart = d.prevLineStart
 	}
 	d.offset--
+	if d.capturing {
+		d.raw = d.raw[:len(d.raw)-1]
+	}
+	if d.teeing {
+		d.teeBuf = d.teeBuf[:len(d.teeBuf)-1]
+	}
 	return nil
 }
 
-func (d *Decoder) unEscape() (byte, error) {
-	c, err := d.readByte()
+// unEscape reads an escape sequence whose backslash has been consumed and
+// appends the character it represents to buf. UTF-16 surrogate pairs are
+// combined and surrogates that are not part of a pair become U+FFFD.
+func (d *Decoder) unEscape(buf []byte) ([]byte, error) {
+	c, err := d.readEscapeByte()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	ec := escapable[c]
-	if ec == 0 {
-		return 0, d.syntaxErrorf("invalid character %q in string escape code", c)
+	if c != 'u' {
+		ec := d.escape(c)
+		if ec == 0 {
+			return nil, d.syntaxErrorf("invalid character %q in string escape code", c)
+		}
+		return append(buf, ec), nil
+	}
+
+	r, err := d.readHex()
+	if err != nil {
+		return nil, err
+	}
+	for utf16.IsSurrogate(r) {
+		if c, err = d.readByte(); err != nil {
+			return nil, err
+		}
+		if c != '\\' {
+			if err = d.unreadByte(); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if c, err = d.readEscapeByte(); err != nil {
+			return nil, err
+		}
+		if c != 'u' {
+			ec := d.escape(c)
+			if ec == 0 {
+				return nil, d.syntaxErrorf("invalid character %q in string escape code", c)
+			}
+			return append(utf8.AppendRune(buf, utf8.RuneError), ec), nil
+		}
+		r2, err := d.readHex()
+		if err != nil {
+			return nil, err
+		}
+		if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+			return utf8.AppendRune(buf, dec), nil
+		}
+		// r was not half of a pair, but r2 still might be
+		buf = utf8.AppendRune(buf, utf8.RuneError)
+		r = r2
+	}
+	// a lone surrogate is appended as U+FFFD
+	return utf8.AppendRune(buf, r), nil
+}
+
+// escape returns the byte the escape code c stands for, or 0 if c is not an
+// escape code.
+func (d *Decoder) escape(c byte) byte {
+	if c == '\'' && d.json5 {
+		return c
+	}
+	return escapable[c]
+}
+
+// readEscapeByte reads the byte following a backslash.
+func (d *Decoder) readEscapeByte() (byte, error) {
+	c, err := d.readByte()
+	if err == io.EOF {
+		d.eofIn = "in string escape code"
+	}
+	return c, err
+}
+
+// readHex reads the four hexadecimal digits of a \u escape.
+func (d *Decoder) readHex() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		c, err := d.readByte()
+		if err != nil {
+			if err == io.EOF {
+				d.eofIn = "in \\u hexadecimal character escape"
+			}
+			return 0, err
+		}
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c -= 'a' - 10
+		case 'A' <= c && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, d.syntaxErrorf("invalid character %q in \\u hexadecimal character escape", c)
+		}
+		r = r<<4 | rune(c)
 	}
-	return ec, nil
+	return r, nil
 }
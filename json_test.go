@@ -1,199 +1,261 @@
 package json
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
+	"net"
+	"net/netip"
+	"net/url"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unsafe"
 
+	"github.com/brackendawson/json/internal/fixture"
 	"github.com/intel-go/fastjson"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-func TestDecode(t *testing.T) {
-	tests := map[string][]byte{
-		"empty json": []byte(``),
-		"invalid":    []byte(`lol`),
-
-		"null":         []byte(`null`),
-		"short null":   []byte(`nul`),
-		"shorter null": []byte(`n`),
-		"wrong null":   []byte(`nil`),
-		"trailed null": []byte(`[null,1]`),
-
-		"true":          []byte(`true`),
-		"false":         []byte(`false`),
-		"invalid true":  []byte(`ture`),
-		"invalid false": []byte(`fsale`),
-		"short true":    []byte(`tru`),
-		"short false":   []byte(`fals`),
-		"shorter true":  []byte(`t`),
-		"shorter false": []byte(`f`),
-
-		"unterm empty string": []byte(`"`),
-		"unterm string":       []byte(`" `),
-		"empty string":        []byte(`""`),
-		"small string":        []byte(`" "`),
-		"string":              []byte(`"string"`),
-		"path string":         []byte(`"/usr/local/bin/go"`),
-		"longer string":       []byte(`"longer string`),
-		"emoji string":        []byte(`"🚀"`),
-		"more emoji string":   []byte(`"I 👏 love 👏 emoji 👏"`),
-		"multiline string":    []byte("\"not\nallowed\""),
-		"windows string":      []byte("\"not\r\nallowed\""),
-		"backspace string":    []byte("\"oops\b\b\b\b\""),
-		"formfeed string":     []byte("\"what even is a form feed?\f\""),
-		"tab string":          []byte("\"tabs\tbreak\tit\""),
-		"esc valids string":   []byte(`"newline \n return \r backspace \b formfeed \f tab \t backslash \\ quote \""`),
-		"empty esc string":    []byte(`"(for offset)\"`),
-		"invalid esc string":  []byte(`"(for an offset)\a(padding)"`),
-		// encoding/json handes invalid UTF8 ungracefully https://github.com/golang/go/issues/16282
-		// "invalid utf8 2/2 string": []byte("\"\xc3\x28\""),
-		// "invalid utf8 2/3 string": []byte("\"\xe2\x28\xa1\""),
-		// "invalid utf8 3/3 string": []byte("\"\xe2\x82\x28\""),
-		// "invalid utf8 2/4 string": []byte("\"\xf0\x28\x8c\xbc\""),
-		// "invalid utf8 3/4 string": []byte("\"\xf0\x90\x28\xbc\""),
-		// "invalid utf8 4/4 string": []byte("\"\xf0\x28\x8c\x28\""),
-		"whitespace string":       []byte(" \t\r\n \"string with whitespace\" \t\r\n "),
-		"formfeed space":          []byte("\f\"what even is a form feed?\""),
-		"two strings":             []byte(`"cant have""two strings"`),
-		"spaced strings":          []byte(`   "cant have"   "two strings"   `),
-		"trailing invalid string": []byte(`"duck duck" goose`),
-
-		"number 0":                              []byte(`0`),
-		"number 1":                              []byte(`1`),
-		"number 42":                             []byte(`42`),
-		"number 32768":                          []byte(`32768`),
-		"number MaxUint64":                      []byte(strconv.FormatUint(math.MaxUint64, 10)),
-		"number -1":                             []byte(`-1`),
-		"number -666":                           []byte(`-666`),
-		"number MinInt64":                       []byte(strconv.FormatInt(math.MinInt64, 10)),
-		"number -0":                             []byte(`-0`),
-		"number 0.1":                            []byte(`0.1`),
-		"number 3.141592654":                    []byte(`3.141592654`),
-		"number 1000.1":                         []byte(`1000.1`),
-		"number -0.1":                           []byte(`-0.1`),
-		"number -999.999":                       []byte(`-999.999`),
-		"number SmallestNonZeroFloat64":         []byte(strconv.FormatFloat(math.SmallestNonzeroFloat64, 'f', -1, 64)),
-		"number MaxFloat64":                     []byte(strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64)),
-		"number SmallestNonZeroNegativeFloat64": []byte(strconv.FormatFloat(-math.SmallestNonzeroFloat64, 'f', -1, 64)),
-		"number MinFloat64":                     []byte(strconv.FormatFloat(-math.MaxFloat64, 'f', -1, 64)),
-		"number .0":                             []byte(`.0`),
-		"number .1":                             []byte(`.1`),
-		"number -.1":                            []byte(`-.1`),
-		"number 01":                             []byte(`01`),
-		"number 001":                            []byte(`001`),
-		"number -01":                            []byte(`-01`),
-		"number -001":                           []byte(`-001`),
-		"number 1.2.3":                          []byte(`1.2.3`),
-		"number 1.2.3.4":                        []byte(`1.2.3.4`),
-		"number -1.2.3":                         []byte(`-1.2.3`),
-		"number -1.2.3.4":                       []byte(`-1.2.3.4`),
-		"number -":                              []byte(`-`),
-		"number --1":                            []byte(`--1`),
-		"number -1-":                            []byte(`-1-`),
-		"number -1-2":                           []byte(`-1-2`),
-		"number 1-2":                            []byte(`1-2`),
-		"number -a":                             []byte(`-a`),
-		"number 0a":                             []byte(`0a`),
-		"number -0a":                            []byte(`-0a`),
-		"number 5345j345":                       []byte(`5345j345`),
-		"number -5345j345":                      []byte(`-5345j345`),
-		"number 5.345j345":                      []byte(`5.345j345`),
-		"number -5.345j345":                     []byte(`-5.345j345`),
-		"number 0x1":                            []byte(`0x1`),
-		"number 1e6":                            []byte(`1e6`),
-		"number 1.1e6":                          []byte(`1.1e6`),
-		"number 1E6":                            []byte(`1E6`),
-		"number 1.1E6":                          []byte(`1.1E6`),
-		"number 1e-6":                           []byte(`1e-6`),
-		"number 1.1e-6":                         []byte(`1.1e-6`),
-		"number 1E-6":                           []byte(`1E-6`),
-		"number 1.1E-6":                         []byte(`1.1E-6`),
-		"number 1e+6":                           []byte(`1e+6`),
-		"number 1.1e+6":                         []byte(`1.1e+6`),
-		"number 1E+6":                           []byte(`1E+6`),
-		"number 1.1E+6":                         []byte(`1.1E+6`),
-		"number -1e6":                           []byte(`-1e6`),
-		"number -1.1e6":                         []byte(`-1.1e6`),
-		"number -1E6":                           []byte(`-1E6`),
-		"number -1.1E6":                         []byte(`-1.1E6`),
-		"number -1e-6":                          []byte(`-1e-6`),
-		"number -1.1e-6":                        []byte(`-1.1e-6`),
-		"number -1E-6":                          []byte(`-1E-6`),
-		"number -1.1E-6":                        []byte(`-1.1E-6`),
-		"number -1e+6":                          []byte(`-1e+6`),
-		"number -1.1e+6":                        []byte(`-1.1e+6`),
-		"number -1E+6":                          []byte(`-1E+6`),
-		"number -1.1E+6":                        []byte(`-1.1E+6`),
-		"number 1ee6":                           []byte(`1ee6`),
-		"number 1eE6":                           []byte(`1eE6`),
-		"number 1Ee6":                           []byte(`1Ee6`),
-		"number 1EE6":                           []byte(`1EE6`),
-		"number 1e--6":                          []byte(`1e--6`),
-		"number 1e-+6":                          []byte(`1e-+6`),
-		"number 1e+-6":                          []byte(`1e+-6`),
-		"number 1e++6":                          []byte(`1e++6`),
-		"number 1e6j7":                          []byte(`1e6j7`),
-		"number 0e6":                            []byte(`0e6`),
-		"number -1ee6":                          []byte(`-1ee6`),
-		"number -1e--6":                         []byte(`-1e--6`),
-		"number -1.1ee6":                        []byte(`-1.1ee6`),
-		"number -1.1e--6":                       []byte(`-1.1e--6`),
-		"number 1.1ee6":                         []byte(`1.1ee6`),
-		"number 1.1e--6":                        []byte(`1.1e--6`),
-
-		"empty array":    []byte(`[]`),
-		"1 num array":    []byte(`[1]`),
-		"2 num array":    []byte(`[1,2]`),
-		"3 num array":    []byte(`[-1,0,1]`),
-		"1 string array": []byte(`["lol"]`),
-		"2 string array": []byte(`["lol","wot"]`),
-		"1 bool array":   []byte(`[true]`),
-		"2 bool array":   []byte(`[true, false]`),
-		"1 float array":  []byte(`[1.1]`),
-		"2 float array":  []byte(`[1.1,-2.2]`),
-		"mixed array":    []byte(`[42,-7,3.141592654,"hello\nworld\n",true]`),
-		"spaced array":   []byte(" \t\n\r [ \t\n\r 42 \t\n\r , \t\n\r -7 \t\n\r ,  3.141592654  ,  \"hello\\nworld\\n\"  ,  true \t\n\r ] \t\n\r "),
-		"smnested array": []byte(`[[[1]]]`),
-		"nested array":   []byte(`[[1,2],[3,4]]`),
-		"very nested array": []byte(`[[[1,2,3],[4,5,6],[7,8,9]],
+var decodeTests = map[string][]byte{
+	"empty json": []byte(``),
+	"invalid":    []byte(`lol`),
+
+	"null":         []byte(`null`),
+	"short null":   []byte(`nul`),
+	"shorter null": []byte(`n`),
+	"wrong null":   []byte(`nil`),
+	"trailed null": []byte(`[null,1]`),
+
+	"true":          []byte(`true`),
+	"false":         []byte(`false`),
+	"invalid true":  []byte(`ture`),
+	"invalid false": []byte(`fsale`),
+	"short true":    []byte(`tru`),
+	"short false":   []byte(`fals`),
+	"shorter true":  []byte(`t`),
+	"shorter false": []byte(`f`),
+
+	"unterm empty string": []byte(`"`),
+	"unterm string":       []byte(`" `),
+	"empty string":        []byte(`""`),
+	"small string":        []byte(`" "`),
+	"string":              []byte(`"string"`),
+	"path string":         []byte(`"/usr/local/bin/go"`),
+	"longer string":       []byte(`"longer string`),
+	"emoji string":        []byte(`"🚀"`),
+	"more emoji string":   []byte(`"I 👏 love 👏 emoji 👏"`),
+	"multiline string":    []byte("\"not\nallowed\""),
+	"windows string":      []byte("\"not\r\nallowed\""),
+	"backspace string":    []byte("\"oops\b\b\b\b\""),
+	"formfeed string":     []byte("\"what even is a form feed?\f\""),
+	"tab string":          []byte("\"tabs\tbreak\tit\""),
+	"esc valids string":   []byte(`"newline \n return \r backspace \b formfeed \f tab \t backslash \\ quote \""`),
+	"esc solidus string":  []byte(`"http:\/\/example.com\/"`),
+	"empty esc string":    []byte(`"(for offset)\"`),
+	"invalid esc string":  []byte(`"(for an offset)\a(padding)"`),
+	"spaced empty array":  []byte("[ \n]"),
+	"spaced empty object": []byte("{\t }"),
+	"chunked string":      []byte(`"` + strings.Repeat("x", 4093) + `\u00e9` + strings.Repeat("y", 5000) + `"`),
+	"chunked bad string":  []byte(`"` + strings.Repeat("x", 5000) + "\t\""),
+
+	"unicode esc string":        []byte(`"\u0041\u00e9\u6F22\u0000\u001f\u2028"`),
+	"surrogate pair string":     []byte(`"rocket \ud83d\ude80!"`),
+	"upper surrogate string":    []byte(`"\uD83D\uDE80"`),
+	"lone high string":          []byte(`"\ud83d"`),
+	"lone high char string":     []byte(`"\ud83dx"`),
+	"lone low string":           []byte(`"\ude80\ud83d\ude80"`),
+	"high high low string":      []byte(`"\ud83d\ud83d\ude80"`),
+	"high esc string":           []byte(`"\ud83d\n"`),
+	"high bmp string":           []byte(`"\ud83d\u0041"`),
+	"high bad esc string":       []byte(`"\ud83d\x"`),
+	"short unicode esc string":  []byte(`"\u12"`),
+	"bad hex string":            []byte(`"\u12g4"`),
+	"bad low hex string":        []byte(`"\ud83d\u12g4"`),
+	"unterm unicode esc string": []byte(`"\u12`),
+	"unterm surrogate string":   []byte(`"\ud83d\`),
+	"escaped key object":        []byte(`{"\u0061":1}`),
+	"invalid utf8 2/2 string":   []byte("\"\xc3\x28\""),
+	"invalid utf8 2/3 string":   []byte("\"\xe2\x28\xa1\""),
+	"invalid utf8 3/3 string":   []byte("\"\xe2\x82\x28\""),
+	"invalid utf8 2/4 string":   []byte("\"\xf0\x28\x8c\xbc\""),
+	"invalid utf8 3/4 string":   []byte("\"\xf0\x90\x28\xbc\""),
+	"invalid utf8 4/4 string":   []byte("\"\xf0\x28\x8c\x28\""),
+	"whitespace string":         []byte(" \t\r\n \"string with whitespace\" \t\r\n "),
+	"formfeed space":            []byte("\f\"what even is a form feed?\""),
+	"two strings":               []byte(`"cant have""two strings"`),
+	"spaced strings":            []byte(`   "cant have"   "two strings"   `),
+	"trailing invalid string":   []byte(`"duck duck" goose`),
+
+	"number 0":                              []byte(`0`),
+	"number 1":                              []byte(`1`),
+	"number 42":                             []byte(`42`),
+	"number 32768":                          []byte(`32768`),
+	"number MaxUint64":                      []byte(strconv.FormatUint(math.MaxUint64, 10)),
+	"number -1":                             []byte(`-1`),
+	"number -666":                           []byte(`-666`),
+	"number MinInt64":                       []byte(strconv.FormatInt(math.MinInt64, 10)),
+	"number -0":                             []byte(`-0`),
+	"number 0.1":                            []byte(`0.1`),
+	"number 3.141592654":                    []byte(`3.141592654`),
+	"number 1000.1":                         []byte(`1000.1`),
+	"number -0.1":                           []byte(`-0.1`),
+	"number -999.999":                       []byte(`-999.999`),
+	"number SmallestNonZeroFloat64":         []byte(strconv.FormatFloat(math.SmallestNonzeroFloat64, 'f', -1, 64)),
+	"number MaxFloat64":                     []byte(strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64)),
+	"number SmallestNonZeroNegativeFloat64": []byte(strconv.FormatFloat(-math.SmallestNonzeroFloat64, 'f', -1, 64)),
+	"number MinFloat64":                     []byte(strconv.FormatFloat(-math.MaxFloat64, 'f', -1, 64)),
+	"number .0":                             []byte(`.0`),
+	"number .1":                             []byte(`.1`),
+	"number -.1":                            []byte(`-.1`),
+	"number 01":                             []byte(`01`),
+	"number 001":                            []byte(`001`),
+	"number -01":                            []byte(`-01`),
+	"number -001":                           []byte(`-001`),
+	"number 00.5":                           []byte(`00.5`),
+	"number 01e2":                           []byte(`01e2`),
+	"number -00":                            []byte(`-00`),
+	"number -01.5":                          []byte(`-01.5`),
+	"number -0.5":                           []byte(`-0.5`),
+	"number -0e-1":                          []byte(`-0e-1`),
+	"number 0-":                             []byte(`0-`),
+	"number -I":                             []byte(`-I`),
+	"number - 1":                            []byte(`- 1`),
+	"number [01]":                           []byte(`[01]`),
+	"number [-]":                            []byte(`[-]`),
+	"number [-01,1]":                        []byte(`[-01,1]`),
+	"number {-01}":                          []byte(`{"a":-01}`),
+	"number 1.2.3":                          []byte(`1.2.3`),
+	"number 1.2.3.4":                        []byte(`1.2.3.4`),
+	"number -1.2.3":                         []byte(`-1.2.3`),
+	"number -1.2.3.4":                       []byte(`-1.2.3.4`),
+	"number -":                              []byte(`-`),
+	"number --1":                            []byte(`--1`),
+	"number -1-":                            []byte(`-1-`),
+	"number -1-2":                           []byte(`-1-2`),
+	"number 1-2":                            []byte(`1-2`),
+	"number -a":                             []byte(`-a`),
+	"number 0a":                             []byte(`0a`),
+	"number -0a":                            []byte(`-0a`),
+	"number 5345j345":                       []byte(`5345j345`),
+	"number -5345j345":                      []byte(`-5345j345`),
+	"number 5.345j345":                      []byte(`5.345j345`),
+	"number -5.345j345":                     []byte(`-5.345j345`),
+	"number 0x1":                            []byte(`0x1`),
+	"number 1.":                             []byte(`1.`),
+	"number -1.":                            []byte(`-1.`),
+	"number 1e":                             []byte(`1e`),
+	"number 1.5E":                           []byte(`1.5E`),
+	"number 1e-":                            []byte(`1e-`),
+	"number -1e+":                           []byte(`-1e+`),
+	"number 1e6":                            []byte(`1e6`),
+	"number 1.1e6":                          []byte(`1.1e6`),
+	"number 1E6":                            []byte(`1E6`),
+	"number 1.1E6":                          []byte(`1.1E6`),
+	"number 1e-6":                           []byte(`1e-6`),
+	"number 1.1e-6":                         []byte(`1.1e-6`),
+	"number 1E-6":                           []byte(`1E-6`),
+	"number 1.1E-6":                         []byte(`1.1E-6`),
+	"number 1e+6":                           []byte(`1e+6`),
+	"number 1.1e+6":                         []byte(`1.1e+6`),
+	"number 1E+6":                           []byte(`1E+6`),
+	"number 1.1E+6":                         []byte(`1.1E+6`),
+	"number -1e6":                           []byte(`-1e6`),
+	"number -1.1e6":                         []byte(`-1.1e6`),
+	"number -1E6":                           []byte(`-1E6`),
+	"number -1.1E6":                         []byte(`-1.1E6`),
+	"number -1e-6":                          []byte(`-1e-6`),
+	"number -1.1e-6":                        []byte(`-1.1e-6`),
+	"number -1E-6":                          []byte(`-1E-6`),
+	"number -1.1E-6":                        []byte(`-1.1E-6`),
+	"number -1e+6":                          []byte(`-1e+6`),
+	"number -1.1e+6":                        []byte(`-1.1e+6`),
+	"number -1E+6":                          []byte(`-1E+6`),
+	"number -1.1E+6":                        []byte(`-1.1E+6`),
+	"number 1ee6":                           []byte(`1ee6`),
+	"number 1eE6":                           []byte(`1eE6`),
+	"number 1Ee6":                           []byte(`1Ee6`),
+	"number 1EE6":                           []byte(`1EE6`),
+	"number 1e--6":                          []byte(`1e--6`),
+	"number 1e-+6":                          []byte(`1e-+6`),
+	"number 1e+-6":                          []byte(`1e+-6`),
+	"number 1e++6":                          []byte(`1e++6`),
+	"number 1e6j7":                          []byte(`1e6j7`),
+	"number 0e6":                            []byte(`0e6`),
+	"number -1ee6":                          []byte(`-1ee6`),
+	"number -1e--6":                         []byte(`-1e--6`),
+	"number -1.1ee6":                        []byte(`-1.1ee6`),
+	"number -1.1e--6":                       []byte(`-1.1e--6`),
+	"number 1.1ee6":                         []byte(`1.1ee6`),
+	"number 1.1e--6":                        []byte(`1.1e--6`),
+	"number 1.e3":                           []byte(`1.e3`),
+	"number 1.5-3":                          []byte(`1.5-3`),
+	"number 1.1e6-2":                        []byte(`1.1e6-2`),
+	"number 1e3.5":                          []byte(`1e3.5`),
+	"number 1e3e":                           []byte(`1e3e`),
+	"number 1.5e+":                          []byte(`1.5e+`),
+	"number [1.]":                           []byte(`[1.]`),
+	"number [1e]":                           []byte(`[1e]`),
+	"number [1e-]":                          []byte(`[1e-]`),
+	"number [1.,2]":                         []byte(`[1.,2]`),
+	"number {1E}":                           []byte(`{"a":1E}`),
+
+	"empty array":    []byte(`[]`),
+	"1 num array":    []byte(`[1]`),
+	"2 num array":    []byte(`[1,2]`),
+	"3 num array":    []byte(`[-1,0,1]`),
+	"1 string array": []byte(`["lol"]`),
+	"2 string array": []byte(`["lol","wot"]`),
+	"1 bool array":   []byte(`[true]`),
+	"2 bool array":   []byte(`[true, false]`),
+	"1 float array":  []byte(`[1.1]`),
+	"2 float array":  []byte(`[1.1,-2.2]`),
+	"mixed array":    []byte(`[42,-7,3.141592654,"hello\nworld\n",true]`),
+	"spaced array":   []byte(" \t\n\r [ \t\n\r 42 \t\n\r , \t\n\r -7 \t\n\r ,  3.141592654  ,  \"hello\\nworld\\n\"  ,  true \t\n\r ] \t\n\r "),
+	"smnested array": []byte(`[[[1]]]`),
+	"nested array":   []byte(`[[1,2],[3,4]]`),
+	"very nested array": []byte(`[[[1,2,3],[4,5,6],[7,8,9]],
 		[["a","b","c"],["d","e","f"],["g","h","i"]],
 			[[true,false,true],[false,true,false],[true,false,true]]]`),
-		"unterm array":      []byte(`[`),
-		"unterm2 array":     []byte(`["`),
-		"unterm3 array":     []byte(`["a`),
-		"unterm4 array":     []byte(`["a"`),
-		"unterm5 array":     []byte(`["a",`),
-		"unterm6 array":     []byte(`["a","`),
-		"unterm7 array":     []byte(`["a","b`),
-		"unterm8 array":     []byte(`["a","b"`),
-		"unexpect array":    []byte(`~["a","b"]`),
-		"unexpect2 array":   []byte(`[~"a","b"]`),
-		"unexpect3 array":   []byte(`["a"~,"b"]`),
-		"unexpect4 array":   []byte(`["a",~"b"]`),
-		"unexpect5 array":   []byte(`["a","b"~]`),
-		"unexpect6 array":   []byte(`["a","b"]~`),
-		"unterm popd array": []byte(`[1`),
-		"unterm sepd array": []byte(`[1,`),
-		"early termd array": []byte(`[1,]`),
-		"unsepd array":      []byte(`[1 2]`),
-		"trailed array":     []byte(`[1,2]trail`),
-		"doublesepd array":  []byte(`[1,,2]`),
-		"valueless array":   []byte(`[,]`),
-
-		"empty object":  []byte(`{}`),
-		"simple object": []byte(`{"a":1}`),
-		"bigger object": []byte(`{"a":1,"b":2}`),
-		"spaced object": []byte(" \t\r\n { \t\r\n \"a\" \t\r\n : \t\r\n 1 \t\r\n , \t\r\n \"b\" \t\r\n : \t\r\n 2 \t\r\n } \t\r\n "),
-		"mixed object": []byte(`{
+	"unterm array":      []byte(`[`),
+	"unterm2 array":     []byte(`["`),
+	"unterm3 array":     []byte(`["a`),
+	"unterm4 array":     []byte(`["a"`),
+	"unterm5 array":     []byte(`["a",`),
+	"unterm6 array":     []byte(`["a","`),
+	"unterm7 array":     []byte(`["a","b`),
+	"unterm8 array":     []byte(`["a","b"`),
+	"unexpect array":    []byte(`~["a","b"]`),
+	"unexpect2 array":   []byte(`[~"a","b"]`),
+	"unexpect3 array":   []byte(`["a"~,"b"]`),
+	"unexpect4 array":   []byte(`["a",~"b"]`),
+	"unexpect5 array":   []byte(`["a","b"~]`),
+	"unexpect6 array":   []byte(`["a","b"]~`),
+	"unterm popd array": []byte(`[1`),
+	"unterm sepd array": []byte(`[1,`),
+	"early termd array": []byte(`[1,]`),
+	"unsepd array":      []byte(`[1 2]`),
+	"trailed array":     []byte(`[1,2]trail`),
+	"doublesepd array":  []byte(`[1,,2]`),
+	"valueless array":   []byte(`[,]`),
+
+	"empty object":  []byte(`{}`),
+	"simple object": []byte(`{"a":1}`),
+	"bigger object": []byte(`{"a":1,"b":2}`),
+	"spaced object": []byte(" \t\r\n { \t\r\n \"a\" \t\r\n : \t\r\n 1 \t\r\n , \t\r\n \"b\" \t\r\n : \t\r\n 2 \t\r\n } \t\r\n "),
+	"mixed object": []byte(`{
 			"string":	"hi",
 			"uint":		1,
 			"int":		-1,
@@ -202,39 +264,39 @@ func TestDecode(t *testing.T) {
 			"array":	[],
 			"object":	{}
 		}`),
-		"unterm object":     []byte(`{`),
-		"unterm2 object":    []byte(`{"`),
-		"unterm3 object":    []byte(`{"a`),
-		"unterm4 object":    []byte(`{"a"`),
-		"unterm5 object":    []byte(`{"a":`),
-		"unterm6 object":    []byte(`{"a":"`),
-		"unterm7 object":    []byte(`{"a":"a`),
-		"unterm8 object":    []byte(`{"a":"a"`),
-		"unterm9 object":    []byte(`{"a":"a",`),
-		"unterm10 object":   []byte(`{"a":"a","`),
-		"unterm11 object":   []byte(`{"a":"a","b`),
-		"unterm12 object":   []byte(`{"a":"a","b"`),
-		"unterm13 object":   []byte(`{"a":"a","b":`),
-		"unterm14 object":   []byte(`{"a":"a","b":"`),
-		"unterm15 object":   []byte(`{"a":"a","b":"b`),
-		"unterm16 object":   []byte(`{"a":"a","b":"b"`),
-		"unexpect object":   []byte(`~{"a":"a","b":"b"}`),
-		"unexpect2 object":  []byte(`{~"a":"a","b":"b"}`),
-		"unexpect3 object":  []byte(`{"a"~:"a","b":"b"}`),
-		"unexpect4 object":  []byte(`{"a":~"a","b":"b"}`),
-		"unexpect5 object":  []byte(`{"a":"a"~,"b":"b"}`),
-		"unexpect6 object":  []byte(`{"a":"a",~"b":"b"}`),
-		"unexpect7 object":  []byte(`{"a":"a","b"~:"b"}`),
-		"unexpect8 object":  []byte(`{"a":"a","b":~"b"}`),
-		"unexpect9 object":  []byte(`{"a":"a","b":"b"~}`),
-		"unexpect10 object": []byte(`{"a":"a","b":"b"}~`),
-		"invalid object":    []byte(`{1:1}`),
-		"invalid2 object":   []byte(`{-1:1}`),
-		"invalid3 object":   []byte(`{1.1:1}`),
-		"invalid4 object":   []byte(`{true:1}`),
-		"invalid5 object":   []byte(`{[]:1}`),
-		"invalid6 object":   []byte(`{{}:1}`),
-		"nested object": []byte(`{
+	"unterm object":     []byte(`{`),
+	"unterm2 object":    []byte(`{"`),
+	"unterm3 object":    []byte(`{"a`),
+	"unterm4 object":    []byte(`{"a"`),
+	"unterm5 object":    []byte(`{"a":`),
+	"unterm6 object":    []byte(`{"a":"`),
+	"unterm7 object":    []byte(`{"a":"a`),
+	"unterm8 object":    []byte(`{"a":"a"`),
+	"unterm9 object":    []byte(`{"a":"a",`),
+	"unterm10 object":   []byte(`{"a":"a","`),
+	"unterm11 object":   []byte(`{"a":"a","b`),
+	"unterm12 object":   []byte(`{"a":"a","b"`),
+	"unterm13 object":   []byte(`{"a":"a","b":`),
+	"unterm14 object":   []byte(`{"a":"a","b":"`),
+	"unterm15 object":   []byte(`{"a":"a","b":"b`),
+	"unterm16 object":   []byte(`{"a":"a","b":"b"`),
+	"unexpect object":   []byte(`~{"a":"a","b":"b"}`),
+	"unexpect2 object":  []byte(`{~"a":"a","b":"b"}`),
+	"unexpect3 object":  []byte(`{"a"~:"a","b":"b"}`),
+	"unexpect4 object":  []byte(`{"a":~"a","b":"b"}`),
+	"unexpect5 object":  []byte(`{"a":"a"~,"b":"b"}`),
+	"unexpect6 object":  []byte(`{"a":"a",~"b":"b"}`),
+	"unexpect7 object":  []byte(`{"a":"a","b"~:"b"}`),
+	"unexpect8 object":  []byte(`{"a":"a","b":~"b"}`),
+	"unexpect9 object":  []byte(`{"a":"a","b":"b"~}`),
+	"unexpect10 object": []byte(`{"a":"a","b":"b"}~`),
+	"invalid object":    []byte(`{1:1}`),
+	"invalid2 object":   []byte(`{-1:1}`),
+	"invalid3 object":   []byte(`{1.1:1}`),
+	"invalid4 object":   []byte(`{true:1}`),
+	"invalid5 object":   []byte(`{[]:1}`),
+	"invalid6 object":   []byte(`{{}:1}`),
+	"nested object": []byte(`{
 			"arrays":	{
 				"of int":		[1,2,3],
 				"of string":	["a","b","c"],
@@ -259,8 +321,10 @@ func TestDecode(t *testing.T) {
 				}
 			}
 		}`),
-	}
-	for name, input := range tests {
+}
+
+func TestDecode(t *testing.T) {
+	for name, input := range decodeTests {
 		t.Run(name, func(t *testing.T) {
 			t.Log("Input: ", string(input))
 			t.Log("Raw: ", input)
@@ -280,6 +344,387 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeOneByteReader(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			var data, dataJ interface{}
+			errJ := json.NewDecoder(iotest.OneByteReader(bytes.NewReader(input))).Decode(&dataJ)
+			err := NewDecoder(iotest.OneByteReader(bytes.NewReader(input))).Decode(&data)
+			assert.Equal(t, dataJ, data)
+			eqaulError(t, errJ, err)
+		})
+	}
+}
+
+func TestDecodeSkip(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			var v interface{}
+			expected := NewDecoder(bytes.NewReader(input)).Decode(&v)
+			assert.Equal(t, expected, NewDecoder(bytes.NewReader(input)).Skip())
+		})
+	}
+}
+
+func TestUnmarshalAs(t *testing.T) {
+	s, err := UnmarshalAs[decodeStruct]([]byte(`{"A": "a", "bee": 2, "Inner": {"X": 1}}`))
+	require.NoError(t, err)
+	assert.Equal(t, decodeStruct{A: "a", B: 2, Inner: decodeInner{X: 1}}, s)
+
+	m, err := UnmarshalAs[map[string][]int]([]byte(`{"a": [1, 2]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"a": {1, 2}}, m)
+
+	p, err := UnmarshalAs[*int]([]byte(`3`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, *p)
+
+	n, err := UnmarshalAs[int]([]byte(`1.5`))
+	assert.EqualError(t, err, "json: cannot unmarshal number 1.5 into Go value of type int")
+	assert.Zero(t, n)
+
+	_, err = UnmarshalAs[interface{}]([]byte(`[1,`))
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
+
+type concreteInner struct {
+	A, B int
+}
+
+func (c *concreteInner) String() string { return fmt.Sprint(c.A, c.B) }
+
+type concreteOuter struct {
+	S fmt.Stringer
+	E interface{}
+}
+
+func TestDecodeConcreteInterface(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		dest  func() interface{}
+	}{
+		"stringer":     {`{"B": 2}`, func() interface{} { var s fmt.Stringer = &concreteInner{A: 1}; return &s }},
+		"empty":        {`{"B": 2}`, func() interface{} { var e interface{} = &concreteInner{A: 1}; return &e }},
+		"number":       {`3`, func() interface{} { var e interface{} = new(int); return &e }},
+		"string":       {`"s"`, func() interface{} { var e interface{} = new(string); return &e }},
+		"null":         {`null`, func() interface{} { var e interface{} = new(int); return &e }},
+		"null pointer": {`null`, func() interface{} { var e interface{} = new(*int); return &e }},
+		"not pointer":  {`{"B": 2}`, func() interface{} { var e interface{} = concreteInner{A: 1}; return &e }},
+		"nil pointer":  {`{"B": 2}`, func() interface{} { var s fmt.Stringer = (*concreteInner)(nil); return &s }},
+		"wrong type":   {`[1]`, func() interface{} { var s fmt.Stringer = &concreteInner{A: 1}; return &s }},
+		"fields": {`{"S": {"B": 2}, "E": {"B": 3}}`, func() interface{} {
+			return &concreteOuter{S: &concreteInner{A: 1}, E: &concreteInner{A: 1}}
+		}},
+		"field null": {`{"S": null, "E": null}`, func() interface{} {
+			return &concreteOuter{S: &concreteInner{A: 1}, E: &concreteInner{A: 1}}
+		}},
+		"field wrong type": {`{"S": "s"}`, func() interface{} {
+			return &concreteOuter{S: &concreteInner{A: 1}}
+		}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected, actual := test.dest(), test.dest()
+			expectedErr := json.Unmarshal([]byte(test.input), expected)
+			actualErr := Unmarshal([]byte(test.input), actual)
+			if expectedErr != nil {
+				assert.EqualError(t, actualErr, expectedErr.Error())
+			} else {
+				assert.NoError(t, actualErr)
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestUnmarshalSyntaxErrorFirst(t *testing.T) {
+	for _, input := range []string{
+		`"string" x`,
+		`{"bee": "b", "C": 1 x`,
+		`[1, "a"] ]`,
+		`{"bee": "b"} 1`,
+	} {
+		var expected, actual decodeStruct
+		expectedErr := json.Unmarshal([]byte(input), &expected)
+		actualErr := Unmarshal([]byte(input), &actual)
+		eqaulError(t, expectedErr, actualErr)
+	}
+}
+
+func TestValid(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, json.Valid(input), Valid(input))
+
+			var v interface{}
+			expected := Unmarshal(input, &v)
+			assert.Equal(t, expected, ValidReader(iotest.OneByteReader(bytes.NewReader(input))))
+		})
+	}
+}
+
+// TestDecodeNumberGrammar decodes every short string of the characters that
+// make up numbers, alone and in arrays and objects, as encoding/json does.
+func TestDecodeNumberGrammar(t *testing.T) {
+	inputs := []string{""}
+	for n := 0; n < 4; n++ {
+		for _, prefix := range inputs[len(inputs)-pow(7, n):] {
+			for _, c := range "01-+.eE" {
+				inputs = append(inputs, prefix+string(c))
+			}
+		}
+	}
+	for _, number := range inputs[1:] {
+		for _, format := range []string{"%s", "[%s]", "[%s,1]", `{"a":%s}`} {
+			input := []byte(fmt.Sprintf(format, number))
+			var vJ, v interface{}
+			errJ := json.Unmarshal(input, &vJ)
+			err := Unmarshal(input, &v)
+			if !assert.Equal(t, fmt.Sprint(errJ), fmt.Sprint(err), "%s", input) {
+				continue
+			}
+			if errJ == nil {
+				assert.Equal(t, vJ, v, "%s", input)
+				continue
+			}
+			assert.Equal(t, errJ.(*json.SyntaxError).Offset, err.(*SyntaxError).Offset, "%s", input)
+			assert.Equal(t, json.Valid(input), Valid(input), "%s", input)
+		}
+	}
+}
+
+func pow(x, y int) int {
+	n := 1
+	for ; y > 0; y-- {
+		n *= x
+	}
+	return n
+}
+
+func TestValidReaderError(t *testing.T) {
+	r := iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(`[1]`)))
+	assert.Equal(t, &DecodeError{Kind: "array", Offset: 1, Err: iotest.ErrTimeout}, ValidReader(r))
+}
+
+func TestUnmarshal(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			t.Log("Input: ", string(input))
+			var data, dataJ interface{}
+			errJ := json.Unmarshal(input, &dataJ)
+			err := Unmarshal(input, &data)
+			if errJ == nil {
+				assert.Equal(t, dataJ, data)
+			}
+			eqaulError(t, errJ, err)
+		})
+	}
+}
+
+type decodeStruct struct {
+	A      string
+	B      int `json:"bee"`
+	C      bool
+	d      string
+	E      []int   `json:"-"`
+	F      float64 `json:"-,"`
+	G      interface{}
+	Inner  decodeInner
+	Inners []decodeInner
+}
+
+type decodeInner struct {
+	X, Y int
+	Z    string `json:"A"`
+}
+
+type decodeFold struct {
+	A int `json:"a"`
+	B int `json:"A"`
+}
+
+type decodeOther struct {
+	W int
+	X int
+}
+
+type decodeEmbedded struct {
+	decodeInner
+	decodeOther
+	A string
+}
+
+// DecodePromoted is exported so that a nil pointer to it may be allocated when
+// it is embedded.
+type DecodePromoted struct {
+	P int
+	*DecodeDeep
+}
+
+type DecodeDeep struct {
+	D int
+}
+
+type decodeEmbeddedPtr struct {
+	*DecodePromoted
+	*decodeOther
+	Q int
+}
+
+type decodeNamed string
+
+type decodeTextKey struct {
+	s string
+}
+
+func (k *decodeTextKey) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty key")
+	}
+	k.s = string(b) + "!"
+	return nil
+}
+
+// decodeNetwork holds types that encoding/json decodes with UnmarshalText.
+type decodeNetwork struct {
+	Addr   netip.Addr
+	Prefix netip.Prefix
+	IP     net.IP
+	IPs    []net.IP
+	Ptr    *netip.Addr
+}
+
+type decodeUpperKey string
+
+// decodeIntKey is an integer key that is written with a "k" prefix.
+type decodeIntKey int
+
+func (k *decodeIntKey) UnmarshalText(b []byte) error {
+	if len(b) == 0 || b[0] != 'k' {
+		return errors.New("no k prefix")
+	}
+	n, err := strconv.Atoi(string(b[1:]))
+	*k = decodeIntKey(n)
+	return err
+}
+
+func (k *decodeUpperKey) UnmarshalText(b []byte) error {
+	*k = decodeUpperKey(strings.ToUpper(string(b)))
+	return nil
+}
+
+// decodeRaw records the JSON it is given, rejecting false.
+type decodeRaw struct {
+	raw string
+}
+
+func (r *decodeRaw) UnmarshalJSON(b []byte) error {
+	if string(b) == "false" {
+		return errors.New("no falsehoods")
+	}
+	r.raw = string(b)
+	return nil
+}
+
+type decodeRawFields struct {
+	A decodeRaw
+	C []decodeRaw
+	D int
+}
+
+func TestDecodeStream(t *testing.T) {
+	tests := map[string]string{
+		"empty":             ``,
+		"whitespace":        " \n\t\r ",
+		"objects":           "{\"a\":1}\n{\"a\":2}\n",
+		"adjacent objects":  `{"a":1}{"a":2}[3]`,
+		"adjacent strings":  `"a""b"`,
+		"adjacent literals": `true false null`,
+		"numbers":           `1 -2 3.5 4e1 0`,
+		"number then array": `1[2]`,
+		"leading zero":      `01`,
+		"literal then num":  `null1`,
+		"mixed":             "\"s\" 1 [true] {\"n\": null}\r\n",
+		"truncated last":    `{"a":1} {"a":`,
+		"truncated number":  `1 2.`,
+		"truncated literal": `true fa`,
+		"truncated exp":     `1 2e`,
+		"invalid second":    `{} }`,
+		"invalid third":     `1 2 lol`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			decJ := json.NewDecoder(strings.NewReader(input))
+			dec := NewDecoder(strings.NewReader(input))
+			for {
+				var expected, actual interface{}
+				errJ := decJ.Decode(&expected)
+				err := dec.Decode(&actual)
+				t.Logf("expected %#v %v, actual %#v %v", expected, errJ, actual, err)
+				if errJ != nil {
+					// offsets differ after the first value, see TestToken
+					assert.EqualError(t, err, errJ.Error())
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeBuffered(t *testing.T) {
+	tests := map[string]string{
+		"object":    `{"a":1} and then some`,
+		"number":    `42,rest`,
+		"literal":   "true\r\nHTTP/1.1 200 OK\r\n",
+		"two":       `[1] [2] tail`,
+		"exhausted": `"done"`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			decJ := json.NewDecoder(strings.NewReader(input))
+			dec := NewDecoder(strings.NewReader(input))
+			var expected, actual interface{}
+			require.NoError(t, decJ.Decode(&expected))
+			require.NoError(t, dec.Decode(&actual))
+			bufferedJ, err := io.ReadAll(decJ.Buffered())
+			require.NoError(t, err)
+			buffered, err := io.ReadAll(dec.Buffered())
+			require.NoError(t, err)
+			assert.Equal(t, string(bufferedJ), string(buffered))
+		})
+	}
+}
+
+type decodeByte byte
+
+type decodeBytes struct {
+	Data []byte
+	List [][]byte
+}
+
+type decodeQuoted struct {
+	I  int         `json:",string"`
+	U  uint8       `json:",string"`
+	F  float32     `json:",string"`
+	B  bool        `json:",string"`
+	S  string      `json:",string"`
+	P  *int        `json:",string"`
+	PP **int       `json:",string"`
+	N  decodeNamed `json:",string"`
+	A  []int       `json:",string"`
+	G  interface{} `json:",string"`
+}
+
+type decodePointers struct {
+	I   *int
+	S   *string
+	P   **decodeInner
+	Raw *decodeRaw
+	Nil *int
+}
+
 func TestDecodeToTypes(t *testing.T) {
 	tests := map[string]struct {
 		input       []byte
@@ -303,7 +748,23 @@ func TestDecodeToTypes(t *testing.T) {
 		"string_*string":      {[]byte(`"string"`), new(string), new(string)},
 		"string_string":       {[]byte(`"string"`), "", ""},
 		"string_*int":         {[]byte(`"string"`), new(int), new(int)},
-		"string_int":          {[]byte(`"string"`), 0, 0},
+		"string_*named":       {[]byte(`"string"`), new(decodeNamed), new(decodeNamed)},
+		"string_*error":       {[]byte(`"string"`), new(error), new(error)},
+
+		"base64_*[]byte":         {[]byte(`"aGVsbG8sIHdvcmxk"`), new([]byte), new([]byte)},
+		"empty base64_*[]byte":   {[]byte(`""`), new([]byte), new([]byte)},
+		"padded base64_*[]byte":  {[]byte(`"aGk="`), new([]byte), new([]byte)},
+		"escaped base64_*[]byte": {[]byte(`"aGk\u003d"`), new([]byte), new([]byte)},
+		"bad base64_*[]byte":     {[]byte(`"not base64!"`), new([]byte), new([]byte)},
+		"short base64_*[]byte":   {[]byte(`"aGk"`), new([]byte), new([]byte)},
+		"base64_*[]uint8":        {[]byte(`"AQID"`), new([]uint8), new([]uint8)},
+		"base64_*[]namedByte":    {[]byte(`"AQID"`), new([]decodeByte), new([]decodeByte)},
+		"base64_*[3]byte":        {[]byte(`"AQID"`), new([3]byte), new([3]byte)},
+		"base64_*[]int":          {[]byte(`"AQID"`), new([]int), new([]int)},
+		"array_*[]byte":          {[]byte(`[1,2,3]`), new([]byte), new([]byte)},
+		"base64 field_*struct":   {[]byte(`{"Data":"AQID","List":[[1],"AQ=="]}`), new(decodeBytes), new(decodeBytes)},
+		"null_*[]byte set":       {[]byte(`null`), &[]byte{1}, &[]byte{1}},
+		"string_int":             {[]byte(`"string"`), 0, 0},
 
 		"bool_*interface{}": {[]byte(`true`), new(interface{}), new(interface{})},
 		"bool_interface{}":  {[]byte(`true`), nil, nil},
@@ -340,6 +801,25 @@ func TestDecodeToTypes(t *testing.T) {
 		"uint_float32":      {[]byte(`1`), float32(0), float32(0)},
 		"uint_*string":      {[]byte(`1`), new(string), new(string)},
 		"uint_string":       {[]byte(`1`), "", ""},
+		"uint_*named":       {[]byte(`1`), new(decodeNamed), new(decodeNamed)},
+
+		"MaxUint64_*uint64":   {[]byte(`18446744073709551615`), new(uint64), new(uint64)},
+		"MaxUint64+1_*uint64": {[]byte(`18446744073709551616`), new(uint64), new(uint64)},
+		"MaxInt64_*int64":     {[]byte(`9223372036854775807`), new(int64), new(int64)},
+		"MaxInt64+1_*int64":   {[]byte(`9223372036854775808`), new(int64), new(int64)},
+		"MinInt64_*int64":     {[]byte(`-9223372036854775808`), new(int64), new(int64)},
+		"MinInt64-1_*int64":   {[]byte(`-9223372036854775809`), new(int64), new(int64)},
+		"2^53+1_*int":         {[]byte(`9007199254740993`), new(int), new(int)},
+		"2^53+1_*uint":        {[]byte(`9007199254740993`), new(uint), new(uint)},
+		"MaxUint8+1_*uint8":   {[]byte(`256`), new(uint8), new(uint8)},
+		"MaxInt8+1_*int8":     {[]byte(`128`), new(int8), new(int8)},
+		"MinInt8-1_*int8":     {[]byte(`-129`), new(int8), new(int8)},
+		"MaxUint32_*uint32":   {[]byte(`4294967295`), new(uint32), new(uint32)},
+		"MaxUint32+1_*uint32": {[]byte(`4294967296`), new(uint32), new(uint32)},
+		"uint_*uintptr":       {[]byte(`7`), new(uintptr), new(uintptr)},
+		"int_*uintptr":        {[]byte(`-7`), new(uintptr), new(uintptr)},
+		"overflow_*[]int16":   {[]byte(`[1,32768]`), new([]int16), new([]int16)},
+		"2^53+1_*interface{}": {[]byte(`9007199254740993`), new(interface{}), new(interface{})},
 
 		"int_*interface{}": {[]byte(`-1`), new(interface{}), new(interface{})},
 		"int_interface{}":  {[]byte(`-1`), nil, nil},
@@ -429,6 +909,15 @@ func TestDecodeToTypes(t *testing.T) {
 		"negfloat_float64":      {[]byte(`-1.2`), float64(0), float64(0)},
 		"negfloat_*float32":     {[]byte(`-1.2`), new(float32), new(float32)},
 		"negfloat_float32":      {[]byte(`-1.2`), float32(0), float32(0)},
+
+		// rounding to a float64 first would give the halfway point between
+		// two float32s, and then round to even
+		"halfway_*float32":      {[]byte(`1.0000000596046447753906251`), new(float32), new(float32)},
+		"maxfloat32_*float32":   {[]byte(`3.4028235677973366e38`), new(float32), new(float32)},
+		"overflow_*float32":     {[]byte(`1e39`), new(float32), new(float32)},
+		"overflow_*float64":     {[]byte(`-1e400`), new(float64), new(float64)},
+		"overflow_*interface{}": {[]byte(`[1e400]`), new(interface{}), new(interface{})},
+		"overflow_*[]float32":   {[]byte(`[1, 1e39, 2]`), new([]float32), new([]float32)},
 		"negfloat_*string":      {[]byte(`-1.2`), new(string), new(string)},
 		"negfloat_string":       {[]byte(`-1.2`), "", ""},
 
@@ -503,7 +992,167 @@ func TestDecodeToTypes(t *testing.T) {
 		"[3]float_*[]int":       {[]byte(`[1.2,1.2,1.3]`), new([]int), new([]int)},
 		"[1][1]int_*[][]string": {[]byte(`[[1]]`), new([][]string), new([][]string)},
 
-		// TODO deep pointers []*imt, *******int and so on.
+		"structName*struct":      {[]byte(`{"A":"a","bee":2,"C":true,"d":"d","E":[1],"-":1.5,"G":{"x":[1]}}`), new(decodeStruct), new(decodeStruct)},
+		"struct_struct":          {[]byte(`{"A":"a"}`), decodeStruct{}, decodeStruct{}},
+		"empty_*struct":          {[]byte(`{}`), new(decodeStruct), new(decodeStruct)},
+		"structName*empty":       {[]byte(`{"A":"a","B":[1,{}]}`), new(struct{}), new(struct{})},
+		"folded_*struct":         {[]byte(`{"a":"a","BEE":2,"c":true}`), new(decodeStruct), new(decodeStruct)},
+		"untagged name_*struct":  {[]byte(`{"B":2,"F":1.5}`), new(decodeStruct), new(decodeStruct)},
+		"unknown_*struct":        {[]byte(`{"Z":{"A":"nested"},"A":"a","Y":[1,2]}`), new(decodeStruct), new(decodeStruct)},
+		"nested_*struct":         {[]byte(`{"Inner":{"X":1,"Y":2,"A":"z"},"Inners":[{"X":3},{"Y":4}]}`), new(decodeStruct), new(decodeStruct)},
+		"repeated_*struct":       {[]byte(`{"A":"first","a":"second"}`), new(decodeStruct), new(decodeStruct)},
+		"exact_*decodeFold":      {[]byte(`{"A":1}`), new(decodeFold), new(decodeFold)},
+		"fold_*decodeFold":       {[]byte(`{"a":1}`), new(decodeFold), new(decodeFold)},
+		"fold2_*decodeFold":      {[]byte(`{"b":1}`), new(decodeFold), new(decodeFold)},
+		"embedded_*struct":       {[]byte(`{"X":1,"Y":2,"A":"a","W":3}`), new(decodeEmbedded), new(decodeEmbedded)},
+		"embedded ptr_*struct":   {[]byte(`{"P":1,"Q":2}`), new(decodeEmbeddedPtr), new(decodeEmbeddedPtr)},
+		"deep ptr_*struct":       {[]byte(`{"D":1}`), new(decodeEmbeddedPtr), new(decodeEmbeddedPtr)},
+		"nil ptr_*struct":        {[]byte(`{"Q":2}`), new(decodeEmbeddedPtr), new(decodeEmbeddedPtr)},
+		"unexported ptr_*struct": {[]byte(`{"W":1}`), new(decodeEmbeddedPtr), new(decodeEmbeddedPtr)},
+		"prefilled_*struct":      {[]byte(`{"A":"a"}`), &decodeStruct{A: "x", B: 7}, &decodeStruct{A: "x", B: 7}},
+		"structName[]struct":     {[]byte(`[{"A":"a"},{"bee":1}]`), new([]decodeStruct), new([]decodeStruct)},
+		"structName*map":         {[]byte(`{"a":{"b":1}}`), new(map[string]interface{}), new(map[string]interface{})},
+		"object_*interface{}":    {[]byte(`{"a":{"b":1}}`), new(interface{}), new(interface{})},
+		"object_*error":          {[]byte(`{"a":1}`), new(error), new(error)},
+		"object_*int":            {[]byte(`{"a":1}`), new(int), new(int)},
+		"object_*[]int":          {[]byte(`{"a":1}`), new([]int), new([]int)},
+
+		"object_*map[string]string":       {[]byte(`{"a":"b","c":"d"}`), new(map[string]string), new(map[string]string)},
+		"object_map[string]string":        {[]byte(`{"a":"b","c":"d"}`), map[string]string{}, map[string]string{}},
+		"object_*map[string]int":          {[]byte(`{"a":1,"b":-2}`), new(map[string]int), new(map[string]int)},
+		"object_*map[string]bad":          {[]byte(`{"a":1,"b":"c"}`), new(map[string]int), new(map[string]int)},
+		"object_*map[string][]int":        {[]byte(`{"a":[1],"b":[]}`), new(map[string][]int), new(map[string][]int)},
+		"object_*map[string]struct":       {[]byte(`{"a":{"X":1},"b":{"A":"z"}}`), new(map[string]decodeInner), new(map[string]decodeInner)},
+		"object_*map[string]map":          {[]byte(`{"a":{"b":{"c":true}}}`), new(map[string]map[string]interface{}), new(map[string]map[string]interface{})},
+		"object_*map[int]float64":         {[]byte(`{"1":1.5,"-2":2.5}`), new(map[int]float64), new(map[int]float64)},
+		"object_*map[int8]bool overflow":  {[]byte(`{"1":true,"128":false}`), new(map[int8]bool), new(map[int8]bool)},
+		"object_*map[int]bool not number": {[]byte(`{"one":true}`), new(map[int]bool), new(map[int]bool)},
+		"object_*map[uint16]int":          {[]byte(`{"65535":1,"0":2}`), new(map[uint16]int), new(map[uint16]int)},
+		"object_*map[uint]int negative":   {[]byte(`{"-1":1}`), new(map[uint]int), new(map[uint]int)},
+		"object_*map[uintptr]int":         {[]byte(`{"7":1}`), new(map[uintptr]int), new(map[uintptr]int)},
+		"object_*map[bool]int":            {[]byte(`{"true":1}`), new(map[bool]int), new(map[bool]int)},
+		"object_*map[named]int":           {[]byte(`{"a":1}`), new(map[decodeNamed]int), new(map[decodeNamed]int)},
+		"object_*map[text]int":            {[]byte(`{"a":1,"b":2}`), new(map[decodeTextKey]int), new(map[decodeTextKey]int)},
+		"object_*map[text string]int":     {[]byte(`{"a":1,"b":2}`), new(map[decodeUpperKey]int), new(map[decodeUpperKey]int)},
+		"object_*map[text]int error":      {[]byte(`{"a":1,"":2}`), new(map[decodeTextKey]int), new(map[decodeTextKey]int)},
+		"object_*map[text int]int":        {[]byte(`{"k1":1,"k\u0032":2}`), new(map[decodeIntKey]int), new(map[decodeIntKey]int)},
+		"object_*map[text int]int error":  {[]byte(`{"k1":1,"2":2}`), new(map[decodeIntKey]int), new(map[decodeIntKey]int)},
+		"object_*map[netip.Addr]int":      {[]byte(`{"1.2.3.4":1,"::1":2}`), new(map[netip.Addr]int), new(map[netip.Addr]int)},
+		"object_*map prefilled": {
+			[]byte(`{"a":{"X":1},"c":{"Y":2}}`),
+			&map[string]decodeInner{"a": {Y: 7}, "b": {X: 8}},
+			&map[string]decodeInner{"a": {Y: 7}, "b": {X: 8}},
+		},
+		"object_*struct map field": {[]byte(`{"G":{"a":1}}`), new(decodeStruct), new(decodeStruct)},
+
+		"object_*Unmarshaler":            {[]byte(`{"a": [1, "2"] }`), new(decodeRaw), new(decodeRaw)},
+		"string_*Unmarshaler":            {[]byte(` "a\"b" `), new(decodeRaw), new(decodeRaw)},
+		"number_*Unmarshaler":            {[]byte(`-1.5e3`), new(decodeRaw), new(decodeRaw)},
+		"null_*Unmarshaler":              {[]byte(`null`), new(decodeRaw), new(decodeRaw)},
+		"false_*Unmarshaler":             {[]byte(`false`), new(decodeRaw), new(decodeRaw)},
+		"invalid_*Unmarshaler":           {[]byte(`{"a":}`), new(decodeRaw), new(decodeRaw)},
+		"object_*Unmarshaler fields":     {[]byte(`{"A":{"x":1},"C":[1,"two",null],"D":4}`), new(decodeRawFields), new(decodeRawFields)},
+		"object_*map[string]Unmarshaler": {[]byte(`{"a":[],"b":{}}`), new(map[string]decodeRaw), new(map[string]decodeRaw)},
+		"false_*Unmarshaler field":       {[]byte(`{"D":1,"A":false}`), new(decodeRawFields), new(decodeRawFields)},
+
+		"string_*TextUnmarshaler":     {[]byte(`"a\u0062"`), new(decodeTextKey), new(decodeTextKey)},
+		"empty_*TextUnmarshaler":      {[]byte(`""`), new(decodeTextKey), new(decodeTextKey)},
+		"number_*TextUnmarshaler":     {[]byte(`1`), new(decodeTextKey), new(decodeTextKey)},
+		"bool_*TextUnmarshaler":       {[]byte(`true`), new(decodeTextKey), new(decodeTextKey)},
+		"object_*TextUnmarshaler":     {[]byte(`{"s":"a"}`), new(decodeTextKey), new(decodeTextKey)},
+		"array_*TextUnmarshaler":      {[]byte(`["a"]`), new(decodeTextKey), new(decodeTextKey)},
+		"null_*TextUnmarshaler set":   {[]byte(`null`), &decodeTextKey{"x"}, &decodeTextKey{"x"}},
+		"string_**TextUnmarshaler":    {[]byte(`"a"`), new(*decodeTextKey), new(*decodeTextKey)},
+		"string_*[]TextUnmarshaler":   {[]byte(`["a","b"]`), new([]decodeTextKey), new([]decodeTextKey)},
+		"string_*netip.Addr":          {[]byte(`"1.2.3.4"`), new(netip.Addr), new(netip.Addr)},
+		"string_*netip.Addr v6":       {[]byte(`"fe80::1%eth0"`), new(netip.Addr), new(netip.Addr)},
+		"invalid_*netip.Addr":         {[]byte(`"1.2.3"`), new(netip.Addr), new(netip.Addr)},
+		"number_*netip.Addr":          {[]byte(`1234`), new(netip.Addr), new(netip.Addr)},
+		"string_*netip.Prefix":        {[]byte(`"10.0.0.0/8"`), new(netip.Prefix), new(netip.Prefix)},
+		"invalid_*netip.Prefix":       {[]byte(`"10.0.0.0/33"`), new(netip.Prefix), new(netip.Prefix)},
+		"string_*netip.AddrPort":      {[]byte(`"[::1]:80"`), new(netip.AddrPort), new(netip.AddrPort)},
+		"string_*net.IP":              {[]byte(`"::ffff:1.2.3.4"`), new(net.IP), new(net.IP)},
+		"invalid_*net.IP":             {[]byte(`"x"`), new(net.IP), new(net.IP)},
+		"null_*net.IP set":            {[]byte(`null`), &net.IP{1, 2, 3, 4}, &net.IP{1, 2, 3, 4}},
+		"object_*network fields":      {[]byte(`{"Addr":"::1","Prefix":"::/0","IP":"1.2.3.4","IPs":["1.1.1.1"],"Ptr":"2.2.2.2"}`), new(decodeNetwork), new(decodeNetwork)},
+		"field error_*network fields": {[]byte(`{"Addr":1}`), new(decodeNetwork), new(decodeNetwork)},
+		"text error_*network fields":  {[]byte(`{"Prefix":"a"}`), new(decodeNetwork), new(decodeNetwork)},
+
+		"int_**int":               {[]byte(`1`), new(*int), new(*int)},
+		"int_*******int":          {[]byte(`1`), new(******int), new(******int)},
+		"null_**int":              {[]byte(`null`), new(*int), new(*int)},
+		"null_**int set":          {[]byte(`null`), func() **int { i := 1; p := &i; return &p }(), func() **int { i := 1; p := &i; return &p }()},
+		"null_***int set":         {[]byte(`null`), func() ***int { i := 1; p := &i; pp := &p; return &pp }(), func() ***int { i := 1; p := &i; pp := &p; return &pp }()},
+		"int_**int set":           {[]byte(`2`), func() **int { i := 1; p := &i; return &p }(), func() **int { i := 1; p := &i; return &p }()},
+		"string_**string":         {[]byte(`"a"`), new(*string), new(*string)},
+		"bool_**bool":             {[]byte(`true`), new(*bool), new(*bool)},
+		"float_**float64":         {[]byte(`1.5`), new(*float64), new(*float64)},
+		"string_**int":            {[]byte(`"a"`), new(*int), new(*int)},
+		"[]_*[]*string":           {[]byte(`["a",null,"c"]`), new([]*string), new([]*string)},
+		"[]_**[]*string":          {[]byte(`["a",null]`), new(*[]*string), new(*[]*string)},
+		"[]_*[]**int":             {[]byte(`[1,null,[2]]`), new([]**int), new([]**int)},
+		"object_*map[string]*int": {[]byte(`{"a":1,"b":null}`), new(map[string]*int), new(map[string]*int)},
+		"object_**map":            {[]byte(`{"a":1}`), new(*map[string]int), new(*map[string]int)},
+		"object_*pointers":        {[]byte(`{"I":1,"S":"s","P":{"X":2},"Raw":[1],"Nil":null}`), new(decodePointers), new(decodePointers)},
+		"null_*pointers set": {
+			[]byte(`{"I":null,"P":null,"Raw":null}`),
+			func() *decodePointers {
+				i := 1
+				in := &decodeInner{X: 1}
+				return &decodePointers{I: &i, P: &in, Raw: &decodeRaw{"x"}}
+			}(),
+			func() *decodePointers {
+				i := 1
+				in := &decodeInner{X: 1}
+				return &decodePointers{I: &i, P: &in, Raw: &decodeRaw{"x"}}
+			}(),
+		},
+		"null_*[]int set":              {[]byte(`null`), &[]int{1}, &[]int{1}},
+		"null_*map set":                {[]byte(`null`), &map[string]int{"a": 1}, &map[string]int{"a": 1}},
+		"null_*interface{} set":        {[]byte(`null`), func() *interface{} { var i interface{} = 1; return &i }(), func() *interface{} { var i interface{} = 1; return &i }()},
+		"null_*error set":              {[]byte(`null`), func() *error { err := errors.New("e"); return &err }(), func() *error { err := errors.New("e"); return &err }()},
+		"null_*string set":             {[]byte(`null`), func() *string { s := "s"; return &s }(), func() *string { s := "s"; return &s }()},
+		"null_*int set":                {[]byte(`null`), func() *int { i := 1; return &i }(), func() *int { i := 1; return &i }()},
+		"null_*bool set":               {[]byte(`null`), func() *bool { b := true; return &b }(), func() *bool { b := true; return &b }()},
+		"null_*[2]int set":             {[]byte(`null`), &[2]int{1, 2}, &[2]int{1, 2}},
+		"null_*struct set":             {[]byte(`null`), &decodeInner{X: 1}, &decodeInner{X: 1}},
+		"null fields_*struct set":      {[]byte(`{"A":null,"bee":null,"G":null,"Inners":null,"Inner":null}`), &decodeStruct{A: "a", B: 1, G: 2, Inners: []decodeInner{{}}, Inner: decodeInner{X: 1}}, &decodeStruct{A: "a", B: 1, G: 2, Inners: []decodeInner{{}}, Inner: decodeInner{X: 1}}},
+		"null elements_*[]int set":     {[]byte(`[null,null]`), &[]int{1, 2}, &[]int{1, 2}},
+		"null elements_*[][]int set":   {[]byte(`[null]`), &[][]int{{1}}, &[][]int{{1}}},
+		"null values_*map":             {[]byte(`{"a":null,"b":null}`), &map[string][]int{"a": {1}}, &map[string][]int{"a": {1}}},
+		"quoted_*struct":               {[]byte(`{"I":"-42","U":"255","F":"1.5","B":"true","S":"\"s\\n\"","P":"7","N":"\"n\"","A":[1]}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted null_*struct":          {[]byte(`{"I":null,"P":null,"S":null}`), &decodeQuoted{I: 1, S: "s"}, &decodeQuoted{I: 1, S: "s"}},
+		"quoted null string_*struct":   {[]byte(`{"I":"null","P":"null"}`), &decodeQuoted{I: 1}, &decodeQuoted{I: 1}},
+		"quoted unquoted_*struct":      {[]byte(`{"I":42}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted empty_*struct":         {[]byte(`{"I":""}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted empty ptr_*struct":     {[]byte(`{"P":""}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted word int_*struct":      {[]byte(`{"I":"abc"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted space int_*struct":     {[]byte(`{"I":" 1"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted bool int_*struct":      {[]byte(`{"I":"true"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted bad bool_*struct":      {[]byte(`{"B":"tru"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted num bool_*struct":      {[]byte(`{"B":"1"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted bare string_*struct":   {[]byte(`{"S":"s"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted num string_*struct":    {[]byte(`{"S":"1"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted unquoted S_*struct":    {[]byte(`{"S":1}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted unquoted N_*struct":    {[]byte(`{"N":1}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted quoted num S_*struct":  {[]byte(`{"S":"\"1\""}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted bad string_*struct":    {[]byte(`{"S":"\"s"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted trailing_*struct":      {[]byte(`{"S":"\"s\"x"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted interface_*struct":     {[]byte(`{"G":"1"}`), new(decodeQuoted), new(decodeQuoted)},
+		"object_*[]*struct":            {[]byte(`[{"X":1},null,{"Y":2}]`), new([]*decodeInner), new([]*decodeInner)},
+		"quoted overflow_*struct":      {[]byte(`{"U":"256"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted float int_*struct":     {[]byte(`{"I":"1.5"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted string int_*struct":    {[]byte(`{"I":"\"1\""}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted ptr_*struct":           {[]byte(`{"PP":"1"}`), new(decodeQuoted), new(decodeQuoted)},
+		"quoted ignored slice_*struct": {[]byte(`{"A":"[1]"}`), new(decodeQuoted), new(decodeQuoted)},
+		"field error_*struct":          {[]byte(`{"A":1}`), new(decodeStruct), new(decodeStruct)},
+		"nested field error_*struct":   {[]byte(`{"Inner":{"X":"s"}}`), new(decodeStruct), new(decodeStruct)},
+		"slice field error_*struct":    {[]byte(`{"Inners":[{},{"A":true}]}`), new(decodeStruct), new(decodeStruct)},
+		"slice elem error_*struct":     {[]byte(`{"Inners":[1]}`), new(decodeStruct), new(decodeStruct)},
+		"map field error_*map":         {[]byte(`{"k":{"Y":[]}}`), new(map[string]decodeInner), new(map[string]decodeInner)},
+		"embedded error_*struct":       {[]byte(`{"W":"w"}`), new(decodeEmbedded), new(decodeEmbedded)},
+		"embedded ptr error_*struct":   {[]byte(`{"D":{}}`), new(decodeEmbeddedPtr), new(decodeEmbeddedPtr)},
+		"anonymous error_*struct":      {[]byte(`{"S":{"N":"n"}}`), new(struct{ S struct{ N int } }), new(struct{ S struct{ N int } })},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -524,43 +1173,1095 @@ func TestDecodeToTypes(t *testing.T) {
 	}
 }
 
-// TODO test the invalid UTF8 sequences here to lock in behaviour
+func TestDecodeURL(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     interface{}
+		expected interface{}
+		err      string
+	}{
+		"url": {
+			input:    `"https://user@example.com:8080/a%20b?q=1#frag"`,
+			dest:     new(url.URL),
+			expected: &url.URL{Scheme: "https", User: url.User("user"), Host: "example.com:8080", Path: "/a b", RawPath: "", RawQuery: "q=1", Fragment: "frag"},
+		},
+		"pointer": {
+			input:    `"/p"`,
+			dest:     new(*url.URL),
+			expected: func() **url.URL { u := &url.URL{Path: "/p"}; return &u }(),
+		},
+		"null": {
+			input:    `null`,
+			dest:     func() **url.URL { u := &url.URL{Path: "/p"}; return &u }(),
+			expected: new(*url.URL),
+		},
+		"field": {
+			input: `{"U":"http://a","P":"b"}`,
+			dest: new(struct {
+				U url.URL
+				P *url.URL
+			}),
+			expected: &struct {
+				U url.URL
+				P *url.URL
+			}{url.URL{Scheme: "http", Host: "a"}, &url.URL{Path: "b"}},
+		},
+		"object": {
+			// as in encoding/json, a URL may still be decoded from its fields
+			input:    `{"Scheme":"http","Host":"a"}`,
+			dest:     new(url.URL),
+			expected: &url.URL{Scheme: "http", Host: "a"},
+		},
+		"invalid": {
+			input: `"http://a b"`,
+			dest:  new(url.URL),
+			err:   `json: cannot unmarshal string "http://a b" into Go value of type url.URL`,
+		},
+		"number": {
+			input: `1`,
+			dest:  new(url.URL),
+			err:   "json: cannot unmarshal number into Go value of type url.URL",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.input), tt.dest)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.dest)
+		})
+	}
+}
 
-// TODO decode into *json.RawMessage
+func TestDecodeStrictNumbers(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		dest  interface{}
+		err   bool
+	}{
+		"0.1_*float64":                {`0.1`, new(float64), false},
+		"-0_*float64":                 {`-0`, new(float64), false},
+		"1e6_*float64":                {`1e6`, new(float64), false},
+		"0e-400_*float64":             {`0e-400`, new(float64), false},
+		"MaxUint64_*float64":          {`18446744073709551615`, new(float64), true},
+		"MaxUint64_*interface{}":      {`18446744073709551615`, new(interface{}), true},
+		"MinInt64_*float64":           {`-9223372036854775807`, new(float64), true},
+		"2^53_*float64":               {`9007199254740992`, new(float64), false},
+		"2^53+1_*float64":             {`9007199254740993`, new(float64), true},
+		"2^24+1_*float32":             {`16777217`, new(float32), true},
+		"2^24+1_*float64":             {`16777217`, new(float64), false},
+		"17 digits_*float64":          {`0.30000000000000004`, new(float64), false},
+		"too many digits_*float64":    {`0.300000000000000041`, new(float64), true},
+		"overflow_*float64":           {`1e400`, new(float64), true},
+		"overflow_*float32":           {`1e39`, new(float32), true},
+		"underflow_*float64":          {`1e-400`, new(float64), true},
+		"negative underflow_*float64": {`-1.5e-400`, new(float64), true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewBufferString(tt.input))
+			dec.StrictNumbers()
+			err := dec.Decode(tt.dest)
+			if !tt.err {
+				assert.NoError(t, err)
+				return
+			}
+			typ := reflect.TypeOf(tt.dest).Elem()
+			if typ.Kind() == reflect.Interface {
+				typ = reflect.TypeOf(float64(0))
+			}
+			assert.Equal(t, &UnmarshalTypeError{
+				Value:  "number " + tt.input,
+				Type:   typ,
+				Offset: int64(len(tt.input)),
+			}, err)
+		})
+	}
+}
 
-func TestDecodeReadError(t *testing.T) {
+func TestDecodeDisallowUnknownFields(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		destJ, dest interface{}
+	}{
+		"known":             {`{"A":"a","bee":1}`, new(decodeStruct), new(decodeStruct)},
+		"folded":            {`{"a":"a","BEE":1}`, new(decodeStruct), new(decodeStruct)},
+		"unknown":           {`{"A":"a","Z":1}`, new(decodeStruct), new(decodeStruct)},
+		"ignored field":     {`{"E":[1]}`, new(decodeStruct), new(decodeStruct)},
+		"unexported field":  {`{"d":"d"}`, new(decodeStruct), new(decodeStruct)},
+		"nested unknown":    {`{"Inner":{"X":1,"W":2}}`, new(decodeStruct), new(decodeStruct)},
+		"embedded":          {`{"X":1,"W":2}`, new(decodeEmbedded), new(decodeEmbedded)},
+		"interface":         {`{"Z":{"Y":1}}`, new(interface{}), new(interface{})},
+		"map":               {`{"Z":1}`, new(map[string]int), new(map[string]int)},
+		"struct in map":     {`{"k":{"X":1,"Q":2}}`, new(map[string]decodeInner), new(map[string]decodeInner)},
+		"interface field":   {`{"G":{"unknown":true}}`, new(decodeStruct), new(decodeStruct)},
+		"unknown escaped":   {`{"\u005a":1}`, new(decodeStruct), new(decodeStruct)},
+		"syntax error":      {`{"Z" 1}`, new(decodeStruct), new(decodeStruct)},
+		"empty object":      {`{}`, new(decodeStruct), new(decodeStruct)},
+		"unknown in slice":  {`[{"X":1},{"V":1}]`, new([]decodeInner), new([]decodeInner)},
+		"Unmarshaler field": {`{"A":{"unknown":1}}`, new(decodeRawFields), new(decodeRawFields)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			decJ := json.NewDecoder(strings.NewReader(tt.input))
+			decJ.DisallowUnknownFields()
+			errJ := decJ.Decode(tt.destJ)
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowUnknownFields()
+			err := dec.Decode(tt.dest)
+			if errJ == nil {
+				assert.Equal(t, tt.destJ, tt.dest)
+			}
+			eqaulError(t, errJ, err)
+		})
+	}
+}
+
+func TestDecodeMatchCaseSensitive(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		unknown  bool
+		expected decodeStruct
+		err      string
+	}{
+		"exact":          {input: `{"A":"a","bee":1}`, expected: decodeStruct{A: "a", B: 1}},
+		"folded":         {input: `{"a":"a","BEE":1,"c":true}`},
+		"nested":         {input: `{"Inner":{"x":1,"Y":2}}`, expected: decodeStruct{Inner: decodeInner{Y: 2}}},
+		"folded unknown": {input: `{"A":"a","Bee":1}`, unknown: true, err: `json: unknown field "Bee"`},
+		"exact unknown":  {input: `{"A":"a","bee":1}`, unknown: true, expected: decodeStruct{A: "a", B: 1}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.MatchCaseSensitive()
+			if tt.unknown {
+				dec.DisallowUnknownFields()
+			}
+			var actual decodeStruct
+			err := dec.Decode(&actual)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDecodeSetTagKey(t *testing.T) {
+	input := `{"name":"n","jsonName":"j","port":1,"Debug":true,"other":"o"}`
+
+	dec := NewDecoder(strings.NewReader(input + input))
+	dec.SetTagKey("config")
+	var actual configTagged
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, configTagged{Name: "n", Port: 1, Other: "o"}, actual)
+
+	dec.SetTagKey("")
+	actual = configTagged{}
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, configTagged{Name: "j", Port: 1, Debug: true, Other: "o"}, actual)
+}
+
+func TestDecodeMaxDepth(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		maxDepth int
+		dest     interface{}
+		err      error
+	}{
+		"default":            {strings.Repeat("[", 1000) + strings.Repeat("]", 1000), 0, new(interface{}), nil},
+		"over default":       {strings.Repeat("[", 1001) + strings.Repeat("]", 1001), 0, new(interface{}), &MaxDepthError{MaxDepth: 1000, Offset: 1001}},
+		"at max":             {`[{"a":[]}]`, 3, new(interface{}), nil},
+		"over max":           {`[{"a":[[]]}]`, 3, new(interface{}), &MaxDepthError{MaxDepth: 3, Offset: 8}},
+		"objects":            {`{"a":{"b":{}}}`, 2, new(interface{}), &MaxDepthError{MaxDepth: 2, Offset: 11}},
+		"siblings":           {`[[],[],{"a":[]}]`, 2, new(interface{}), &MaxDepthError{MaxDepth: 2, Offset: 13}},
+		"scalars":            {`"[[["`, 1, new(interface{}), nil},
+		"raised":             {strings.Repeat("[", 5000) + strings.Repeat("]", 5000), 5000, new(interface{}), nil},
+		"discarded in field": {`{"unknown":[[[]]]}`, 3, new(decodeStruct), &MaxDepthError{MaxDepth: 3, Offset: 14}},
+		"typed":              {`[[[1]]]`, 2, new([][][]int), &MaxDepthError{MaxDepth: 2, Offset: 3}},
+		"Unmarshaler":        {`[[[1]]]`, 2, new(decodeRaw), &MaxDepthError{MaxDepth: 2, Offset: 3}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxDepth(tt.maxDepth)
+			err := dec.Decode(tt.dest)
+			assert.Equal(t, tt.err, err)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, ErrMaxDepthExceeded))
+			}
+		})
+	}
+}
+
+func TestDecodeMaxDepthRecovers(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[[1]] [1]`))
+	dec.SetMaxDepth(1)
+	var v interface{}
+	require.Error(t, dec.Decode(&v))
+	dec = NewDecoder(strings.NewReader(`[1] [2]`))
+	dec.SetMaxDepth(1)
+	require.NoError(t, dec.Decode(&v))
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, []interface{}{float64(2)}, v)
+}
+
+func TestDecodeMaxBytes(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		maxBytes int64
+		values   int
+		err      error
+	}{
+		"unlimited":    {strings.Repeat(" ", 100) + `"a"`, 0, 1, nil},
+		"under":        {`{"a":1}`, 8, 1, nil},
+		"exact":        {`{"a":1}`, 7, 1, nil},
+		"exact number": {`123`, 3, 1, nil},
+		"over":         {`{"a":1}`, 6, 0, &MaxBytesError{MaxBytes: 6}},
+		"whitespace":   {`1          `, 5, 1, &MaxBytesError{MaxBytes: 5}},
+		"stream":       {`[1] [2] [3]`, 8, 2, &MaxBytesError{MaxBytes: 8}},
+		"in string":    {`"abcdef"`, 4, 0, &MaxBytesError{MaxBytes: 4}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxBytes(tt.maxBytes)
+			for i := 0; i < tt.values; i++ {
+				var v interface{}
+				require.NoError(t, dec.Decode(&v))
+			}
+			var v interface{}
+			err := dec.Decode(&v)
+			if tt.err == nil {
+				assert.Equal(t, io.EOF, err)
+				return
+			}
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestLimitedDecoder(t *testing.T) {
+	dec := LimitedDecoder(strings.NewReader(`{"a": [1, 2]} {"b": "`+strings.Repeat("x", readChunk)+`"}`), 100)
+	var v map[string]interface{}
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{float64(1), float64(2)}}, v)
+
+	err := dec.Decode(&v)
+	assert.Equal(t, &MaxBytesError{MaxBytes: 100}, err)
+	assert.True(t, errors.Is(err, ErrTooLarge))
+	assert.False(t, errors.Is(err, ErrMaxDepthExceeded))
+	assert.False(t, errors.Is(&MaxDepthError{}, ErrTooLarge))
+
+	// the limit is kept by Reset
+	dec.Reset(strings.NewReader(strings.Repeat(" ", 101) + `1`))
+	assert.True(t, errors.Is(dec.Decode(&v), ErrTooLarge))
+
+	dec = LimitedDecoder(strings.NewReader(`"`+strings.Repeat("x", readChunk)+`"`), 0)
+	var s string
+	require.NoError(t, dec.Decode(&s), "zero means no limit")
+	assert.Len(t, s, readChunk)
+}
+
+func TestDecodeMaxStringLen(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		dest  interface{}
+		err   error
+	}{
+		"short string":  {`"abcd"`, new(interface{}), nil},
+		"long string":   {`"abcde"`, new(interface{}), &MaxStringLenError{MaxStringLen: 4, Offset: 6}},
+		"escapes":       {`"\n\n\n\n"`, new(interface{}), nil},
+		"escaped long":  {`"\u00e9\u00e9\u00e9"`, new(string), &MaxStringLenError{MaxStringLen: 4, Offset: 19}},
+		"long key":      {`{"abcde":1}`, new(map[string]int), &MaxStringLenError{MaxStringLen: 4, Offset: 7}},
+		"short number":  {`1234`, new(int), nil},
+		"long number":   {`12345`, new(int), &MaxStringLenError{MaxStringLen: 4, Offset: 5}},
+		"long negative": {`-1234`, new(int), &MaxStringLenError{MaxStringLen: 4, Offset: 5}},
+		"long float":    {`1.2345`, new(float64), &MaxStringLenError{MaxStringLen: 4, Offset: 5}},
+		"discarded":     {`{"Z":"abcdefg"}`, new(decodeStruct), &MaxStringLenError{MaxStringLen: 4, Offset: 11}},
+		"long escaped":  {`"abc\nd"`, new(string), &MaxStringLenError{MaxStringLen: 4, Offset: 7}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxStringLen(4)
+			assert.Equal(t, tt.err, dec.Decode(tt.dest))
+		})
+	}
+}
+
+func TestDecodeMaxNumberLen(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		dest  interface{}
+		err   error
+	}{
+		"short number":  {`1234`, new(int), nil},
+		"long number":   {`12345`, new(int), &MaxNumberLenError{MaxNumberLen: 4, Offset: 5}},
+		"long negative": {`-1234`, new(int), &MaxNumberLenError{MaxNumberLen: 4, Offset: 5}},
+		"long float":    {`1.2e45`, new(float64), &MaxNumberLenError{MaxNumberLen: 4, Offset: 5}},
+		"discarded":     {`{"Z":12345}`, new(decodeStruct), &MaxNumberLenError{MaxNumberLen: 4, Offset: 10}},
+		"raw":           {`[12345]`, new(RawMessage), &MaxNumberLenError{MaxNumberLen: 4, Offset: 6}},
+		"long string":   {`"12345"`, new(string), nil},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxNumberLen(4)
+			assert.Equal(t, tt.err, dec.Decode(tt.dest))
+		})
+	}
+}
+
+func TestDecodeDefaultMaxNumberLen(t *testing.T) {
+	digits := strings.Repeat("9", DefaultMaxNumberLen)
+	var f float64
+	assert.EqualError(t, Unmarshal([]byte(digits+"9"), &f), fmt.Sprintf("json: number literal exceeds %d bytes at offset %d", DefaultMaxNumberLen, DefaultMaxNumberLen+1))
+	assert.EqualError(t, Unmarshal([]byte("0."+digits), &f), fmt.Sprintf("json: number literal exceeds %d bytes at offset %d", DefaultMaxNumberLen, DefaultMaxNumberLen+1))
+	assert.NoError(t, Unmarshal([]byte("0."+digits[2:]), &f))
+
+	dec := NewDecoder(strings.NewReader(digits + "9"))
+	dec.SetMaxNumberLen(-1)
+	var n RawMessage
+	require.NoError(t, dec.Decode(&n))
+	assert.Len(t, n, DefaultMaxNumberLen+1)
+
+	// the shorter limit is reported
+	dec = NewDecoder(strings.NewReader(`12345`))
+	dec.SetMaxNumberLen(3)
+	dec.SetMaxStringLen(4)
+	assert.Equal(t, &MaxNumberLenError{MaxNumberLen: 3, Offset: 4}, dec.Decode(new(int)))
+}
+
+func TestDecodeDisallowDuplicateKeys(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		dest  interface{}
+		err   error
+	}{
+		"unique":          {`{"a":1,"b":2}`, new(interface{}), nil},
+		"duplicate":       {`{"a":1,"b":2,"a":3}`, new(interface{}), &DuplicateKeyError{Key: "a", Offset: 14}},
+		"escaped":         {`{"a":1,"\u0061":2}`, new(map[string]int), &DuplicateKeyError{Key: "a", Offset: 8}},
+		"case differs":    {`{"a":1,"A":2}`, new(map[string]int), nil},
+		"nested":          {`{"a":{"b":1,"b":2}}`, new(interface{}), &DuplicateKeyError{Key: "b", Offset: 13}},
+		"sibling objects": {`[{"a":1},{"a":2}]`, new(interface{}), nil},
+		"struct":          {`{"A":"a","bee":1,"A":"b"}`, new(decodeStruct), &DuplicateKeyError{Key: "A", Offset: 18}},
+		"struct folded":   {`{"A":"a","a":"b"}`, new(decodeStruct), nil},
+		"empty key":       {`{"":1,"":2}`, new(interface{}), &DuplicateKeyError{Key: "", Offset: 7}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowDuplicateKeys()
+			assert.Equal(t, tt.err, dec.Decode(tt.dest))
+		})
+	}
+}
+
+func TestDecodeDisallowTrailingData(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"single":          {input: `{"a":1}`},
+		"trailing space":  {input: "[1, 2] \n\t"},
+		"second value":    {input: `1 2`, err: "invalid character '2' after top-level value"},
+		"garbage":         {input: `"a"x`, err: "invalid character 'x' after top-level value"},
+		"trailing object": {input: `{}{}`, err: "invalid character '{' after top-level value"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var v interface{}
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowTrailingData()
+			err := dec.Decode(&v)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+
+			dec = NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowTrailingData()
+			err = dec.Skip()
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeDisallowTrailingDataNested(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[1, {"a": 2}] 3`))
+	dec.DisallowTrailingData()
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('['), tok)
+	var n int
+	require.NoError(t, dec.Decode(&n), "elements may be followed by more elements")
+	var m map[string]int
+	require.NoError(t, dec.Decode(&m))
+	assert.Equal(t, map[string]int{"a": 2}, m)
+	_, err = dec.Token()
+	require.NoError(t, err)
+	assert.True(t, dec.More())
+}
+
+func TestDecodeMoreTopLevel(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(" 1 \"two\"\n[3] "))
+	var values []interface{}
+	for dec.More() {
+		var v interface{}
+		require.NoError(t, dec.Decode(&v))
+		values = append(values, v)
+	}
+	assert.Equal(t, []interface{}{float64(1), "two", []interface{}{float64(3)}}, values)
+}
+
+func TestDecodeAllowComments(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     interface{}
+		expected interface{}
+		err      string
+	}{
+		"line comments": {
+			input:    "// config\n{\"a\": 1, // one\n\"b\": [2, 3] // two three\n}\n// end",
+			dest:     new(interface{}),
+			expected: map[string]interface{}{"a": float64(1), "b": []interface{}{float64(2), float64(3)}},
+		},
+		"block comments": {
+			input:    `/* a */{/**/"a"/* b */:/* c */1/***/,"b":[/* d */2/* e */,/* f **/3]}/* g */`,
+			dest:     new(interface{}),
+			expected: map[string]interface{}{"a": float64(1), "b": []interface{}{float64(2), float64(3)}},
+		},
+		"struct": {
+			input:    "{\"A\": \"x\" /* first */, \"bee\": /* second */ 2 // third\n}",
+			dest:     new(decodeStruct),
+			expected: &decodeStruct{A: "x", B: 2},
+		},
+		"scalar": {
+			input:    `/* lead */ 1 // trail`,
+			dest:     new(interface{}),
+			expected: float64(1),
+		},
+		"unknown field": {
+			input:    `{"z": /* skipped */ [1 /* , */], "A": "a"}`,
+			dest:     new(decodeStruct),
+			expected: &decodeStruct{A: "a"},
+		},
+		"slashes in strings": {
+			input:    `["// not a comment", "/* nor this */"]`,
+			dest:     new([]string),
+			expected: &[]string{"// not a comment", "/* nor this */"},
+		},
+		"comment in literal": {
+			input: `tr/**/ue`,
+			dest:  new(bool),
+			err:   "invalid character '/' in literal true (expecting 'u')",
+		},
+		"unterminated block": {
+			input: `[1 /* 2]`,
+			dest:  new(interface{}),
+			err:   "unexpected EOF",
+		},
+		"lone slash": {
+			input: `[1 / 2]`,
+			dest:  new(interface{}),
+			err:   "invalid character ' ' looking for beginning of comment",
+		},
+		"trailing slash": {
+			input: `1 /`,
+			dest:  new(interface{}),
+			err:   "unexpected end of JSON input",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.AllowComments()
+			err := dec.Decode(tt.dest)
+			if err == nil {
+				err = dec.checkEnd(nil)
+			}
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if p, ok := tt.dest.(*interface{}); ok {
+				assert.Equal(t, tt.expected, *p)
+				return
+			}
+			assert.Equal(t, tt.expected, tt.dest)
+		})
+	}
+}
+
+func TestDecodeAllowJSON5(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     interface{}
+		expected interface{}
+		err      string
+	}{
+		"unquoted keys": {
+			input:    `{a: 1, $b_2: 2, ünï: 3}`,
+			dest:     new(interface{}),
+			expected: map[string]interface{}{"a": float64(1), "$b_2": float64(2), "ünï": float64(3)},
+		},
+		"single quotes": {
+			input:    `{'a': 'say "hi"', "b": 'it\'s', 'c': "it\'s"}`,
+			dest:     new(interface{}),
+			expected: map[string]interface{}{"a": `say "hi"`, "b": "it's", "c": "it's"},
+		},
+		"hex": {
+			input:    `[0x1F, 0XfF, -0x10, +0x0, 0x10000000000000000]`,
+			dest:     new([]interface{}),
+			expected: &[]interface{}{float64(31), float64(255), float64(-16), float64(0), float64(1 << 64)},
+		},
+		"hex into int": {
+			input:    `-0x7fffffffffffffff`,
+			dest:     new(int64),
+			expected: func() *int64 { n := int64(-0x7fffffffffffffff); return &n }(),
+		},
+		"plus": {
+			input:    `[+1, +0.5, -0, 0, 0.25]`,
+			dest:     new([]float64),
+			expected: &[]float64{1, 0.5, 0, 0, 0.25},
+		},
+		"comments": {
+			input:    "{a: /* one */ 1} // done",
+			dest:     new(map[string]int),
+			expected: &map[string]int{"a": 1},
+		},
+		"struct": {
+			input:    `{A: 'x', bee: +0x2, Inner: {X: 3}}`,
+			dest:     new(decodeStruct),
+			expected: &decodeStruct{A: "x", B: 2, Inner: decodeInner{X: 3}},
+		},
+		"skipped": {
+			input:    `{unknown: ['a', +Infinity, 0x1, {b: NaN}], A: 'a'}`,
+			dest:     new(decodeStruct),
+			expected: &decodeStruct{A: "a"},
+		},
+		"nan into int": {
+			input: `NaN`,
+			dest:  new(int),
+			err:   "json: cannot unmarshal number NaN into Go value of type int",
+		},
+		"bad hex": {
+			input: `0xg`,
+			dest:  new(interface{}),
+			err:   "invalid character 'g' in numeric literal",
+		},
+		"bad literal": {
+			input: `Infinite`,
+			dest:  new(interface{}),
+			err:   "invalid character 'e' in literal Infinity (expecting 'y')",
+		},
+		"bad sign": {
+			input: `+-1`,
+			dest:  new(interface{}),
+			err:   "invalid character '-' in numeric literal",
+		},
+		"identifier starts with digit": {
+			input: `{1a: 1}`,
+			dest:  new(interface{}),
+			err:   "invalid character '1' looking for beginning of object key string",
+		},
+		"unterminated single quote": {
+			input: `'abc`,
+			dest:  new(interface{}),
+			err:   "unexpected EOF",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.AllowJSON5()
+			err := dec.Decode(tt.dest)
+			if err == nil {
+				err = dec.checkEnd(nil)
+			}
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if p, ok := tt.dest.(*interface{}); ok {
+				assert.Equal(t, tt.expected, *p)
+				return
+			}
+			assert.Equal(t, tt.expected, tt.dest)
+		})
+	}
+}
+
+func TestDecodeJSON5NonFinite(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[NaN, Infinity, -Infinity, +Infinity, -NaN]`))
+	dec.AllowJSON5()
+	dec.StrictNumbers()
+	var v []float64
+	require.NoError(t, dec.Decode(&v))
+	require.Len(t, v, 5)
+	assert.True(t, math.IsNaN(v[0]))
+	assert.Equal(t, []float64{math.Inf(1), math.Inf(-1), math.Inf(1)}, v[1:4])
+	assert.True(t, math.IsNaN(v[4]))
+}
+
+func TestDecodeAllowNonFinite(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[NaN, Infinity, -Infinity, 1] {"a": -Infinity} Infinity NaN`))
+	dec.AllowNonFinite()
+	var f []float32
+	require.NoError(t, dec.Decode(&f))
+	require.Len(t, f, 4)
+	assert.True(t, math.IsNaN(float64(f[0])))
+	assert.Equal(t, []float32{float32(math.Inf(1)), float32(math.Inf(-1)), 1}, f[1:])
+
+	var m map[string]interface{}
+	require.NoError(t, dec.Decode(&m))
+	assert.Equal(t, map[string]interface{}{"a": math.Inf(-1)}, m)
+
+	var n int
+	assert.EqualError(t, dec.Decode(&n), "json: cannot unmarshal number Infinity into Go value of type int")
+	var i interface{}
+	require.NoError(t, dec.Decode(&i))
+	assert.True(t, math.IsNaN(i.(float64)))
+
+	for input, expected := range map[string]string{
+		`-NaN`:      "invalid character 'N' in numeric literal",
+		`+Infinity`: "invalid character '+' looking for beginning of value",
+		`Infinite`:  "invalid character 'e' in literal Infinity (expecting 'y')",
+		`-Inf`:      "unexpected EOF",
+		`{a: NaN}`:  "invalid character 'a' looking for beginning of object key string",
+	} {
+		dec = NewDecoder(strings.NewReader(input))
+		dec.AllowNonFinite()
+		assert.EqualError(t, dec.Decode(&i), expected, input)
+	}
+}
+
+func TestDecodeJSON5Disallowed(t *testing.T) {
+	for _, input := range []string{`{a: 1}`, `'a'`, `{'a': 1}`, `0x1`, `+1`, `NaN`, `Infinity`, `-Infinity`, `"\'"`} {
+		var v interface{}
+		assert.Error(t, Unmarshal([]byte(input), &v), input)
+	}
+}
+
+func TestTokenJSON5(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: 'b', 'c': [0x10, +Infinity]}`))
+	dec.AllowJSON5()
+	var tokens []Token
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tokens = append(tokens, tok)
+	}
+	assert.Equal(t, []Token{Delim('{'), "a", "b", "c", Delim('['), float64(16), math.Inf(1), Delim(']'), Delim('}')}, tokens)
+}
+
+func TestDecodeCommentsDisallowed(t *testing.T) {
+	for _, input := range []string{`// a` + "\n1", `[1 /* a */]`, `{"a" /* b */: 1}`, `1 /* a */`} {
+		var v interface{}
+		assert.Error(t, Unmarshal([]byte(input), &v), input)
+	}
+}
+
+func TestTokenAllowComments(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("/* a */ [ // b\n 1, /* c */ {\"d\" /* e */ : true} ] // f"))
+	dec.AllowComments()
+	var tokens []Token
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tokens = append(tokens, tok)
+	}
+	assert.Equal(t, []Token{Delim('['), float64(1), Delim('{'), "d", true, Delim('}'), Delim(']')}, tokens)
+}
+
+func TestDecodeReset(t *testing.T) {
+	var dec Decoder
+	dec.Reset(strings.NewReader(`{"a":[1`))
+	dec.SetMaxDepth(2)
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('{'), tok)
+	var v interface{}
+	require.Error(t, dec.Decode(&v))
+
+	dec.Reset(strings.NewReader("\n\n [1, 2] lol"))
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, v)
+	err = dec.Decode(&v)
+	assert.EqualError(t, err, "invalid character 'l' looking for beginning of value")
+	assert.Equal(t, int64(11), err.(*SyntaxError).Offset)
+	assert.Equal(t, int64(3), err.(*SyntaxError).Line)
+
+	dec.Reset(strings.NewReader(`[[[1]]]`))
+	assert.Equal(t, &MaxDepthError{MaxDepth: 2, Offset: 3}, dec.Decode(&v), "options are kept")
+
+	br := bufio.NewReader(strings.NewReader(`"same" "reader"`))
+	dec.Reset(br)
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, "same", v)
+	dec.Reset(br)
+	assert.Equal(t, io.EOF, dec.Decode(&v), "buffered input is lost")
+}
+
+func TestDecodeStringAllocs(t *testing.T) {
+	input := []byte(`"` + strings.Repeat("x", 1000) + `"`)
+	r := bytes.NewReader(input)
+	var (
+		dec Decoder
+		s   string
+	)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(input)
+		dec.Reset(r)
+		require.NoError(t, dec.Decode(&s))
+	})
+	assert.Equal(t, float64(1), allocs, "only the string itself should be allocated")
+	assert.Equal(t, strings.Repeat("x", 1000), s)
+}
+
+func TestDecodeScalarAllocs(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		dest   interface{}
+		allocs float64
+	}{
+		"interface bool":   {`true`, new(interface{}), 0},
+		"interface null":   {`null`, new(interface{}), 0},
+		"interface number": {`1.5`, new(interface{}), 1},
+		"interface string": {`"abc"`, new(interface{}), 2},
+		"string":           {`"abc"`, new(string), 1},
+		"bool":             {`false`, new(bool), 0},
+		"int64":            {`-12`, new(int64), 0},
+		"float64":          {`1.5e3`, new(float64), 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := []byte(tt.input)
+			r := bytes.NewReader(input)
+			var dec Decoder
+			allocs := testing.AllocsPerRun(100, func() {
+				r.Reset(input)
+				dec.Reset(r)
+				require.NoError(t, dec.Decode(tt.dest))
+			})
+			assert.Equal(t, tt.allocs, allocs)
+		})
+	}
+}
+
+func TestDecodeStructAllocs(t *testing.T) {
+	input := []byte(`{"bee": 1, "C": true, "Inner": {}, "unknown": {"x": [1, "two", null]}}`)
+	r := bytes.NewReader(input)
+	var (
+		dec Decoder
+		v   decodeStruct
+	)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(input)
+		dec.Reset(r)
+		require.NoError(t, dec.Decode(&v))
+	})
+	assert.Zero(t, allocs, "the fields of the struct should be cached")
+	assert.Equal(t, decodeStruct{B: 1, C: true}, v)
+}
+
+func TestDecodeReportPosition(t *testing.T) {
+	tests := map[string]struct {
+		input        string
+		line, column int64
+		msg          string
+	}{
+		"first line":   {`{"a" 1}`, 1, 6, "invalid character '1' after object key"},
+		"second line":  {"{\n  \"a\": tru }", 2, 11, "invalid character ' ' in literal true (expecting 'e')"},
+		"after blank":  {"[\n\n\n  1,\n  ]", 5, 3, "invalid character ']' looking for beginning of value"},
+		"crlf":         {"[\r\n1 2]", 2, 3, "invalid character '2' after array element"},
+		"newline":      {"\"a\nb\"", 1, 3, "invalid character '\\n' in string literal"},
+		"line start":   {"[1,\n}", 2, 1, "invalid character '}' looking for beginning of value"},
+		"after number": {"[1\n\n}", 3, 1, "invalid character '}' after array element"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var v interface{}
+			err := NewDecoder(strings.NewReader(tt.input)).Decode(&v)
+			synErr, ok := err.(*SyntaxError)
+			require.True(t, ok, "%T is not a *SyntaxError", err)
+			assert.Equal(t, tt.line, synErr.Line, "bad Line")
+			assert.Equal(t, tt.column, synErr.Column, "bad Column")
+			assert.EqualError(t, err, tt.msg)
+
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.ReportPosition()
+			err = dec.Decode(&v)
+			assert.EqualError(t, err, fmt.Sprintf("%s at line %d, column %d", tt.msg, tt.line, tt.column))
+		})
+	}
+}
+
+func TestSyntaxErrorContext(t *testing.T) {
+	long := `{"key": "` + strings.Repeat("v", 100) + `", "other": nope}`
+	tests := map[string]struct {
+		input   string
+		context string
+	}{
+		"first byte":  {`x`, "x\n^"},
+		"first line":  {`{"a" 1}`, "{\"a\" 1\n     ^"},
+		"second line": {"{\n  \"a\": tru }", "  \"a\": tru \n          ^"},
+		"tabs":        {"[\t1,\t\t}", "[\t1,\t\t}\n \t  \t\t^"},
+		"newline":     {"\"a\nb\"", "\"a \n  ^"},
+		"multibyte":   {`["héllo" 1]`, "[\"héllo\" 1\n         ^"},
+		"long line":   {long, strings.Repeat("v", 19) + `", "other": no` + "\n" + strings.Repeat(" ", 32) + "^"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var v interface{}
+			err := Unmarshal([]byte(tt.input), &v)
+			synErr, ok := err.(*SyntaxError)
+			require.True(t, ok, "%T is not a *SyntaxError", err)
+			assert.Equal(t, tt.context, synErr.Context())
+
+			err = NewDecoder(iotest.OneByteReader(strings.NewReader(tt.input))).Decode(&v)
+			require.IsType(t, &SyntaxError{}, err)
+			assert.Equal(t, tt.context, err.(*SyntaxError).Context(), "the context does not depend on buffering")
+		})
+	}
+}
+
+type errorPathItem struct {
+	Price int `json:"price"`
+}
+
+type errorPathOrder struct {
+	Items  []errorPathItem            `json:"items"`
+	ByKey  map[string]errorPathItem   `json:"byKey"`
+	Counts []int                      `json:"counts"`
+	Grid   [2][2]int                  `json:"grid"`
+	Nested map[string][]errorPathItem `json:"nested"`
+}
+
+func TestUnmarshalTypeErrorField(t *testing.T) {
+	tests := map[string]struct {
+		input      string
+		v          interface{}
+		structName string
+		field      string
+	}{
+		"slice of structs": {`{"items": [{}, {}, {}, {"price": "1"}]}`, &errorPathOrder{}, "errorPathItem", "items[3].price"},
+		"map of structs":   {`{"byKey": {"k": {"price": true}}}`, &errorPathOrder{}, "errorPathItem", "byKey[k].price"},
+		"slice":            {`{"counts": [1, 2, 3, "4"]}`, &errorPathOrder{}, "errorPathOrder", "counts[3]"},
+		"nested arrays":    {`{"grid": [[1, 2], [3, "4"]]}`, &errorPathOrder{}, "errorPathOrder", "grid[1][1]"},
+		"slice in map":     {`{"nested": {"a": [{"price": {}}]}}`, &errorPathOrder{}, "errorPathItem", "nested[a][0].price"},
+		"top level slice":  {`[{}, {"price": "1"}]`, &[]errorPathItem{}, "errorPathItem", "price"},
+		"top level map":    {`{"a": "1"}`, &map[string]int{}, "", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.input), tt.v)
+			var typeErr *UnmarshalTypeError
+			require.True(t, errors.As(err, &typeErr), "%T is not a *UnmarshalTypeError", err)
+			assert.Equal(t, tt.structName, typeErr.Struct)
+			assert.Equal(t, tt.field, typeErr.Field)
+		})
+	}
+}
+
+func TestDecodeTeeRaw(t *testing.T) {
+	long := `"` + strings.Repeat("a", 3*teeChunk) + `"`
+	tests := map[string]struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		"empty":      {``, ``, false},
+		"string":     {`"a"`, `"a"`, false},
+		"number":     {`1`, `1`, false},
+		"numbers":    {"1 -2\n3.5", `1-23.5`, false},
+		"spaced":     {" \t{ \"a\" : [ 1 , 2 ] }\n\n\"b\" ", `{ "a" : [ 1 , 2 ] }"b"`, false},
+		"long":       {" " + long + " 1", long + `1`, false},
+		"long split": {strings.Repeat(" ", teeChunk-1) + "1234 5", `12345`, false},
+		"invalid":    {`[1,2,}`, `[1,2,}`, true},
+		"truncated":  {`{"a":`, `{"a":`, true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.TeeRaw(&out)
+			var err error
+			for err == nil {
+				var v interface{}
+				err = dec.Decode(&v)
+			}
+			if tt.err {
+				assert.NotEqual(t, io.EOF, err)
+			} else {
+				assert.Equal(t, io.EOF, err)
+			}
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestDecodeTeeRawWriteError(t *testing.T) {
+	w := &mockWriter{}
+	w.Test(t)
+	w.On("Write", []byte(`[1]`)).Return(0, errors.New("lol")).Once()
+	dec := NewDecoder(strings.NewReader(`[1] 2`))
+	dec.TeeRaw(w)
+	var v interface{}
+	assert.EqualError(t, dec.Decode(&v), "lol")
+	assert.Equal(t, []interface{}{float64(1)}, v)
+	dec.TeeRaw(nil)
+	assert.NoError(t, dec.Decode(&v))
+	assert.Equal(t, float64(2), v)
+	w.AssertExpectations(t)
+}
+
+func TestDecodeSharedKeys(t *testing.T) {
+	input := `[{"id":1,"name":"a"},{"id":2,"name":"b"},{"name":"c","id":3},{}]`
+	var v []map[string]interface{}
+	require.NoError(t, NewDecoder(strings.NewReader(input)).Decode(&v))
+	require.Len(t, v, 4)
+	keys := map[string]*byte{}
+	for _, obj := range v {
+		for key := range obj {
+			if p, ok := keys[key]; ok {
+				assert.Equal(t, p, unsafe.StringData(key), key)
+			}
+			keys[key] = unsafe.StringData(key)
+		}
+	}
+	assert.Len(t, keys, 2)
+}
+
+func TestDecodeRawMessage(t *testing.T) {
+	type rawFields struct {
+		A    RawMessage
+		B    int
+		Rest []RawMessage
+	}
+	type rawFieldsJ struct {
+		A    json.RawMessage
+		B    int
+		Rest []json.RawMessage
+	}
 	tests := map[string]string{
-		"fist read":   ``,
-		"second read": ` `,
-		"null":        `n`,
-		"read string": `"`,
-		"unescape":    `"\`,
-		"bool":        `t`,
-		"uint":        `0`,
-		"uint2":       `10`,
-		"int":         `-`,
-		"int2":        `-1`,
-		"float":       `0.`,
-		"float2":      `0.1`,
-		"expo":        `0.1e6`,
-		"expo2":       `0.1e`,
-		"expo3":       `0.1e-`,
-		"expo4":       `0.1e-6`,
-		"arr":         `[`,
-		"arr2":        `[" "`,
-		"obj":         `{`,
-		"objkey":      `{"a"`,
-		"objsep":      `{"a":`,
-		"objval":      `{"a":"a"`,
-		"objspace":    `{ `,
+		"object":   `{"a": [1, 2.5e1, "\"x\""], "b": null}`,
+		"string":   `  "str"  `,
+		"number":   `-0.5`,
+		"literal":  `true`,
+		"null":     `null`,
+		"fields":   `{"A": {"deferred": [{}]}, "B": 2, "Rest": [1, "two", {"three": 3}]}`,
+		"invalid":  `{"a" 1}`,
+		"truncate": `[1, 2`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var expected, actual RawMessage
+			errJ := json.NewDecoder(strings.NewReader(input)).Decode((*json.RawMessage)(&expected))
+			err := NewDecoder(strings.NewReader(input)).Decode(&actual)
+			if errJ == nil {
+				assert.Equal(t, string(expected), string(actual))
+			}
+			eqaulError(t, errJ, err)
+
+			var expectedFields rawFieldsJ
+			var actualFields rawFields
+			errJ = json.NewDecoder(strings.NewReader(input)).Decode(&expectedFields)
+			err = NewDecoder(strings.NewReader(input)).Decode(&actualFields)
+			if errJ == nil {
+				assert.Equal(t, string(expectedFields.A), string(actualFields.A))
+				assert.Equal(t, expectedFields.B, actualFields.B)
+				require.Len(t, actualFields.Rest, len(expectedFields.Rest))
+				for i := range expectedFields.Rest {
+					assert.Equal(t, string(expectedFields.Rest[i]), string(actualFields.Rest[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRawMessageStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a":1} [2] "three"`))
+	var msgs []RawMessage
+	for {
+		var m RawMessage
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, m)
+	}
+	assert.Equal(t, []RawMessage{RawMessage(`{"a":1}`), RawMessage(`[2]`), RawMessage(`"three"`)}, msgs)
+}
+
+func TestRawMessageMarshalJSON(t *testing.T) {
+	b, err := RawMessage(nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+	b, err = RawMessage(`{"a":1}`).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(b))
+	assert.EqualError(t, (*RawMessage)(nil).UnmarshalJSON([]byte("1")), "json.RawMessage: UnmarshalJSON on nil pointer")
+}
+
+func TestDecodeReadError(t *testing.T) {
+	tests := map[string]struct {
+		input, kind string
+	}{
+		"fist read":   {``, ""},
+		"second read": {` `, ""},
+		"null":        {`n`, "null"},
+		"read string": {`"`, "string"},
+		"unescape":    {`"\`, "string"},
+		"bool":        {`t`, "bool"},
+		"uint":        {`0`, "number"},
+		"uint2":       {`10`, "number"},
+		"int":         {`-`, "number"},
+		"int2":        {`-1`, "number"},
+		"float":       {`0.`, "number"},
+		"float2":      {`0.1`, "number"},
+		"expo":        {`0.1e6`, "number"},
+		"expo2":       {`0.1e`, "number"},
+		"expo3":       {`0.1e-`, "number"},
+		"expo4":       {`0.1e-6`, "number"},
+		"arr":         {`[`, "array"},
+		"arr2":        {`[" "`, "array"},
+		"arr3":        {`[" `, "string"},
+		"obj":         {`{`, "object"},
+		"objkey":      {`{"a"`, "object"},
+		"objsep":      {`{"a":`, "object"},
+		"objval":      {`{"a":"a"`, "object"},
+		"objspace":    {`{ `, "object"},
+		"objnum":      {`{"a":1`, "number"},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
+			readErr := errors.New("lol")
 			primeMock := func(r *mockReader) {
 				r.Test(t)
 				t.Cleanup(func() { r.AssertExpectations(t) })
-				for _, b := range []byte(test) {
+				for _, b := range []byte(test.input) {
 					func(b byte) {
 						r.On("Read", mock.Anything).Run(func(args mock.Arguments) {
 							p := args.Get(0).([]byte)
@@ -569,20 +2270,36 @@ func TestDecodeReadError(t *testing.T) {
 						}).Return(1, nil).Once()
 					}(b)
 				}
-				r.On("Read", mock.Anything).Return(0, errors.New("lol")).Once()
+				r.On("Read", mock.Anything).Return(0, readErr).Once()
 			}
 			r := &mockReader{}
 			var x interface{}
 			primeMock(r)
 			errJ := json.NewDecoder(r).Decode(&x)
+			require.Equal(t, readErr, errJ)
 			r = &mockReader{}
 			primeMock(r)
 			err := NewDecoder(r).Decode(&x)
-			eqaulError(t, errJ, err)
+			assert.Equal(t, &DecodeError{
+				Kind:   test.kind,
+				Offset: int64(len(test.input)),
+				Err:    readErr,
+			}, err)
+			assert.True(t, errors.Is(err, readErr))
 		})
 	}
 }
 
+func TestDecodeErrorMessage(t *testing.T) {
+	err := &DecodeError{Offset: 3, Err: io.ErrClosedPipe}
+	assert.EqualError(t, err, "json: read error at offset 3: io: read/write on closed pipe")
+	err.Kind = "string"
+	assert.EqualError(t, err, "json: read error in string at offset 3: io: read/write on closed pipe")
+}
+
+// fixtureSize is the size of the documents BenchmarkDecode generates.
+var fixtureSize = flag.Int("fixture.size", 64<<10, "size in bytes of the documents generated for BenchmarkDecode")
+
 func BenchmarkDecode(b *testing.B) {
 	tests, err := ioutil.ReadDir("fixtures")
 	require.NoError(b, err)
@@ -590,35 +2307,48 @@ func BenchmarkDecode(b *testing.B) {
 		b.Run(file.Name(), func(b *testing.B) {
 			input, err := ioutil.ReadFile(filepath.Join("fixtures", file.Name()))
 			require.NoError(b, err)
-
-			b.Run("github.com/brackendawson/json", func(b *testing.B) {
-				for i := 0; i < b.N; i++ {
-					var v interface{}
-					if err := NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
-						b.Fatal(err)
-					}
-				}
-			})
-			b.Run("encoding/json                ", func(b *testing.B) {
-				for i := 0; i < b.N; i++ {
-					var v interface{}
-					if err := json.NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
-						b.Fatal(err)
-					}
-				}
-			})
-			b.Run("github.com/intel-go/fastjson ", func(b *testing.B) {
-				for i := 0; i < b.N; i++ {
-					var v interface{}
-					if err := fastjson.NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
-						b.Fatal(err)
-					}
-				}
-			})
+			benchmarkDecode(b, input)
 		})
 	}
+	for _, k := range fixture.Kinds {
+		b.Run("generated "+k.String(), func(b *testing.B) {
+			benchmarkDecode(b, fixture.Generate(k, *fixtureSize))
+		})
+	}
+}
+
+func benchmarkDecode(b *testing.B, input []byte) {
+	b.Run("github.com/brackendawson/json", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json                ", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := json.NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("github.com/intel-go/fastjson ", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := fastjson.NewDecoder(bytes.NewReader(input)).Decode(&v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
 
+var errorIndexes = regexp.MustCompile(`\[[^\]]*\]`)
+
 func eqaulError(t *testing.T, expected, err error) {
 	t.Log("expected error: ", expected)
 	t.Log("actual error  : ", err)
@@ -638,14 +2368,19 @@ func eqaulError(t *testing.T, expected, err error) {
 			t.Errorf("Incorrect error type %T, expected *InvalidUnmarshalError: %s", err, err)
 		}
 	case *json.UnmarshalTypeError:
-		assert.EqualError(t, err, expected.Error())
 		if err2, ok := err.(*UnmarshalTypeError); ok {
+			// encoding/json does not report array indexes or map keys in Field.
+			stripped := *err2
+			stripped.Field = errorIndexes.ReplaceAllString(err2.Field, "")
+			err2 = &stripped
+			assert.EqualError(t, err2, expected.Error())
 			assert.Equal(t, expected.Value, err2.Value, "bad Value")
 			assert.Equal(t, expected.Type, err2.Type, "bad Type")
 			assert.Equal(t, expected.Offset, err2.Offset, "bad Offset")
 			assert.Equal(t, expected.Struct, err2.Struct, "bad Struct")
 			assert.Equal(t, expected.Field, err2.Field, "bad Field")
 		} else {
+			assert.EqualError(t, err, expected.Error())
 			t.Errorf("Incorrect error type %T, expected *UnmarshalTypeError: %s", err, err)
 		}
 	default:
@@ -662,3 +2397,12 @@ func (m *mockReader) Read(b []byte) (int, error) {
 	args := m.Called(b)
 	return args.Int(0), args.Error(1)
 }
+
+type mockWriter struct {
+	mock.Mock
+}
+
+func (m *mockWriter) Write(b []byte) (int, error) {
+	args := m.Called(b)
+	return args.Int(0), args.Error(1)
+}
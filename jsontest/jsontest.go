@@ -0,0 +1,124 @@
+// Package jsontest provides helpers for testing and fuzzing code that uses
+// github.com/brackendawson/json, by comparing it with encoding/json.
+package jsontest
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/brackendawson/json"
+)
+
+// Divergence is returned by CompareWithStdlib when the two packages decode an
+// input differently.
+type Divergence struct {
+	Input []byte
+	// Value and Err are the results of this package.
+	Value interface{}
+	Err   error
+	// StdlibValue and StdlibErr are the results of encoding/json.
+	StdlibValue interface{}
+	StdlibErr   error
+	msg         string
+}
+
+func (d *Divergence) Error() string {
+	input := d.Input
+	if len(input) > maxInput {
+		input = input[:maxInput]
+		return fmt.Sprintf("jsontest: decoding %q...: %s", input, d.msg)
+	}
+	return fmt.Sprintf("jsontest: decoding %q: %s", input, d.msg)
+}
+
+// maxInput is the length of the longest input quoted in full by a Divergence.
+const maxInput = 64
+
+// CompareWithStdlib decodes input into a new T with Unmarshal of both this
+// package and encoding/json, and returns a *Divergence if they disagree. They
+// agree if both succeed with equal values, or if both fail with the same error
+// message and, for syntax and type errors, the same details. The array indexes
+// and map keys that this package adds to the Field of type errors are ignored.
+// Values are not compared after an error, as what has been decoded is
+// unspecified.
+//
+// It suits fuzz targets:
+//
+//	func FuzzDecode(f *testing.F) {
+//		f.Fuzz(func(t *testing.T, input []byte) {
+//			if err := jsontest.CompareWithStdlib[interface{}](input); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+//
+// Inputs using the package's extensions, such as the default tag option, or
+// exceeding its limits, such as DefaultMaxNumberLen, are expected to diverge.
+func CompareWithStdlib[T any](input []byte) error {
+	var value, stdlibValue T
+	d := &Divergence{
+		Input:       input,
+		Err:         json.Unmarshal(input, &value),
+		StdlibErr:   stdjson.Unmarshal(input, &stdlibValue),
+		Value:       value,
+		StdlibValue: stdlibValue,
+	}
+	switch {
+	case d.Err == nil && d.StdlibErr == nil:
+		if reflect.DeepEqual(value, stdlibValue) {
+			return nil
+		}
+		d.msg = fmt.Sprintf("got %#v, encoding/json got %#v", value, stdlibValue)
+	case d.Err == nil:
+		d.msg = fmt.Sprintf("got %#v, encoding/json failed: %v", value, d.StdlibErr)
+	case d.StdlibErr == nil:
+		d.msg = fmt.Sprintf("failed: %v, encoding/json got %#v", d.Err, stdlibValue)
+	default:
+		d.msg = compareErrors(d.Err, d.StdlibErr)
+		if d.msg == "" {
+			return nil
+		}
+	}
+	return d
+}
+
+// fieldIndexes matches the array indexes and map keys in the Field of an
+// UnmarshalTypeError.
+var fieldIndexes = regexp.MustCompile(`\[[^\]]*\]`)
+
+// compareErrors describes how err differs from stdlibErr, the error of
+// encoding/json, or returns the empty string if it does not.
+func compareErrors(err, stdlibErr error) string {
+	if e, ok := err.(*json.UnmarshalTypeError); ok {
+		// encoding/json does not report array indexes or map keys in Field.
+		stripped := *e
+		stripped.Field = fieldIndexes.ReplaceAllString(e.Field, "")
+		err = &stripped
+	}
+	if err.Error() != stdlibErr.Error() {
+		return fmt.Sprintf("failed: %v, encoding/json failed: %v", err, stdlibErr)
+	}
+	switch stdlibErr := stdlibErr.(type) {
+	case *stdjson.SyntaxError:
+		e, ok := err.(*json.SyntaxError)
+		if !ok {
+			return fmt.Sprintf("got error type %T, encoding/json got %T", err, stdlibErr)
+		}
+		if e.Offset != stdlibErr.Offset {
+			return fmt.Sprintf("got syntax error at offset %d, encoding/json got %d", e.Offset, stdlibErr.Offset)
+		}
+	case *stdjson.UnmarshalTypeError:
+		e, ok := err.(*json.UnmarshalTypeError)
+		if !ok {
+			return fmt.Sprintf("got error type %T, encoding/json got %T", err, stdlibErr)
+		}
+		actual := []interface{}{e.Value, e.Type, e.Offset, e.Struct, e.Field}
+		expected := []interface{}{stdlibErr.Value, stdlibErr.Type, stdlibErr.Offset, stdlibErr.Struct, stdlibErr.Field}
+		if !reflect.DeepEqual(actual, expected) {
+			return fmt.Sprintf("got type error %v, encoding/json got %v", actual, expected)
+		}
+	}
+	return ""
+}
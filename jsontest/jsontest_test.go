@@ -0,0 +1,92 @@
+package jsontest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+type defaulted struct {
+	Count int `json:"count,default:5"`
+}
+
+func TestCompareWithStdlib(t *testing.T) {
+	inputs := []string{
+		`{"name": "a", "count": 1, "tags": ["x"]}`,
+		`{"NAME": "a", "unknown": {}}`,
+		`null`,
+		`{"count": "1"}`,
+		`{"tags": [1]}`,
+		`{"name": "a"`,
+		`{"name": tru}`,
+		`[1, 2]`,
+		``,
+	}
+	for _, input := range inputs {
+		assert.NoError(t, CompareWithStdlib[record]([]byte(input)), input)
+		assert.NoError(t, CompareWithStdlib[interface{}]([]byte(input)), input)
+	}
+}
+
+func TestCompareWithStdlibDivergence(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		compare  func([]byte) error
+		expected string
+	}{
+		"value": {
+			input:    `{}`,
+			compare:  CompareWithStdlib[defaulted],
+			expected: `jsontest: decoding "{}": got jsontest.defaulted{Count:5}, encoding/json got jsontest.defaulted{Count:0}`,
+		},
+		"null": {
+			input:    `{"count": null}`,
+			compare:  CompareWithStdlib[defaulted],
+			expected: `jsontest: decoding "{\"count\": null}": got jsontest.defaulted{Count:5}, encoding/json got jsontest.defaulted{Count:0}`,
+		},
+		"limit": {
+			input:    "1." + strings.Repeat("0", 9999),
+			compare:  CompareWithStdlib[interface{}],
+			expected: `jsontest: decoding "1.` + strings.Repeat("0", 62) + `"...: failed: json: number literal exceeds 10000 bytes at offset 10001, encoding/json got 1`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.compare([]byte(tt.input))
+			assert.EqualError(t, err, tt.expected)
+			var d *Divergence
+			require.True(t, errors.As(err, &d))
+			assert.Equal(t, []byte(tt.input), d.Input)
+		})
+	}
+}
+
+func FuzzCompareWithStdlib(f *testing.F) {
+	for _, input := range []string{
+		`{"name": "a", "count": 1, "tags": ["x", "y"]}`,
+		`[1, -2.5e3, true, false, null, "\u00e9\n"]`,
+		`{"count": 1.5}`,
+		`{"tags": "x"}`,
+		`{"name": "a", }`,
+		`"\ud800"`,
+	} {
+		f.Add([]byte(input))
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if err := CompareWithStdlib[interface{}](input); err != nil {
+			t.Fatal(err)
+		}
+		if err := CompareWithStdlib[record](input); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package json
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// maxSafeInteger is the largest integer that a JavaScript number holds
+// exactly, along with every integer nearer zero.
+const maxSafeInteger = 1<<53 - 1
+
+// StringifyLargeInts causes the Encoder to write integers beyond the range that
+// JavaScript numbers hold exactly, ±(2^53-1), as strings, as in "9007199254740993",
+// so that clients parsing the output with JavaScript do not lose precision.
+// Smaller integers are still written as numbers. A Decoder reads such strings
+// back if it is set to AllowQuotedInts.
+func (enc *Encoder) StringifyLargeInts() {
+	enc.stringifyLargeInts = true
+}
+
+// AllowQuotedInts causes the Decoder to accept strings holding a JSON integer,
+// such as those written by an Encoder set to StringifyLargeInts, as the value of
+// integer types.
+func (d *Decoder) AllowQuotedInts() {
+	d.allowQuotedInts = true
+}
+
+// appendInt appends the integer n, quoted if it is too large for JavaScript and
+// the Encoder is set to StringifyLargeInts.
+func (e *encodeState) appendInt(n int64) {
+	if e.stringifyLargeInts && (n > maxSafeInteger || n < -maxSafeInteger) {
+		e.buf = append(strconv.AppendInt(append(e.buf, '"'), n, 10), '"')
+		return
+	}
+	e.buf = strconv.AppendInt(e.buf, n, 10)
+}
+
+// appendUint appends the unsigned integer n as appendInt does.
+func (e *encodeState) appendUint(n uint64) {
+	if e.stringifyLargeInts && n > maxSafeInteger {
+		e.buf = append(strconv.AppendUint(append(e.buf, '"'), n, 10), '"')
+		return
+	}
+	e.buf = strconv.AppendUint(e.buf, n, 10)
+}
+
+// setQuotedInt stores the integer in the string buf in the integer pointed to
+// by v.
+func (d *Decoder) setQuotedInt(buf []byte, v reflect.Value) error {
+	s := string(buf)
+	if !isValidNumber(s) {
+		return d.unmarshalTypeError("string "+strconv.Quote(s), v.Elem().Type())
+	}
+	if v.Elem().CanInt() {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v.Elem().OverflowInt(n) {
+			return d.unmarshalTypeError("string "+strconv.Quote(s), v.Elem().Type())
+		}
+		v.Elem().SetInt(n)
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || v.Elem().OverflowUint(n) {
+		return d.unmarshalTypeError("string "+strconv.Quote(s), v.Elem().Type())
+	}
+	v.Elem().SetUint(n)
+	return nil
+}
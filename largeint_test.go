@@ -0,0 +1,142 @@
+package json
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type largeInts struct {
+	ID     int64       `json:"id"`
+	Count  uint64      `json:"count"`
+	Small  int32       `json:"small"`
+	Quoted int64       `json:"quoted,string"`
+	Ptr    *int64      `json:"ptr,omitempty"`
+	Any    interface{} `json:"any,omitempty"`
+}
+
+func TestEncoderStringifyLargeInts(t *testing.T) {
+	big := int64(1 << 60)
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"max safe":         {int64(maxSafeInteger), `9007199254740991`},
+		"min safe":         {int64(-maxSafeInteger), `-9007199254740991`},
+		"above max safe":   {int64(maxSafeInteger + 1), `"9007199254740992"`},
+		"below min safe":   {int64(-maxSafeInteger - 1), `"-9007199254740992"`},
+		"max int64":        {int64(math.MaxInt64), `"9223372036854775807"`},
+		"min int64":        {int64(math.MinInt64), `"-9223372036854775808"`},
+		"uint max safe":    {uint64(maxSafeInteger), `9007199254740991`},
+		"max uint64":       {uint64(math.MaxUint64), `"18446744073709551615"`},
+		"int":              {1 << 53, `"9007199254740992"`},
+		"small":            {int32(math.MaxInt32), `2147483647`},
+		"float":            {float64(1 << 60), `1152921504606847000`},
+		"slice":            {[]int64{1, 1 << 60}, `[1,"1152921504606846976"]`},
+		"map key":          {map[int64]int64{1 << 60: 1}, `{"1152921504606846976":1}`},
+		"fields":           {largeInts{ID: 1 << 60, Count: 1, Small: 1, Quoted: 1 << 60, Ptr: &big, Any: uint64(1 << 60)}, `{"id":"1152921504606846976","count":1,"small":1,"quoted":"1152921504606846976","ptr":"1152921504606846976","any":"1152921504606846976"}`},
+		"string option":    {largeInts{Quoted: 1}, `{"id":0,"count":0,"small":0,"quoted":"1"}`},
+		"registered value": {registeredLargeInt{1 << 60}, `{"n":"1152921504606846976"}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.StringifyLargeInts()
+			enc.RegisterEncoder(reflect.TypeOf(registeredLargeInt{}), func(enc *Encoder, v reflect.Value) error {
+				return enc.Encode(map[string]int64{"n": v.Interface().(registeredLargeInt).n})
+			})
+			require.NoError(t, enc.Encode(tt.value))
+			assert.Equal(t, tt.expected+"\n", buf.String())
+		})
+	}
+}
+
+type registeredLargeInt struct {
+	n int64
+}
+
+func TestMarshalLargeInts(t *testing.T) {
+	b, err := Marshal(largeInts{ID: 1 << 60})
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1152921504606846976,"count":0,"small":0,"quoted":"0"}`, string(b))
+}
+
+func TestDecoderAllowQuotedInts(t *testing.T) {
+	big := int64(1 << 60)
+	tests := map[string]struct {
+		input    string
+		expected largeInts
+	}{
+		"numbers": {
+			input:    `{"id": 1152921504606846976, "count": 18446744073709551615, "small": -1}`,
+			expected: largeInts{ID: 1 << 60, Count: math.MaxUint64, Small: -1},
+		},
+		"strings": {
+			input:    `{"id": "1152921504606846976", "count": "18446744073709551615", "small": "-1", "ptr": "1152921504606846976"}`,
+			expected: largeInts{ID: 1 << 60, Count: math.MaxUint64, Small: -1, Ptr: &big},
+		},
+		"interface": {
+			input:    `{"any": "1152921504606846976"}`,
+			expected: largeInts{Any: "1152921504606846976"},
+		},
+		"string option": {
+			input:    `{"quoted": "1152921504606846976"}`,
+			expected: largeInts{Quoted: 1 << 60},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.AllowQuotedInts()
+			var actual largeInts
+			require.NoError(t, dec.Decode(&actual))
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDecoderAllowQuotedIntsError(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expectedErr string
+	}{
+		"not a number": {`{"id": "x"}`, `json: cannot unmarshal string "x" into Go struct field largeInts.id of type int64`},
+		"fraction":     {`{"id": "1.5"}`, `json: cannot unmarshal string "1.5" into Go struct field largeInts.id of type int64`},
+		"exponent":     {`{"id": "1e3"}`, `json: cannot unmarshal string "1e3" into Go struct field largeInts.id of type int64`},
+		"padded":       {`{"id": " 1"}`, `json: cannot unmarshal string " 1" into Go struct field largeInts.id of type int64`},
+		"overflow":     {`{"small": "2147483648"}`, `json: cannot unmarshal string "2147483648" into Go struct field largeInts.small of type int32`},
+		"negative":     {`{"count": "-1"}`, `json: cannot unmarshal string "-1" into Go struct field largeInts.count of type uint64`},
+		"uint64":       {`{"count": "18446744073709551616"}`, `json: cannot unmarshal string "18446744073709551616" into Go struct field largeInts.count of type uint64`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.AllowQuotedInts()
+			assert.EqualError(t, dec.Decode(new(largeInts)), tt.expectedErr)
+		})
+	}
+
+	// without the option strings are not integers
+	err := Unmarshal([]byte(`{"id": "1"}`), new(largeInts))
+	assert.EqualError(t, err, "json: cannot unmarshal string into Go struct field largeInts.id of type int64")
+}
+
+func TestStringifyLargeIntsRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.StringifyLargeInts()
+	expected := largeInts{ID: -1 << 62, Count: math.MaxUint64, Small: 3, Quoted: 1 << 60}
+	require.NoError(t, enc.Encode(expected))
+
+	dec := NewDecoder(&buf)
+	dec.AllowQuotedInts()
+	var actual largeInts
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,103 @@
+package json
+
+import (
+	"bufio"
+	"io"
+)
+
+// LinesDecoder decodes newline delimited JSON, also known as JSON Lines, where
+// each line of the input holds exactly one JSON value. Blank lines are skipped
+// and errors are reported as a *LineError giving the line number.
+type LinesDecoder struct {
+	in   *bufio.Reader
+	buf  []byte
+	line int64
+}
+
+func NewLinesDecoder(r io.Reader) *LinesDecoder {
+	return &LinesDecoder{
+		in: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next line holding a value from the input and decodes it
+// into the value pointed to by v. It returns io.EOF when the input ends. A
+// final line without a terminating newline is decoded.
+func (l *LinesDecoder) Decode(v interface{}) error {
+	for {
+		line, err := l.readLine()
+		if err != nil {
+			if err == io.EOF {
+				return err
+			}
+			return &LineError{Line: l.line, Err: err}
+		}
+		if isBlank(line) {
+			continue
+		}
+		if err := Unmarshal(line, v); err != nil {
+			return &LineError{Line: l.line, Err: err}
+		}
+		return nil
+	}
+}
+
+// Line returns the 1-based number of the line last read.
+func (l *LinesDecoder) Line() int64 {
+	return l.line
+}
+
+// readLine reads the next line including its newline. The line is only valid
+// until the next call.
+func (l *LinesDecoder) readLine() ([]byte, error) {
+	line, err := l.in.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		l.buf = append(l.buf[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = l.in.ReadSlice('\n')
+			l.buf = append(l.buf, line...)
+		}
+		line = l.buf
+	}
+	if len(line) > 0 {
+		l.line++
+		if err == io.EOF {
+			// the last line has no newline
+			err = nil
+		}
+	}
+	return line, err
+}
+
+// isBlank reports whether b is only JSON whitespace.
+func isBlank(b []byte) bool {
+	for _, c := range b {
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// LinesEncoder writes newline delimited JSON, each value is written on its own
+// line.
+type LinesEncoder struct {
+	enc *Encoder
+}
+
+func NewLinesEncoder(w io.Writer) *LinesEncoder {
+	return &LinesEncoder{
+		enc: NewEncoder(w),
+	}
+}
+
+// Encode writes the JSON encoding of v followed by a newline. Nothing is written
+// if v cannot be encoded.
+func (l *LinesEncoder) Encode(v interface{}) error {
+	return l.enc.Encode(v)
+}
+
+// SetEscapeHTML is as Encoder.SetEscapeHTML.
+func (l *LinesEncoder) SetEscapeHTML(on bool) {
+	l.enc.SetEscapeHTML(on)
+}
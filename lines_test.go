@@ -0,0 +1,148 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinesDecoder(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []interface{}
+		lines    []int64
+		err      string
+	}{
+		"empty": {
+			input: ``,
+		},
+		"one line": {
+			input:    "{\"a\":1}\n",
+			expected: []interface{}{map[string]interface{}{"a": float64(1)}},
+			lines:    []int64{1},
+		},
+		"no final newline": {
+			input:    "1\n\"two\"",
+			expected: []interface{}{float64(1), "two"},
+			lines:    []int64{1, 2},
+		},
+		"blank lines": {
+			input:    "\n1\n  \n\t\r\n[2]\n\n",
+			expected: []interface{}{float64(1), []interface{}{float64(2)}},
+			lines:    []int64{2, 5},
+		},
+		"crlf": {
+			input:    "true\r\nnull\r\n",
+			expected: []interface{}{true, nil},
+			lines:    []int64{1, 2},
+		},
+		"surrounding space": {
+			input:    "  1  \n",
+			expected: []interface{}{float64(1)},
+			lines:    []int64{1},
+		},
+		"value split over lines": {
+			input: "[1,\n2]\n",
+			err:   "json: line 1: unexpected end of JSON input",
+		},
+		"two values on a line": {
+			input:    "1\n2 3\n",
+			expected: []interface{}{float64(1)},
+			lines:    []int64{1},
+			err:      "json: line 2: invalid character '3' after top-level value",
+		},
+		"syntax error": {
+			input:    "1\n\n{\"a\" 1}\n",
+			expected: []interface{}{float64(1)},
+			lines:    []int64{1},
+			err:      "json: line 3: invalid character '1' after object key",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewLinesDecoder(strings.NewReader(test.input))
+			var (
+				actual []interface{}
+				lines  []int64
+				err    error
+			)
+			for {
+				var v interface{}
+				if err = dec.Decode(&v); err != nil {
+					break
+				}
+				actual = append(actual, v)
+				lines = append(lines, dec.Line())
+			}
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.lines, lines)
+			if test.err == "" {
+				assert.Equal(t, io.EOF, err)
+				return
+			}
+			assert.EqualError(t, err, test.err)
+			var lineErr *LineError
+			require.True(t, errors.As(err, &lineErr))
+			assert.Equal(t, lineErr.Line, dec.Line())
+		})
+	}
+}
+
+func TestLinesDecoderLongLine(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	input := `"` + long + "\"\n2\n"
+	dec := NewLinesDecoder(iotest.OneByteReader(strings.NewReader(input)))
+
+	var s string
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, long, s)
+	var n int
+	require.NoError(t, dec.Decode(&n))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.EOF, dec.Decode(&n))
+}
+
+func TestLinesDecoderReadError(t *testing.T) {
+	readErr := errors.New("boom")
+	dec := NewLinesDecoder(io.MultiReader(strings.NewReader("1\n2"), iotest.ErrReader(readErr)))
+
+	var n int
+	require.NoError(t, dec.Decode(&n))
+	err := dec.Decode(&n)
+	assert.EqualError(t, err, "json: line 2: boom")
+	assert.True(t, errors.Is(err, readErr))
+}
+
+func TestLinesEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewLinesEncoder(&buf)
+	require.NoError(t, enc.Encode(map[string]interface{}{"a": []int{1, 2}}))
+	require.NoError(t, enc.Encode("<b>"))
+	enc.SetEscapeHTML(false)
+	require.NoError(t, enc.Encode("<b>"))
+	assert.Error(t, enc.Encode(complex(1, 2)))
+	assert.Equal(t, "{\"a\":[1,2]}\n\"\\u003cb\\u003e\"\n\"<b>\"\n", buf.String())
+
+	dec := NewLinesDecoder(&buf)
+	var actual []interface{}
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			require.Equal(t, io.EOF, err)
+			break
+		}
+		actual = append(actual, v)
+	}
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": []interface{}{float64(1), float64(2)}},
+		"<b>",
+		"<b>",
+	}, actual)
+}
@@ -0,0 +1,57 @@
+package json
+
+// MergePatch applies the JSON Merge Patch patch, as defined by RFC 7386, to the
+// JSON document target and returns the patched document. An object in the
+// patch is merged into the target member by member, where a null removes the
+// member, any other value replaces the target. The order of the target's
+// members and the text of its numbers are kept.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	t, err := parseNode(target)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parseNode(patch)
+	if err != nil {
+		return nil, err
+	}
+	return appendNode(nil, mergeNode(t, p)), nil
+}
+
+// mergeNode merges patch into target, which may be nil, and returns the result.
+func mergeNode(target, patch *node) *node {
+	if patch.kind != '{' {
+		return patch
+	}
+	if target == nil || target.kind != '{' {
+		target = &node{kind: '{'}
+	}
+	for i, key := range patch.keys {
+		j := target.member(key)
+		switch {
+		case patch.values[i].kind == 'n':
+			if j >= 0 {
+				target.keys = append(target.keys[:j], target.keys[j+1:]...)
+				target.values = append(target.values[:j], target.values[j+1:]...)
+			}
+		case j >= 0:
+			target.values[j] = mergeNode(target.values[j], patch.values[i])
+		default:
+			target.keys = append(target.keys, key)
+			target.values = append(target.values, mergeNode(nil, patch.values[i]))
+		}
+	}
+	return target
+}
+
+// DecodeMergePatch reads the next value from the input as a JSON Merge Patch,
+// as defined by RFC 7386, and applies it to the value pointed to by v. Objects
+// are merged into structs, maps and OrderedMaps, and into a
+// map[string]interface{} held in an interface, as Decode does but keeping the
+// existing values of map elements. A null sets what it is decoded into to its
+// zero value, even if that cannot be nil, and removes a map element. Anything
+// else replaces the existing value, arrays included.
+func (d *Decoder) DecodeMergePatch(v interface{}) error {
+	d.mergePatch = true
+	defer func() { d.mergePatch = false }()
+	return d.Decode(v)
+}
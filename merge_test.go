@@ -0,0 +1,136 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := map[string]struct {
+		target, patch, expected string
+	}{
+		// the examples of RFC 7386 appendix A
+		"replace member":     {`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		"add member":         {`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		"remove member":      {`{"a":"b"}`, `{"a":null}`, `{}`},
+		"remove one":         {`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		"replace array":      {`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		"replace with array": {`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		"nested":             {`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		"array of objects":   {`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		"arrays":             {`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		"object to array":    {`{"a":"b"}`, `["c"]`, `["c"]`},
+		"object to null":     {`{"a":"foo"}`, `null`, `null`},
+		"object to string":   {`{"a":"foo"}`, `"bar"`, `"bar"`},
+		"null stays":         {`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		"array to object":    {`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		"new nested":         {`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		"order and numbers":  {`{"z": 1.0, "y": 1e2, "x": 3}`, `{"y": 2}`, `{"z":1.0,"y":2,"x":3}`},
+		"escaped keys":       {`{"a\"b": 1}`, `{"a\u0022b": {"c": 2}}`, `{"a\"b":{"c":2}}`},
+		"remove missing":     {`{"a": 1}`, `{"b": null}`, `{"a":1}`},
+		"empty patch":        {`{"a": 1}`, `{}`, `{"a":1}`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := MergePatch([]byte(test.target), []byte(test.patch))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+
+	_, err := MergePatch([]byte(`{`), []byte(`{}`))
+	assert.EqualError(t, err, "unexpected end of JSON input")
+	_, err = MergePatch([]byte(`{}`), []byte(`{"a"}`))
+	assert.EqualError(t, err, "invalid character '}' after object key")
+}
+
+type mergeTarget struct {
+	Name    string
+	Count   int
+	Tags    []string
+	Inner   decodeInner
+	Ptr     *decodeInner
+	Labels  map[string]string
+	Structs map[string]decodeInner
+	Any     interface{}
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	target := func() mergeTarget {
+		return mergeTarget{
+			Name:    "n",
+			Count:   2,
+			Tags:    []string{"a", "b"},
+			Inner:   decodeInner{X: 1, Y: 2},
+			Ptr:     &decodeInner{X: 3},
+			Labels:  map[string]string{"a": "1", "b": "2"},
+			Structs: map[string]decodeInner{"s": {X: 4, Y: 5}},
+			Any:     map[string]interface{}{"k": "v", "m": map[string]interface{}{"x": 1.0}},
+		}
+	}
+	tests := map[string]struct {
+		patch    string
+		expected func(*mergeTarget)
+	}{
+		"empty": {
+			patch:    `{}`,
+			expected: func(*mergeTarget) {},
+		},
+		"replace": {
+			patch:    `{"Name": "m", "Tags": ["c"]}`,
+			expected: func(m *mergeTarget) { m.Name, m.Tags = "m", []string{"c"} },
+		},
+		"null zeroes": {
+			patch:    `{"Name": null, "Count": null, "Inner": null, "Ptr": null}`,
+			expected: func(m *mergeTarget) { m.Name, m.Count, m.Inner, m.Ptr = "", 0, decodeInner{}, nil },
+		},
+		"merge structs": {
+			patch:    `{"Inner": {"Y": 7}, "Ptr": {"Y": 8}}`,
+			expected: func(m *mergeTarget) { m.Inner.Y, m.Ptr.Y = 7, 8 },
+		},
+		"merge maps": {
+			patch: `{"Labels": {"a": null, "c": "3"}, "Structs": {"s": {"Y": 6}, "t": {"X": 1}}}`,
+			expected: func(m *mergeTarget) {
+				m.Labels = map[string]string{"b": "2", "c": "3"}
+				m.Structs = map[string]decodeInner{"s": {X: 4, Y: 6}, "t": {X: 1}}
+			},
+		},
+		"merge interface": {
+			patch: `{"Any": {"k": null, "m": {"y": 2}, "n": {"a": null}}}`,
+			expected: func(m *mergeTarget) {
+				m.Any = map[string]interface{}{"m": map[string]interface{}{"x": 1.0, "y": 2.0}, "n": map[string]interface{}{}}
+			},
+		},
+		"replace interface": {
+			patch:    `{"Any": [1]}`,
+			expected: func(m *mergeTarget) { m.Any = []interface{}{1.0} },
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, expected := target(), target()
+			test.expected(&expected)
+			dec := NewDecoder(strings.NewReader(test.patch))
+			require.NoError(t, dec.DecodeMergePatch(&actual))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestDecodeMergePatchStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": null} {"a": null} null`))
+	m := map[string]int{"a": 1, "b": 2}
+	require.NoError(t, dec.DecodeMergePatch(&m))
+	assert.Equal(t, map[string]int{"b": 2}, m)
+
+	m["a"] = 1
+	require.NoError(t, dec.Decode(&m), "Decode does not merge")
+	assert.Equal(t, map[string]int{"a": 0, "b": 2}, m)
+
+	s := "s"
+	require.NoError(t, dec.DecodeMergePatch(&s))
+	assert.Equal(t, "", s)
+}
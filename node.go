@@ -0,0 +1,200 @@
+package json
+
+import (
+	"io"
+	"math/big"
+)
+
+// node is a parsed JSON value that keeps the order of object members and the
+// exact text of numbers, so that a document can be edited and written again
+// without changing the parts that were not edited.
+type node struct {
+	// kind is '{' for an object, '[' for an array, or otherwise the first
+	// byte of the scalar in raw.
+	kind byte
+	raw  []byte
+	// keys are the keys of an object's members, in order.
+	keys []string
+	// values are the values of an object's members, or an array's elements.
+	values []*node
+}
+
+// parseNode parses the single JSON value in data.
+func parseNode(data []byte) (*node, error) {
+	if err := checkValid(data); err != nil {
+		return nil, err
+	}
+	d := &Decoder{buf: data}
+	c, err := d.readNonSpace()
+	if err != nil {
+		return nil, err
+	}
+	return d.readNode(c)
+}
+
+// readNode reads the value beginning with c, which is known to be valid.
+func (d *Decoder) readNode(c byte) (*node, error) {
+	n := &node{kind: c}
+	if c != '{' && c != '[' {
+		raw, err := d.readRaw(c)
+		if err != nil {
+			return nil, err
+		}
+		n.raw = append([]byte(nil), raw...)
+		return n, nil
+	}
+	for {
+		c, err := d.readNonSpace()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if c == ',' {
+			continue
+		}
+		if c == '}' || c == ']' {
+			return n, nil
+		}
+		if n.kind == '{' {
+			key, _, err := d.readObjectKey(c)
+			if err != nil {
+				return nil, err
+			}
+			if err = d.readObjectSeparator(); err != nil {
+				return nil, err
+			}
+			if c, err = d.readNonSpace(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			n.keys = append(n.keys, key)
+		}
+		value, err := d.readNode(c)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+	}
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF in place of io.EOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendNode appends the compact JSON encoding of n.
+func appendNode(b []byte, n *node) []byte {
+	switch n.kind {
+	case '{':
+		b = append(b, '{')
+		for i, key := range n.keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendString(b, key, false, false)
+			b = append(b, ':')
+			b = appendNode(b, n.values[i])
+		}
+		return append(b, '}')
+	case '[':
+		b = append(b, '[')
+		for i, value := range n.values {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendNode(b, value)
+		}
+		return append(b, ']')
+	default:
+		return append(b, n.raw...)
+	}
+}
+
+// member returns the index of the first member of the object n named key, or
+// -1 if there is none.
+func (n *node) member(key string) int {
+	for i, k := range n.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// find returns the part of n named by the reference tokens of a JSON Pointer,
+// or nil if there is no such part.
+func (n *node) find(tokens []string) *node {
+	for _, token := range tokens {
+		switch n.kind {
+		case '{':
+			i := n.member(token)
+			if i < 0 {
+				return nil
+			}
+			n = n.values[i]
+		case '[':
+			i := pointerIndex(token)
+			if i < 0 || i >= len(n.values) {
+				return nil
+			}
+			n = n.values[i]
+		default:
+			return nil
+		}
+	}
+	return n
+}
+
+// clone returns a deep copy of n.
+func (n *node) clone() *node {
+	c := &node{kind: n.kind, raw: n.raw}
+	c.keys = append(c.keys, n.keys...)
+	for _, value := range n.values {
+		c.values = append(c.values, value.clone())
+	}
+	return c
+}
+
+// equal reports whether n and o are the same JSON value, objects are equal if
+// they have the same members in any order, and numbers if they have the same
+// value however they are written.
+func (n *node) equal(o *node) bool {
+	switch {
+	case n.kind == '{' && o.kind == '{':
+		if len(n.keys) != len(o.keys) {
+			return false
+		}
+		for i, key := range n.keys {
+			j := o.member(key)
+			if j < 0 || !n.values[i].equal(o.values[j]) {
+				return false
+			}
+		}
+		return true
+	case n.kind == '[' && o.kind == '[':
+		if len(n.values) != len(o.values) {
+			return false
+		}
+		for i := range n.values {
+			if !n.values[i].equal(o.values[i]) {
+				return false
+			}
+		}
+		return true
+	case n.kind == '"' && o.kind == '"':
+		var s, t string
+		return Unmarshal(n.raw, &s) == nil && Unmarshal(o.raw, &t) == nil && s == t
+	case n.isNumber() && o.isNumber():
+		x, okX := new(big.Rat).SetString(string(n.raw))
+		y, okY := new(big.Rat).SetString(string(o.raw))
+		return okX && okY && x.Cmp(y) == 0
+	default:
+		return n.kind != '{' && n.kind != '[' && string(n.raw) == string(o.raw)
+	}
+}
+
+// isNumber reports whether n is a number.
+func (n *node) isNumber() bool {
+	return n.kind == '-' || n.kind >= '0' && n.kind <= '9'
+}
@@ -0,0 +1,106 @@
+package json
+
+import (
+	"reflect"
+)
+
+// Null is a value of type T that may be null, or absent from an object. It is
+// decoded from null with Valid false, and from anything else into Value with
+// Valid true, in both cases setting Set, so that a member that was absent, one
+// that was null and one that was the zero value can be told apart. It is
+// encoded as null if it is not Valid, or as Value if it is. A field of type
+// Null with the omitempty option is omitted if it is neither Valid nor Set.
+type Null[T any] struct {
+	Value T
+	// Valid is true if the value is not null.
+	Valid bool
+	// Set is true if the value was decoded, even from null.
+	Set bool
+}
+
+// NewNull returns a Valid Null holding value.
+func NewNull[T any](value T) Null[T] {
+	return Null[T]{
+		Value: value,
+		Valid: true,
+		Set:   true,
+	}
+}
+
+// nullable is implemented by every Null type, so that they can be recognised
+// whatever T is.
+type nullable interface {
+	nullable()
+}
+
+// nullSetter is implemented by a pointer to every Null type.
+type nullSetter interface {
+	// valuePtr returns a pointer to the Value field.
+	valuePtr() interface{}
+	// setNull records that the value was decoded, and whether it was null.
+	setNull(valid bool)
+}
+
+var (
+	nullableType   = reflect.TypeOf((*nullable)(nil)).Elem()
+	nullSetterType = reflect.TypeOf((*nullSetter)(nil)).Elem()
+)
+
+func (Null[T]) nullable() {}
+
+func (n *Null[T]) valuePtr() interface{} {
+	return &n.Value
+}
+
+func (n *Null[T]) setNull(valid bool) {
+	if !valid {
+		var zero T
+		n.Value = zero
+	}
+	n.Valid, n.Set = valid, true
+}
+
+// MarshalJSON returns null if n is not Valid, or the encoding of its Value.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return Marshal(n.Value)
+}
+
+// UnmarshalJSON decodes data into n, as the Decoder does.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	return Unmarshal(data, n)
+}
+
+// isNull reports whether v is of a Null type.
+func isNull(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && v.Type().Implements(nullableType)
+}
+
+// readNullable reads the value beginning with c into the Null that v points to.
+func (d *Decoder) readNullable(c byte, v reflect.Value) error {
+	n := v.Interface().(nullSetter)
+	if c == 'n' {
+		if err := d.readLiteral(c); err != nil {
+			return err
+		}
+		n.setNull(false)
+		return nil
+	}
+	if err := d.readValue(c, reflect.ValueOf(n.valuePtr())); err != nil {
+		return err
+	}
+	n.setNull(true)
+	return nil
+}
+
+// encodeNull appends the Null v, which may not be interfaceable if it is in an
+// unexported embedded struct.
+func (e *encodeState) encodeNull(v reflect.Value) error {
+	if !v.FieldByName("Valid").Bool() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	return e.encode(v.Field(0))
+}
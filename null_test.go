@@ -0,0 +1,186 @@
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nullEmbedded struct {
+	Embedded Null[int] `json:"embedded,omitempty"`
+}
+
+type nullFields struct {
+	nullEmbedded
+	Name    Null[string]          `json:"name"`
+	Age     Null[int]             `json:"age,omitempty"`
+	Tags    Null[[]string]        `json:"tags,omitempty"`
+	Inner   Null[nullInner]       `json:"inner,omitempty"`
+	Ptr     *Null[float64]        `json:"ptr,omitempty"`
+	Time    Null[time.Time]       `json:"time,omitempty"`
+	Map     map[string]Null[bool] `json:"map,omitempty"`
+	Nested  Null[Null[int]]       `json:"nested,omitempty"`
+	Default Null[int]             `json:"default,omitempty,default:5"`
+	Raw     Null[RawMessage]      `json:"raw,omitempty"`
+	Custom  Null[nullUnmarshaler] `json:"custom,omitempty"`
+	Iface   Null[interface{}]     `json:"iface,omitempty"`
+	Slice   []Null[string]        `json:"slice,omitempty"`
+	Pointer Null[*string]         `json:"pointer,omitempty"`
+}
+
+type nullInner struct {
+	A int `json:"a"`
+}
+
+type nullUnmarshaler string
+
+func (u *nullUnmarshaler) UnmarshalJSON(b []byte) error {
+	*u = nullUnmarshaler("custom " + string(b))
+	return nil
+}
+
+func TestDecodeNull(t *testing.T) {
+	str := "s"
+	tests := map[string]struct {
+		input    string
+		expected nullFields
+	}{
+		"absent": {
+			input:    `{}`,
+			expected: nullFields{Default: NewNull(5)},
+		},
+		"null": {
+			input: `{"embedded": null, "name": null, "age": null, "tags": null, "inner": null, "ptr": null, "map": {"a": null}, "nested": null, "default": null, "raw": null, "iface": null, "slice": [null], "pointer": null}`,
+			expected: nullFields{
+				nullEmbedded: nullEmbedded{Embedded: Null[int]{Set: true}},
+				Name:         Null[string]{Set: true},
+				Age:          Null[int]{Set: true},
+				Tags:         Null[[]string]{Set: true},
+				Inner:        Null[nullInner]{Set: true},
+				Map:          map[string]Null[bool]{"a": {Set: true}},
+				Nested:       Null[Null[int]]{Set: true},
+				Default:      NewNull(5),
+				Raw:          Null[RawMessage]{Set: true},
+				Iface:        Null[interface{}]{Set: true},
+				Slice:        []Null[string]{{Set: true}},
+				Pointer:      Null[*string]{Set: true},
+			},
+		},
+		"values": {
+			input: `{"embedded": 1, "name": "", "age": 0, "tags": [], "inner": {"a": 1}, "ptr": 1.5, "time": "2024-01-02T03:04:05Z", "map": {"a": false}, "nested": 2, "default": 0, "raw": [1], "custom": 3, "iface": {}, "slice": ["x"], "pointer": "s"}`,
+			expected: nullFields{
+				nullEmbedded: nullEmbedded{Embedded: NewNull(1)},
+				Name:         NewNull(""),
+				Age:          NewNull(0),
+				Tags:         NewNull([]string{}),
+				Inner:        NewNull(nullInner{A: 1}),
+				Ptr:          func() *Null[float64] { n := NewNull(1.5); return &n }(),
+				Time:         NewNull(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+				Map:          map[string]Null[bool]{"a": NewNull(false)},
+				Nested:       NewNull(NewNull(2)),
+				Default:      NewNull(0),
+				Raw:          NewNull(RawMessage(`[1]`)),
+				Custom:       NewNull(nullUnmarshaler("custom 3")),
+				Iface:        NewNull(interface{}(map[string]interface{}{})),
+				Slice:        []Null[string]{NewNull("x")},
+				Pointer:      NewNull(&str),
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual nullFields
+			require.NoError(t, Unmarshal([]byte(tt.input), &actual))
+			assert.Equal(t, tt.expected, actual)
+
+			// encoding/json uses the UnmarshalJSON method
+			var std nullFields
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &std))
+			assert.Equal(t, tt.expected, withoutDefault(std, tt.expected))
+		})
+	}
+}
+
+// withoutDefault sets the Default field of f to that of expected, as
+// encoding/json does not use defaults.
+func withoutDefault(f, expected nullFields) nullFields {
+	f.Default = expected.Default
+	return f
+}
+
+func TestDecodeNullReplaces(t *testing.T) {
+	actual := NewNull("a")
+	require.NoError(t, Unmarshal([]byte(`null`), &actual))
+	assert.Equal(t, Null[string]{Set: true}, actual)
+
+	require.NoError(t, Unmarshal([]byte(`"b"`), &actual))
+	assert.Equal(t, NewNull("b"), actual)
+
+	err := Unmarshal([]byte(`{"age": "x"}`), new(nullFields))
+	assert.EqualError(t, err, "json: cannot unmarshal string into Go struct field nullFields.age of type int")
+}
+
+func TestDecodeNullMergePatch(t *testing.T) {
+	actual := nullFields{Name: NewNull("a"), Age: NewNull(1)}
+	require.NoError(t, NewDecoder(strings.NewReader(`{"name": null}`)).DecodeMergePatch(&actual))
+	assert.Equal(t, nullFields{Name: Null[string]{Set: true}, Age: NewNull(1)}, actual)
+}
+
+func TestEncodeNull(t *testing.T) {
+	str := "s"
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"zero":           {Null[int]{}, `null`},
+		"null":           {Null[int]{Set: true}, `null`},
+		"value":          {NewNull(1), `1`},
+		"zero value":     {NewNull(0), `0`},
+		"valid not set":  {Null[int]{Value: 2, Valid: true}, `2`},
+		"invalid value":  {Null[int]{Value: 2, Set: true}, `null`},
+		"pointer":        {func() *Null[string] { n := NewNull("a"); return &n }(), `"a"`},
+		"nil pointer":    {(*Null[string])(nil), `null`},
+		"nested":         {NewNull(Null[int]{Set: true}), `null`},
+		"slice":          {[]Null[string]{NewNull("a"), {}}, `["a",null]`},
+		"empty fields":   {nullFields{}, `{"name":null}`},
+		"null fields":    {nullFields{nullEmbedded: nullEmbedded{Null[int]{Set: true}}, Name: NewNull("a"), Age: Null[int]{Set: true}, Ptr: &Null[float64]{}}, `{"embedded":null,"name":"a","age":null,"ptr":null}`},
+		"value fields":   {nullFields{Name: NewNull(""), Age: NewNull(0), Inner: NewNull(nullInner{}), Pointer: NewNull(&str)}, `{"name":"","age":0,"inner":{"a":0},"pointer":"s"}`},
+		"marshaler":      {NewNull(RawMessage(`{"a":1}`)), `{"a":1}`},
+		"time":           {NewNull(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), `"2024-01-02T03:04:05Z"`},
+		"map of nulls":   {map[string]Null[bool]{"a": {}, "b": NewNull(true)}, `{"a":null,"b":true}`},
+		"interface null": {NewNull(interface{}(nil)), `null`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+
+			// encoding/json uses the MarshalJSON method, but cannot omit
+			// Nulls
+			if _, ok := tt.value.(nullFields); !ok {
+				b, err = json.Marshal(tt.value)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestNullRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		`{"name":null,"default":5}`,
+		`{"name":"a","age":null,"default":1}`,
+		`{"name":"","age":0,"tags":[],"map":{"a":null},"default":0}`,
+	} {
+		var v nullFields
+		require.NoError(t, Unmarshal([]byte(input), &v))
+		b, err := Marshal(v)
+		require.NoError(t, err)
+		assert.Equal(t, input, string(b))
+	}
+}
@@ -0,0 +1,101 @@
+package json
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Number is the text of a JSON number literal exactly as it appeared in the
+// input, such as "1.10" or "1e3". Numbers are decoded into a Number without
+// being parsed, and a Number is encoded as its text, so that it is not changed
+// by a round trip. Decoding into an empty interface gives a Number when the
+// Decoder is set to UseRawNumber.
+type Number string
+
+var numberType = reflect.TypeOf(Number(""))
+
+// UseRawNumber causes the Decoder to decode numbers into an empty interface as
+// a Number holding their text, in place of a float64. JSON5 numbers lose a
+// leading + sign, and hexadecimal numbers are converted to decimal.
+func (d *Decoder) UseRawNumber() {
+	d.rawNumber = true
+}
+
+// String returns the text of n.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns n as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns n as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// encodeNumber appends the text of the Number n. As in encoding/json the empty
+// Number is 0. The non-finite numbers a Decoder may AllowNonFinite are encoded
+// as floats are, by the Encoder's SetNonFinite option.
+func (e *encodeState) encodeNumber(n Number) error {
+	switch {
+	case n == "":
+		e.buf = append(e.buf, '0')
+	case isValidNumber(string(n)):
+		e.buf = append(e.buf, n...)
+	case n == "NaN", n == "Infinity", n == "+Infinity", n == "-Infinity":
+		f, _ := n.Float64()
+		return e.encodeFloat(reflect.ValueOf(f))
+	default:
+		return fmt.Errorf("json: invalid number literal %q", string(n))
+	}
+	return nil
+}
+
+// isValidNumber reports whether s is a JSON number literal.
+func isValidNumber(s string) bool {
+	if s != "" && s[0] == '-' {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	switch {
+	case s[0] == '0':
+		s = s[1:]
+	case s[0] >= '1' && s[0] <= '9':
+		s = s[1:]
+		s = skipDigits(s)
+	default:
+		return false
+	}
+	if len(s) >= 2 && s[0] == '.' && isDigit(s[1]) {
+		s = skipDigits(s[2:])
+	}
+	if len(s) >= 2 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if s[0] == '+' || s[0] == '-' {
+			s = s[1:]
+		}
+		if s == "" || !isDigit(s[0]) {
+			return false
+		}
+		s = skipDigits(s)
+	}
+	return s == ""
+}
+
+// skipDigits returns s without its leading decimal digits.
+func skipDigits(s string) string {
+	for s != "" && isDigit(s[0]) {
+		s = s[1:]
+	}
+	return s
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,162 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRawNumber(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected interface{}
+	}{
+		"integer":  {`12`, Number("12")},
+		"zero":     {`-0`, Number("-0")},
+		"fraction": {`1.10`, Number("1.10")},
+		"exponent": {`1E+03`, Number("1E+03")},
+		"big":      {`123456789012345678901234567890`, Number("123456789012345678901234567890")},
+		"array":    {`[1.0, 2e1]`, []interface{}{Number("1.0"), Number("2e1")}},
+		"object":   {`{"a": 0.50}`, map[string]interface{}{"a": Number("0.50")}},
+		"string":   {`"1.0"`, "1.0"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.UseRawNumber()
+			var actual interface{}
+			require.NoError(t, dec.Decode(&actual))
+			assert.Equal(t, tt.expected, actual)
+
+			// encoding/json's UseNumber keeps the text too
+			stdDec := json.NewDecoder(strings.NewReader(tt.input))
+			stdDec.UseNumber()
+			var expected interface{}
+			require.NoError(t, stdDec.Decode(&expected))
+			b, err := json.Marshal(expected)
+			require.NoError(t, err)
+			b2, err := Marshal(actual)
+			require.NoError(t, err)
+			assert.Equal(t, string(b), string(b2))
+		})
+	}
+}
+
+type numberFields struct {
+	N Number
+	P *Number
+	M map[string]Number
+}
+
+func TestDecodeNumber(t *testing.T) {
+	var actual numberFields
+	require.NoError(t, Unmarshal([]byte(`{"N": 1.50, "P": -2e-1, "M": {"a": 3, "b": "4.0"}}`), &actual))
+	p := Number("-2e-1")
+	assert.Equal(t, numberFields{N: "1.50", P: &p, M: map[string]Number{"a": "3", "b": "4.0"}}, actual)
+
+	for _, input := range []string{`"abc"`, `""`, `"01"`, `true`, `{}`} {
+		t.Run(input, func(t *testing.T) {
+			var n Number
+			err := Unmarshal([]byte(input), &n)
+			var stdN json.Number
+			stdErr := json.Unmarshal([]byte(input), &stdN)
+			require.Error(t, stdErr)
+			require.Error(t, err)
+			assert.Equal(t, stdErr.Error(), err.Error())
+		})
+	}
+}
+
+func TestDecodeRawNumberOptions(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[0x1F, +1.0, NaN, -Infinity]`))
+	dec.UseRawNumber()
+	dec.AllowJSON5()
+	dec.AllowNonFinite()
+	var actual interface{}
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, []interface{}{Number("31"), Number("1.0"), Number("NaN"), Number("-Infinity")}, actual)
+
+	dec = NewDecoder(strings.NewReader(`{"b": 1.0, "a": [2.50]}`))
+	dec.UseRawNumber()
+	dec.UseOrderedObjects()
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, orderedMap("b", Number("1.0"), "a", []interface{}{Number("2.50")}), actual)
+
+	dec = NewDecoder(strings.NewReader(`{"a": 1.0} 2.0`))
+	dec.UseRawNumber()
+	var tokens []Token
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+	assert.Equal(t, []Token{Delim('{'), "a", Number("1.0"), Delim('}'), Number("2.0")}, tokens)
+}
+
+func TestEncodeNumber(t *testing.T) {
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+		err      string
+	}{
+		"number":   {Number("1.10"), `1.10`, ""},
+		"exponent": {Number("-1E+03"), `-1E+03`, ""},
+		"empty":    {Number(""), `0`, ""},
+		"pointer":  {func() *Number { n := Number("2.0"); return &n }(), `2.0`, ""},
+		"field":    {numberFields{N: "1.0", M: map[string]Number{"a": "0.0"}}, `{"N":1.0,"P":null,"M":{"a":0.0}}`, ""},
+		"invalid":  {Number("1.0x"), "", `json: invalid number literal "1.0x"`},
+		"leading":  {Number("01"), "", `json: invalid number literal "01"`},
+		"plus":     {Number("+1"), "", `json: invalid number literal "+1"`},
+		"NaN":      {Number("NaN"), "", "json: unsupported value: NaN"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+}
+
+func TestEncodeNumberNonFinite(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetNonFinite(NonFiniteString)
+	require.NoError(t, enc.Encode([]Number{"NaN", "Infinity", "-Infinity"}))
+	assert.Equal(t, "[\"NaN\",\"Infinity\",\"-Infinity\"]\n", buf.String())
+}
+
+func TestNumberMethods(t *testing.T) {
+	n := Number("12")
+	assert.Equal(t, "12", n.String())
+	i, err := n.Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), i)
+	f, err := Number("1.5e1").Float64()
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, f)
+	_, err = Number("1.5").Int64()
+	assert.Error(t, err)
+}
+
+func TestRawNumberRoundTrip(t *testing.T) {
+	input := `{"version":1.10,"size":1e3,"ratio":0.50,"id":12345678901234567890,"list":[-0,1.0E-2]}`
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseRawNumber()
+	dec.UseOrderedObjects()
+	var v interface{}
+	require.NoError(t, dec.Decode(&v))
+	b, err := Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, input, string(b))
+}
@@ -0,0 +1,109 @@
+package json
+
+import (
+	"reflect"
+)
+
+// OrderedMap is a JSON object that keeps the order of its members, it is what
+// objects are decoded into in place of map[string]interface{} when a Decoder is
+// set to UseOrderedObjects. The zero OrderedMap is an empty object ready to use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// UseOrderedObjects causes the Decoder to decode objects into an empty
+// interface as an *OrderedMap, so that the order of their members is kept.
+// Objects decoded by DecodeMergePatch are still maps.
+func (d *Decoder) UseOrderedObjects() {
+	d.orderedObjects = true
+}
+
+// Keys returns the keys of the members of m in order.
+func (m *OrderedMap) Keys() []string {
+	return append([]string(nil), m.keys...)
+}
+
+// Get returns the value of the member of m named key, and whether there is
+// one.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Set sets the value of the member of m named key. A new member is added after
+// the others, an existing member keeps its place.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes the member of m named key, if there is one.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Len returns the number of members of m.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Range calls fn for each member of m in order, until fn returns false. fn may
+// set or delete the member it is called for.
+func (m *OrderedMap) Range(fn func(key string, value interface{}) bool) {
+	for i := 0; i < len(m.keys); i++ {
+		key := m.keys[i]
+		if !fn(key, m.values[key]) {
+			return
+		}
+		if i < len(m.keys) && m.keys[i] != key {
+			// the member was deleted
+			i--
+		}
+	}
+}
+
+// MarshalJSON encodes m as an object with its members in order. The Encoder
+// encodes an OrderedMap the same way, with its options applied to the values.
+func (m OrderedMap) MarshalJSON() ([]byte, error) {
+	return Marshal(m)
+}
+
+// UnmarshalJSON sets the members of the object data in m, adding them after
+// any members m already has, as Unmarshal does into a map. Objects within data
+// are decoded as an *OrderedMap. The Decoder decodes into an OrderedMap the
+// same way, with its options applied.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	return Unmarshal(data, m)
+}
+
+// encodeOrderedMap appends m as an object with its members in order.
+func (e *encodeState) encodeOrderedMap(m OrderedMap) error {
+	e.buf = append(e.buf, '{')
+	for i, key := range m.keys {
+		if i > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = appendString(e.buf, key, e.escapeHTML, e.escapeSolidus)
+		e.buf = append(e.buf, ':')
+		if err := e.encode(reflect.ValueOf(m.values[key])); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
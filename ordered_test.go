@@ -0,0 +1,292 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orderedMap(kv ...interface{}) *OrderedMap {
+	m := &OrderedMap{}
+	for i := 0; i < len(kv); i += 2 {
+		m.Set(kv[i].(string), kv[i+1])
+	}
+	return m
+}
+
+func TestDecodeOrderedObjects(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     interface{}
+		expected interface{}
+	}{
+		"object": {
+			input:    `{"z": 1, "a": "x", "m": null}`,
+			dest:     new(interface{}),
+			expected: orderedMap("z", float64(1), "a", "x", "m", nil),
+		},
+		"empty": {
+			input:    `{}`,
+			dest:     new(interface{}),
+			expected: &OrderedMap{},
+		},
+		"nested": {
+			input: `[{"b": {"d": 1, "c": 2}}, {"a": [true]}]`,
+			dest:  new(interface{}),
+			expected: []interface{}{
+				orderedMap("b", orderedMap("d", float64(1), "c", float64(2))),
+				orderedMap("a", []interface{}{true}),
+			},
+		},
+		"duplicate": {
+			input:    `{"b": 1, "a": 2, "b": 3}`,
+			dest:     new(interface{}),
+			expected: orderedMap("b", float64(3), "a", float64(2)),
+		},
+		"field": {
+			input:    `{"G": {"y": 1, "x": 2}}`,
+			dest:     new(decodeStruct),
+			expected: &decodeStruct{G: orderedMap("y", float64(1), "x", float64(2))},
+		},
+		"map": {
+			input:    `{"y": {"b": 1, "a": 2}}`,
+			dest:     new(map[string]interface{}),
+			expected: &map[string]interface{}{"y": orderedMap("b", float64(1), "a", float64(2))},
+		},
+		"typed map": {
+			input:    `{"y": 1, "x": 2}`,
+			dest:     new(map[string]int),
+			expected: &map[string]int{"x": 2, "y": 1},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.UseOrderedObjects()
+			require.NoError(t, dec.Decode(tt.dest))
+			if p, ok := tt.dest.(*interface{}); ok {
+				assert.Equal(t, tt.expected, *p)
+				return
+			}
+			assert.Equal(t, tt.expected, tt.dest)
+		})
+	}
+}
+
+func TestDecodeOrderedObjectsKeys(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"c": 1, "b": 2, "a": 3, "d": {"z": 0, "y": 0}}`))
+	dec.UseOrderedObjects()
+	var v interface{}
+	require.NoError(t, dec.Decode(&v))
+	m := v.(*OrderedMap)
+	assert.Equal(t, []string{"c", "b", "a", "d"}, m.Keys())
+	d, ok := m.Get("d")
+	require.True(t, ok)
+	assert.Equal(t, []string{"z", "y"}, d.(*OrderedMap).Keys())
+	_, ok = m.Get("e")
+	assert.False(t, ok)
+
+	// keys returned are a copy
+	m.Keys()[0] = "x"
+	assert.Equal(t, "c", m.Keys()[0])
+}
+
+func TestDecodeOrderedObjectsMergePatch(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"b": null, "c": {"d": 1}}`))
+	dec.UseOrderedObjects()
+	var v interface{} = map[string]interface{}{"a": 1.0, "b": 2.0}
+	require.NoError(t, dec.DecodeMergePatch(&v))
+	assert.Equal(t, map[string]interface{}{"a": 1.0, "c": map[string]interface{}{"d": 1.0}}, v)
+}
+
+func TestOrderedMapSet(t *testing.T) {
+	var m OrderedMap
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	assert.Equal(t, []string{"b", "a"}, m.Keys())
+	v, ok := m.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
+func TestOrderedMapDeleteLenRange(t *testing.T) {
+	m := orderedMap("a", 1, "b", 2, "c", 3, "d", 4)
+	m.Delete("b")
+	m.Delete("x")
+	assert.Equal(t, []string{"a", "c", "d"}, m.Keys())
+	assert.Equal(t, 3, m.Len())
+	_, ok := m.Get("b")
+	assert.False(t, ok)
+
+	var visited []string
+	m.Range(func(key string, value interface{}) bool {
+		visited = append(visited, key)
+		if key == "a" {
+			m.Delete(key)
+		}
+		return key != "c"
+	})
+	assert.Equal(t, []string{"a", "c"}, visited)
+	assert.Equal(t, []string{"c", "d"}, m.Keys())
+
+	var empty OrderedMap
+	empty.Delete("a")
+	assert.Equal(t, 0, empty.Len())
+	empty.Range(func(string, interface{}) bool {
+		t.Error("called for empty map")
+		return true
+	})
+}
+
+type orderedFields struct {
+	M  OrderedMap
+	P  *OrderedMap
+	I  interface{}
+	MS map[string]OrderedMap `json:",omitempty"`
+}
+
+func TestEncodeOrderedMap(t *testing.T) {
+	m := orderedMap("z", 1, "a", orderedMap("y", "<", "x", nil), "m", []interface{}{orderedMap()})
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"value":   {*m, `{"z":1,"a":{"y":"\u003c","x":null},"m":[{}]}`},
+		"pointer": {m, `{"z":1,"a":{"y":"\u003c","x":null},"m":[{}]}`},
+		"nil":     {(*OrderedMap)(nil), `null`},
+		"zero":    {OrderedMap{}, `{}`},
+		"fields": {
+			orderedFields{M: *orderedMap("b", 1, "a", 2), P: orderedMap("d", 3, "c", 4), I: orderedMap("f", 5, "e", 6)},
+			`{"M":{"b":1,"a":2},"P":{"d":3,"c":4},"I":{"f":5,"e":6}}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+
+			// encoding/json uses the MarshalJSON method
+			b, err = json.Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+}
+
+func TestEncodeOrderedMapOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", " ")
+	assert.Error(t, enc.Encode(orderedMap("b", "<", "a", math.NaN())))
+	assert.Equal(t, "", buf.String())
+
+	enc.SetNonFinite(NonFiniteString)
+	require.NoError(t, enc.Encode(orderedMap("b", "<", "a", math.NaN())))
+	assert.Equal(t, "{\n \"b\": \"<\",\n \"a\": \"NaN\"\n}\n", buf.String())
+}
+
+func TestEncodeOrderedMapCycle(t *testing.T) {
+	m := orderedMap()
+	m.Set("self", m)
+	_, err := Marshal(m)
+	assert.Error(t, err)
+}
+
+func TestUnmarshalOrderedMap(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     interface{}
+		expected interface{}
+		err      string
+	}{
+		"value": {
+			input:    `{"b": 1, "a": {"d": 2, "c": 3}}`,
+			dest:     &OrderedMap{},
+			expected: orderedMap("b", float64(1), "a", orderedMap("d", float64(2), "c", float64(3))),
+		},
+		"existing": {
+			input:    `{"c": 3, "a": 4}`,
+			dest:     orderedMap("a", 1, "b", 2),
+			expected: orderedMap("a", float64(4), "b", 2, "c", float64(3)),
+		},
+		"null": {
+			input:    `null`,
+			dest:     orderedMap("a", 1),
+			expected: orderedMap("a", 1),
+		},
+		"pointer null": {
+			input:    `null`,
+			dest:     func() **OrderedMap { m := orderedMap("a", 1); return &m }(),
+			expected: new(*OrderedMap),
+		},
+		"fields": {
+			input:    `{"M": {"b": 1, "a": 2}, "P": {"d": [{"y": 1, "x": 2}]}, "I": {"f": 5}}`,
+			dest:     new(orderedFields),
+			expected: &orderedFields{M: *orderedMap("b", float64(1), "a", float64(2)), P: orderedMap("d", []interface{}{orderedMap("y", float64(1), "x", float64(2))}), I: map[string]interface{}{"f": float64(5)}},
+		},
+		"array": {
+			input: `[1]`,
+			dest:  new(OrderedMap),
+			err:   "json: cannot unmarshal array into Go value of type json.OrderedMap",
+		},
+		"string": {
+			input: `"a"`,
+			dest:  new(OrderedMap),
+			err:   "json: cannot unmarshal string into Go value of type json.OrderedMap",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.input), tt.dest)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.dest)
+		})
+	}
+}
+
+func TestOrderedMapUnmarshalJSON(t *testing.T) {
+	// encoding/json uses the UnmarshalJSON method
+	var m OrderedMap
+	require.NoError(t, json.Unmarshal([]byte(`{"b": 1, "a": {"d": 2, "c": 3}}`), &m))
+	assert.Equal(t, orderedMap("b", float64(1), "a", orderedMap("d", float64(2), "c", float64(3))), &m)
+}
+
+func TestDecodeOrderedMapOptions(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{b: 'x', /* c */ a: 0x10}`))
+	dec.AllowJSON5()
+	var m OrderedMap
+	require.NoError(t, dec.Decode(&m))
+	assert.Equal(t, orderedMap("b", "x", "a", float64(16)), &m)
+
+	dec = NewDecoder(strings.NewReader(`{"a": 1, "a": 2}`))
+	dec.DisallowDuplicateKeys()
+	assert.Equal(t, &DuplicateKeyError{Key: "a", Offset: 10}, dec.Decode(&m))
+}
+
+func TestDecodeMergePatchOrderedMap(t *testing.T) {
+	m := orderedMap("a", float64(1), "b", orderedMap("c", float64(2), "d", float64(3)), "e", float64(4))
+	dec := NewDecoder(strings.NewReader(`{"e": null, "b": {"c": null, "f": 5}, "g": 6}`))
+	require.NoError(t, dec.DecodeMergePatch(m))
+	assert.Equal(t, orderedMap("a", float64(1), "b", orderedMap("d", float64(3), "f", float64(5)), "g", float64(6)), m)
+}
+
+func TestOrderedMapRoundTrip(t *testing.T) {
+	input := `{"name":"app","version":2,"deps":{"zlib":"1.3","abc":"0.1"},"tags":["b","a"],"empty":{}}`
+	var m OrderedMap
+	require.NoError(t, Unmarshal([]byte(input), &m))
+	b, err := Marshal(m)
+	require.NoError(t, err)
+	assert.Equal(t, input, string(b))
+}
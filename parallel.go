@@ -0,0 +1,154 @@
+package json
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// parallelMinSize is the smallest input that UnmarshalParallel divides between
+// goroutines, smaller inputs are not worth the extra pass.
+var parallelMinSize = 1 << 20
+
+// UnmarshalParallel is like Unmarshal but decodes a large array using all the
+// CPUs available, for multi-megabyte documents. It does so in two stages: the
+// first finds where each element of the array begins and checks that data is
+// valid, the second decodes the elements into v in parallel across goroutines.
+//
+// Only an array decoded into a slice, an array or a nil empty interface is
+// divided, anything else is decoded by Unmarshal, as are small inputs. Element
+// types must be safe to decode concurrently, so UnmarshalJSON methods must not
+// share state without synchronisation. Errors are those that Unmarshal returns,
+// but if data cannot be decoded into v then v may hold more of data than it
+// would after Unmarshal.
+func UnmarshalParallel(data []byte, v interface{}) error {
+	workers := runtime.GOMAXPROCS(0)
+	if len(data) < parallelMinSize || workers < 2 || !parallelTarget(v) {
+		return Unmarshal(data, v)
+	}
+	starts, err := indexArray(data)
+	if err != nil || len(starts) < 2*workers {
+		return Unmarshal(data, v)
+	}
+	if decodeParallel(data, starts, reflect.ValueOf(v).Elem(), workers) != nil {
+		// decode again for the error exactly as Unmarshal reports it
+		return Unmarshal(data, v)
+	}
+	return nil
+}
+
+// parallelTarget reports whether UnmarshalParallel can divide an array decoded
+// into v.
+func parallelTarget(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Type().Implements(unmarshalerType) {
+		return false
+	}
+	switch elem := rv.Elem(); elem.Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	case reflect.Interface:
+		return elem.NumMethod() == 0 && elem.IsNil()
+	default:
+		return false
+	}
+}
+
+// indexArray returns the offset of the first byte of each element of the array
+// that data must hold, surrounded by nothing but whitespace. Each element is
+// checked to be valid.
+func indexArray(data []byte) ([]int, error) {
+	d := &Decoder{buf: data}
+	c, err := d.readNonSpace()
+	if err != nil {
+		return nil, err
+	}
+	if c != '[' {
+		return nil, d.syntaxErrorf("invalid character %q looking for beginning of array", c)
+	}
+	// elements are at the depth of the array's
+	d.depth = 1
+	var starts []int
+	for {
+		if c, err = d.readNonSpace(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if c == ']' && len(starts) == 0 {
+			break
+		}
+		starts = append(starts, d.pos-1)
+		if err = d.skipValue(c); err != nil {
+			return nil, err
+		}
+		if c, err = d.readNonSpace(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if c == ']' {
+			break
+		}
+		if c != ',' {
+			return nil, d.syntaxErrorf("invalid character %q after array element", c)
+		}
+	}
+	return starts, d.readEnd()
+}
+
+// decodeParallel decodes the elements of the array in data, which begin at
+// starts, into v as readArray would, dividing them between workers goroutines.
+func decodeParallel(data []byte, starts []int, v reflect.Value, workers int) error {
+	arr := v
+	switch v.Kind() {
+	case reflect.Interface:
+		arr = reflect.ValueOf(make([]interface{}, len(starts)))
+	case reflect.Slice:
+		if n := len(starts) - v.Len(); n > 0 {
+			arr = reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), n, n))
+		}
+		arr = arr.Slice(0, len(starts))
+	case reflect.Array:
+		// an array with no more space has the rest of the elements skipped
+		starts = starts[:min(len(starts), v.Len())]
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	chunk := (len(starts) + workers - 1) / workers
+	for first := 0; first < len(starts); first += chunk {
+		last := min(first+chunk, len(starts))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := &Decoder{buf: data, depth: 1}
+			for i := first; i < last; i++ {
+				d.pos, d.offset = starts[i], int64(starts[i])
+				c, err := d.readByte()
+				if err == nil {
+					err = d.readValue(c, arr.Index(i).Addr())
+				}
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if v.Kind() == reflect.Slice && len(starts) == 0 {
+		arr = reflect.MakeSlice(v.Type(), 0, 0)
+	}
+	if v.Kind() != reflect.Array {
+		v.Set(arr)
+	}
+	return nil
+}
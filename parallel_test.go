@@ -0,0 +1,152 @@
+package json
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forceParallel makes UnmarshalParallel divide small inputs between 4
+// goroutines for the rest of the test.
+func forceParallel(t *testing.T) {
+	minSize, procs := parallelMinSize, runtime.GOMAXPROCS(4)
+	parallelMinSize = 0
+	t.Cleanup(func() {
+		parallelMinSize = minSize
+		runtime.GOMAXPROCS(procs)
+	})
+}
+
+type parallelRecord struct {
+	ID    int
+	Name  string
+	Tags  []string
+	Inner *decodeInner
+}
+
+func parallelInput(n int) string {
+	var b strings.Builder
+	b.WriteString(" [")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "n\"%d", "tags": ["a", "%d"], "inner": {"x": %d}}`, i, i, i, i)
+	}
+	b.WriteString("] ")
+	return b.String()
+}
+
+func TestUnmarshalParallel(t *testing.T) {
+	forceParallel(t)
+	input := []byte(parallelInput(100))
+
+	var expected, actual []parallelRecord
+	require.NoError(t, Unmarshal(input, &expected))
+	require.NoError(t, UnmarshalParallel(input, &actual))
+	assert.Equal(t, expected, actual)
+
+	var expectedI, actualI interface{}
+	require.NoError(t, Unmarshal(input, &expectedI))
+	require.NoError(t, UnmarshalParallel(input, &actualI))
+	assert.Equal(t, expectedI, actualI)
+
+	var expectedA, actualA [150]parallelRecord
+	expectedA[120].ID, actualA[120].ID = 1, 1
+	require.NoError(t, Unmarshal(input, &expectedA))
+	require.NoError(t, UnmarshalParallel(input, &actualA))
+	assert.Equal(t, expectedA, actualA)
+
+	var expectedShort, actualShort [10]parallelRecord
+	require.NoError(t, Unmarshal(input, &expectedShort))
+	require.NoError(t, UnmarshalParallel(input, &actualShort))
+	assert.Equal(t, expectedShort, actualShort)
+}
+
+func TestUnmarshalParallelExisting(t *testing.T) {
+	forceParallel(t)
+	input := []byte(parallelInput(20))
+	for _, n := range []int{0, 10, 20, 30} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			existing := func() []parallelRecord {
+				s := make([]parallelRecord, n, n+5)
+				for i := range s {
+					s[i] = parallelRecord{Name: "old", Tags: []string{"x", "y", "z"}}
+				}
+				return s
+			}
+			expected, actual := existing(), existing()
+			require.NoError(t, Unmarshal(input, &expected))
+			require.NoError(t, UnmarshalParallel(input, &actual))
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, cap(expected), cap(actual))
+		})
+	}
+}
+
+func TestUnmarshalParallelErrors(t *testing.T) {
+	forceParallel(t)
+	valid := parallelInput(20)
+	tests := map[string]string{
+		"syntax":   valid[:len(valid)-50] + "}]",
+		"truncate": valid[:len(valid)/2],
+		"trailing": valid + "[]",
+		"type":     strings.Replace(valid, `"id": 15`, `"id": "15"`, 1),
+		"element":  strings.Replace(valid, `{"id": 3,`, `3, {"id": 3,`, 1),
+		"object":   `{"a": 1}`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var expected, actual []parallelRecord
+			expectedErr := Unmarshal([]byte(input), &expected)
+			require.Error(t, expectedErr)
+			assert.Equal(t, expectedErr, UnmarshalParallel([]byte(input), &actual))
+		})
+	}
+}
+
+func TestUnmarshalParallelFallback(t *testing.T) {
+	forceParallel(t)
+	input := []byte(parallelInput(20))
+
+	var m map[string]interface{}
+	assert.Equal(t, Unmarshal(input, &m), UnmarshalParallel(input, &m))
+	assert.Equal(t, &InvalidUnmarshalError{}, UnmarshalParallel(input, nil))
+
+	var raw RawMessage
+	require.NoError(t, UnmarshalParallel(input, &raw))
+	assert.Equal(t, strings.TrimSpace(string(input)), string(raw))
+
+	var held interface{} = &[]parallelRecord{}
+	require.NoError(t, UnmarshalParallel(input, &held))
+	assert.Len(t, *held.(*[]parallelRecord), 20)
+
+	var few []int
+	require.NoError(t, UnmarshalParallel([]byte(`[1, 2]`), &few))
+	assert.Equal(t, []int{1, 2}, few)
+}
+
+func BenchmarkUnmarshalParallel(b *testing.B) {
+	input := []byte(parallelInput(50000))
+	b.SetBytes(int64(len(input)))
+	b.Run("Unmarshal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v []parallelRecord
+			if err := Unmarshal(input, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("UnmarshalParallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v []parallelRecord
+			if err := UnmarshalParallel(input, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
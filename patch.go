@@ -0,0 +1,285 @@
+package json
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// patchOperation is an operation of a JSON Patch document. Value is nil if it
+// is missing, as opposed to null.
+type patchOperation struct {
+	Op    string
+	Path  *string
+	From  *string
+	Value RawMessage
+}
+
+// ApplyPatch applies the JSON Patch patch, as defined by RFC 6902, to the JSON
+// document doc and returns the patched document. The order of object members
+// and the text of numbers that the patch does not change are kept. The patch is
+// applied in full or not at all, a *PatchError is returned if one of its
+// operations cannot be applied.
+func ApplyPatch(doc, patch []byte) ([]byte, error) {
+	root, err := parseNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ops []patchOperation
+	if err = Unmarshal(patch, &ops); err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if root, err = applyOperation(root, op); err != nil {
+			return nil, &PatchError{Index: i, Op: op.Op, msg: err.Error()}
+		}
+	}
+	return appendNode(nil, root), nil
+}
+
+// applyOperation applies op to the document root and returns the new root.
+func applyOperation(root *node, op patchOperation) (*node, error) {
+	if op.Path == nil {
+		return nil, errors.New("missing path")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, errors.New("invalid path " + strconv.Quote(*op.Path))
+	}
+	var from []string
+	switch op.Op {
+	case "move", "copy":
+		if op.From == nil {
+			return nil, errors.New("missing from")
+		}
+		if from, err = parsePointer(*op.From); err != nil {
+			return nil, errors.New("invalid from " + strconv.Quote(*op.From))
+		}
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		value, err := parseNode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return addNode(root, path, value)
+	case "remove":
+		root, _, err = removeNode(root, path)
+		return root, err
+	case "replace":
+		value, err := parseNode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if root.find(path) == nil {
+			return nil, errors.New("path does not exist")
+		}
+		return setNode(root, path, value), nil
+	case "move":
+		if len(from) < len(path) && isPointerPrefix(from, path) {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		root, value, err := removeNode(root, from)
+		if err != nil {
+			return nil, errors.New("from does not exist")
+		}
+		return addNode(root, path, value)
+	case "copy":
+		value := root.find(from)
+		if value == nil {
+			return nil, errors.New("from does not exist")
+		}
+		return addNode(root, path, value.clone())
+	case "test":
+		value, err := parseNode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		target := root.find(path)
+		if target == nil {
+			return nil, errors.New("path does not exist")
+		}
+		if !target.equal(value) {
+			return nil, errors.New("test failed")
+		}
+		return root, nil
+	default:
+		return nil, errors.New("unknown operation")
+	}
+}
+
+// isPointerPrefix reports whether the tokens of prefix begin those of path.
+func isPointerPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addNode adds value to root at path as the add operation does, and returns the
+// new root.
+func addNode(root *node, path []string, value *node) (*node, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent := root.find(path[:len(path)-1])
+	if parent == nil {
+		return nil, errors.New("path does not exist")
+	}
+	last := path[len(path)-1]
+	switch parent.kind {
+	case '{':
+		if i := parent.member(last); i >= 0 {
+			parent.values[i] = value
+		} else {
+			parent.keys = append(parent.keys, last)
+			parent.values = append(parent.values, value)
+		}
+	case '[':
+		i := len(parent.values)
+		if last != "-" {
+			if i = pointerIndex(last); i < 0 || i > len(parent.values) {
+				return nil, errors.New("array index out of range")
+			}
+		}
+		parent.values = append(parent.values, nil)
+		copy(parent.values[i+1:], parent.values[i:])
+		parent.values[i] = value
+	default:
+		return nil, errors.New("path does not exist")
+	}
+	return root, nil
+}
+
+// removeNode removes the value at path from root, returning the new root and the
+// removed value.
+func removeNode(root *node, path []string) (*node, *node, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	parent := root.find(path[:len(path)-1])
+	if parent == nil {
+		return nil, nil, errors.New("path does not exist")
+	}
+	last := path[len(path)-1]
+	var i int
+	switch parent.kind {
+	case '{':
+		if i = parent.member(last); i < 0 {
+			return nil, nil, errors.New("path does not exist")
+		}
+		parent.keys = append(parent.keys[:i], parent.keys[i+1:]...)
+	case '[':
+		if i = pointerIndex(last); i < 0 || i >= len(parent.values) {
+			return nil, nil, errors.New("path does not exist")
+		}
+	default:
+		return nil, nil, errors.New("path does not exist")
+	}
+	value := parent.values[i]
+	parent.values = append(parent.values[:i], parent.values[i+1:]...)
+	return root, value, nil
+}
+
+// setNode replaces the existing value at path in root with value, and returns
+// the new root.
+func setNode(root *node, path []string, value *node) *node {
+	if len(path) == 0 {
+		return value
+	}
+	parent := root.find(path[:len(path)-1])
+	last := path[len(path)-1]
+	if parent.kind == '{' {
+		parent.values[parent.member(last)] = value
+	} else {
+		parent.values[pointerIndex(last)] = value
+	}
+	return root
+}
+
+// CreatePatch returns a JSON Patch, as defined by RFC 6902, that ApplyPatch
+// applies to the JSON document a to give the JSON document b. Objects are
+// compared member by member and arrays element by element, values that differ
+// in type are replaced whole.
+func CreatePatch(a, b []byte) ([]byte, error) {
+	from, err := parseNode(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseNode(b)
+	if err != nil {
+		return nil, err
+	}
+	patch := appendDiff([]byte{'['}, "", from, to)
+	return append(patch, ']'), nil
+}
+
+// appendDiff appends the operations that change from into to at path.
+func appendDiff(patch []byte, path string, from, to *node) []byte {
+	switch {
+	case from.equal(to):
+		return patch
+	case from.kind == '{' && to.kind == '{':
+		for i, key := range from.keys {
+			if j := to.member(key); j >= 0 {
+				patch = appendDiff(patch, path+"/"+escapePointerToken(key), from.values[i], to.values[j])
+			} else {
+				patch = appendOperation(patch, "remove", path+"/"+escapePointerToken(key), nil)
+			}
+		}
+		for i, key := range to.keys {
+			if from.member(key) < 0 {
+				patch = appendOperation(patch, "add", path+"/"+escapePointerToken(key), to.values[i])
+			}
+		}
+		return patch
+	case from.kind == '[' && to.kind == '[':
+		n := len(from.values)
+		if len(to.values) < n {
+			n = len(to.values)
+		}
+		for i := 0; i < n; i++ {
+			patch = appendDiff(patch, path+"/"+strconv.Itoa(i), from.values[i], to.values[i])
+		}
+		for i := len(from.values) - 1; i >= n; i-- {
+			patch = appendOperation(patch, "remove", path+"/"+strconv.Itoa(i), nil)
+		}
+		for i := n; i < len(to.values); i++ {
+			patch = appendOperation(patch, "add", path+"/"+strconv.Itoa(i), to.values[i])
+		}
+		return patch
+	default:
+		return appendOperation(patch, "replace", path, to)
+	}
+}
+
+// appendOperation appends a patch operation, value is omitted if it is nil.
+func appendOperation(patch []byte, op, path string, value *node) []byte {
+	if len(patch) > 1 {
+		patch = append(patch, ',')
+	}
+	patch = append(patch, `{"op":`...)
+	patch = appendString(patch, op, false, false)
+	patch = append(patch, `,"path":`...)
+	patch = appendString(patch, path, false, false)
+	if value != nil {
+		patch = append(patch, `,"value":`...)
+		patch = appendNode(patch, value)
+	}
+	return append(patch, '}')
+}
+
+// pointerEscaper escapes a JSON Pointer reference token.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointerToken returns a JSON Pointer reference token naming key.
+func escapePointerToken(key string) string {
+	return pointerEscaper.Replace(key)
+}
@@ -0,0 +1,242 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	tests := map[string]struct {
+		doc, patch, expected string
+		err                  string
+	}{
+		// the examples of RFC 6902 appendix A
+		"add member": {
+			doc:      `{"foo": "bar"}`,
+			patch:    `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			expected: `{"foo":"bar","baz":"qux"}`,
+		},
+		"add element": {
+			doc:      `{"foo": ["bar", "baz"]}`,
+			patch:    `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			expected: `{"foo":["bar","qux","baz"]}`,
+		},
+		"remove member": {
+			doc:      `{"baz": "qux", "foo": "bar"}`,
+			patch:    `[{"op": "remove", "path": "/baz"}]`,
+			expected: `{"foo":"bar"}`,
+		},
+		"remove element": {
+			doc:      `{"foo": ["bar", "qux", "baz"]}`,
+			patch:    `[{"op": "remove", "path": "/foo/1"}]`,
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		"replace": {
+			doc:      `{"baz": "qux", "foo": "bar"}`,
+			patch:    `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			expected: `{"baz":"boo","foo":"bar"}`,
+		},
+		"move member": {
+			doc:      `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch:    `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		"move element": {
+			doc:      `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch:    `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			expected: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		"test": {
+			doc: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}]`,
+			expected: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		"test fails": {
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			err:   "json: patch operation 0 (test): test failed",
+		},
+		"add nested": {
+			doc:      `{"foo": "bar"}`,
+			patch:    `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			expected: `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		"ignore unknown members": {
+			doc:      `{"foo": "bar"}`,
+			patch:    `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			expected: `{"foo":"bar","baz":"qux"}`,
+		},
+		"add to missing": {
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			err:   "json: patch operation 0 (add): path does not exist",
+		},
+		"escaped": {
+			doc: `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10},
+				{"op": "test", "path": "/~1", "value": 9}]`,
+			expected: `{"/":9,"~1":10}`,
+		},
+		"test string number": {
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			err:   "json: patch operation 0 (test): test failed",
+		},
+		"add array": {
+			doc:      `{"foo": ["bar"]}`,
+			patch:    `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			expected: `{"foo":["bar",["abc","def"]]}`,
+		},
+
+		"numbers kept": {
+			doc:      `{"a": 1.50, "b": 12345678901234567890, "c": 1}`,
+			patch:    `[{"op": "replace", "path": "/c", "value": 1e3}, {"op": "test", "path": "/a", "value": 1.5}]`,
+			expected: `{"a":1.50,"b":12345678901234567890,"c":1e3}`,
+		},
+		"test object": {
+			doc:      `{"a": {"x": 1, "y": [true, null]}}`,
+			patch:    `[{"op": "test", "path": "/a", "value": {"y": [true, null], "x": 1.0}}]`,
+			expected: `{"a":{"x":1,"y":[true,null]}}`,
+		},
+		"replace root": {
+			doc:      `{"a": 1}`,
+			patch:    `[{"op": "replace", "path": "", "value": [1]}]`,
+			expected: `[1]`,
+		},
+		"copy": {
+			doc:      `{"a": {"b": 1}}`,
+			patch:    `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			expected: `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		"move into child": {
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			err:   "json: patch operation 0 (move): cannot move a value into itself",
+		},
+		"index out of range": {
+			doc:   `[1, 2]`,
+			patch: `[{"op": "add", "path": "/3", "value": 3}]`,
+			err:   "json: patch operation 0 (add): array index out of range",
+		},
+		"remove missing": {
+			doc:   `[1, 2]`,
+			patch: `[{"op": "test", "path": "/0", "value": 1}, {"op": "remove", "path": "/2"}]`,
+			err:   "json: patch operation 1 (remove): path does not exist",
+		},
+		"replace missing": {
+			doc:   `{}`,
+			patch: `[{"op": "replace", "path": "/a", "value": 1}]`,
+			err:   "json: patch operation 0 (replace): path does not exist",
+		},
+		"missing value": {
+			doc:   `{}`,
+			patch: `[{"op": "add", "path": "/a"}]`,
+			err:   "json: patch operation 0 (add): missing value",
+		},
+		"null value": {
+			doc:      `{}`,
+			patch:    `[{"op": "add", "path": "/a", "value": null}]`,
+			expected: `{"a":null}`,
+		},
+		"missing path": {
+			doc:   `{}`,
+			patch: `[{"op": "remove"}]`,
+			err:   "json: patch operation 0 (remove): missing path",
+		},
+		"missing from": {
+			doc:   `{}`,
+			patch: `[{"op": "copy", "path": "/a"}]`,
+			err:   "json: patch operation 0 (copy): missing from",
+		},
+		"invalid path": {
+			doc:   `{}`,
+			patch: `[{"op": "remove", "path": "a"}]`,
+			err:   `json: patch operation 0 (remove): invalid path "a"`,
+		},
+		"unknown op": {
+			doc:   `{}`,
+			patch: `[{"op": "frob", "path": ""}]`,
+			err:   "json: patch operation 0 (frob): unknown operation",
+		},
+		"invalid doc": {
+			doc:   `{`,
+			patch: `[]`,
+			err:   "unexpected end of JSON input",
+		},
+		"invalid patch": {
+			doc:   `{}`,
+			patch: `{}`,
+			err:   "json: cannot unmarshal object into Go value of type []json.patchOperation",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ApplyPatch([]byte(test.doc), []byte(test.patch))
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				assert.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
+
+func TestCreatePatch(t *testing.T) {
+	tests := map[string]struct {
+		a, b, expected string
+	}{
+		"equal": {
+			a:        `{"a": [1, {"b": 2.0}]}`,
+			b:        `{"a":[1,{"b":2}]}`,
+			expected: `[]`,
+		},
+		"members": {
+			a:        `{"a": 1, "b": 2, "c/~": {"d": true}}`,
+			b:        `{"c/~": {"d": false}, "a": 1, "e": null}`,
+			expected: `[{"op":"remove","path":"/b"},{"op":"replace","path":"/c~1~0/d","value":false},{"op":"add","path":"/e","value":null}]`,
+		},
+		"shorter array": {
+			a:        `[1, 2, 3, 4]`,
+			b:        `[1, 5]`,
+			expected: `[{"op":"replace","path":"/1","value":5},{"op":"remove","path":"/3"},{"op":"remove","path":"/2"}]`,
+		},
+		"longer array": {
+			a:        `{"x": []}`,
+			b:        `{"x": ["a", ["b"]]}`,
+			expected: `[{"op":"add","path":"/x/0","value":"a"},{"op":"add","path":"/x/1","value":["b"]}]`,
+		},
+		"type change": {
+			a:        `{"x": [1]}`,
+			b:        `{"x": {"0": 1}}`,
+			expected: `[{"op":"replace","path":"/x","value":{"0":1}}]`,
+		},
+		"root": {
+			a:        `1`,
+			b:        `"1"`,
+			expected: `[{"op":"replace","path":"","value":"1"}]`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			patch, err := CreatePatch([]byte(test.a), []byte(test.b))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(patch))
+
+			patched, err := ApplyPatch([]byte(test.a), patch)
+			require.NoError(t, err)
+			expected, err := parseNode([]byte(test.b))
+			require.NoError(t, err)
+			actual, err := parseNode(patched)
+			require.NoError(t, err)
+			assert.True(t, expected.equal(actual), string(patched))
+		})
+	}
+
+	_, err := CreatePatch([]byte(`{}`), []byte(`[`))
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
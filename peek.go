@@ -0,0 +1,111 @@
+package json
+
+import (
+	"io"
+)
+
+// Kind is the kind of a JSON value.
+type Kind int
+
+const (
+	// KindNull is the literal null.
+	KindNull Kind = iota + 1
+	// KindBool is the literal true or false.
+	KindBool
+	// KindNumber is a number.
+	KindNumber
+	// KindString is a string.
+	KindString
+	// KindArray is an array.
+	KindArray
+	// KindObject is an object.
+	KindObject
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	default:
+		return "invalid"
+	}
+}
+
+// Peek returns the next byte of the input that Token would read, without
+// consuming it, such as '{' for an object or ']' for the end of an array. A
+// comma or colon separating values is consumed to look past it, as Token and
+// Decode would. At the end of the input it returns io.EOF.
+func (d *Decoder) Peek() (byte, error) {
+	c, err := d.peek()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c == ',' && d.tokenState == tokenArrayComma:
+		d.tokenState = tokenArrayValue
+	case c == ',' && d.tokenState == tokenObjectComma:
+		d.tokenState = tokenObjectKey
+	case c == ':' && d.tokenState == tokenObjectColon:
+		d.tokenState = tokenObjectValue
+	default:
+		return c, nil
+	}
+	_, _ = d.readByte()
+	if c, err = d.peek(); err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return c, err
+}
+
+// PeekKind returns the kind of the next value without consuming it, so that a
+// caller can choose what to decode it into. An object key is a KindString. If
+// the next byte does not begin a value, including at the end of an array or
+// object, it returns the *SyntaxError that Decode would. At the end of the
+// input it returns io.EOF.
+func (d *Decoder) PeekKind() (Kind, error) {
+	c, err := d.Peek()
+	if err != nil {
+		return 0, err
+	}
+	if d.json5 && (d.tokenState == tokenObjectStart || d.tokenState == tokenObjectKey) && c != '}' {
+		// JSON5 keys may be identifiers
+		return KindString, nil
+	}
+	switch c {
+	case '{':
+		return KindObject, nil
+	case '[':
+		return KindArray, nil
+	case '"':
+		return KindString, nil
+	case '\'':
+		if d.json5 {
+			return KindString, nil
+		}
+	case 't', 'f':
+		return KindBool, nil
+	case 'n':
+		return KindNull, nil
+	case '+', 'I', 'N':
+		if d.allowNonFinite && (c != '+' || d.json5) {
+			return KindNumber, nil
+		}
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return KindNumber, nil
+	}
+	// report the byte as Decode would, having read it
+	_, _ = d.readByte()
+	err = d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+	_ = d.unreadByte()
+	return 0, err
+}
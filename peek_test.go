@@ -0,0 +1,169 @@
+package json
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeekKind(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected Kind
+		err      string
+		setup    func(d *Decoder)
+	}{
+		"object":          {input: ` {"a": 1}`, expected: KindObject},
+		"array":           {input: "\n[1]", expected: KindArray},
+		"string":          {input: `"s"`, expected: KindString},
+		"true":            {input: `true`, expected: KindBool},
+		"false":           {input: `false`, expected: KindBool},
+		"null":            {input: `null`, expected: KindNull},
+		"number":          {input: `12`, expected: KindNumber},
+		"negative":        {input: `-1`, expected: KindNumber},
+		"invalid":         {input: ` ~`, err: "invalid character '~' looking for beginning of value"},
+		"end":             {input: ` ]`, err: "invalid character ']' looking for beginning of value"},
+		"single quote":    {input: `'s'`, err: "invalid character '\\'' looking for beginning of value"},
+		"json5 string":    {input: `'s'`, expected: KindString, setup: (*Decoder).AllowJSON5},
+		"nan":             {input: `NaN`, err: "invalid character 'N' looking for beginning of value"},
+		"allowed nan":     {input: `NaN`, expected: KindNumber, setup: (*Decoder).AllowNonFinite},
+		"plus":            {input: `+1`, err: "invalid character '+' looking for beginning of value", setup: (*Decoder).AllowNonFinite},
+		"json5 plus":      {input: `+1`, expected: KindNumber, setup: (*Decoder).AllowJSON5},
+		"comment":         {input: "/* c */ {}", expected: KindObject, setup: (*Decoder).AllowComments},
+		"comment refused": {input: "/* c */ {}", err: "invalid character '/' looking for beginning of value"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, oneByte := range []bool{false, true} {
+				var r io.Reader = strings.NewReader(test.input)
+				if oneByte {
+					r = iotest.OneByteReader(r)
+				}
+				d := NewDecoder(r)
+				if test.setup != nil {
+					test.setup(d)
+				}
+				kind, err := d.PeekKind()
+				if test.err != "" {
+					assert.EqualError(t, err, test.err)
+					assert.Equal(t, d.Decode(new(interface{})), err)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, kind)
+				// the value is still there to decode
+				var v interface{}
+				assert.NoError(t, d.Decode(&v))
+			}
+		})
+	}
+}
+
+func TestPeekKindPolymorphic(t *testing.T) {
+	type item struct {
+		Name string
+	}
+	d := NewDecoder(strings.NewReader(`"a" {"name": "b"} "c"`))
+	var names []string
+	for {
+		kind, err := d.PeekKind()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		var it item
+		if kind == KindString {
+			err = d.Decode(&it.Name)
+		} else {
+			err = d.Decode(&it)
+		}
+		require.NoError(t, err)
+		names = append(names, it.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+}
+
+func TestPeekToken(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"a": [1, "x", null], "b": {}}`))
+	var kinds []Kind
+	var peeked []byte
+	for {
+		c, err := d.Peek()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		peeked = append(peeked, c)
+		if c != ']' && c != '}' {
+			kind, err := d.PeekKind()
+			require.NoError(t, err)
+			kinds = append(kinds, kind)
+		}
+		_, err = d.Token()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, `{"[1"n]"{}}`, string(peeked))
+	assert.Equal(t, []Kind{KindObject, KindString, KindArray, KindNumber, KindString, KindNull, KindString, KindObject}, kinds)
+}
+
+func TestPeekDecodeElements(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[1, "two", [3]]`))
+	_, err := d.Token()
+	require.NoError(t, err)
+	var got []interface{}
+	for d.More() {
+		kind, err := d.PeekKind()
+		require.NoError(t, err)
+		switch kind {
+		case KindNumber:
+			var n int
+			require.NoError(t, d.Decode(&n))
+			got = append(got, n)
+		case KindString:
+			var s string
+			require.NoError(t, d.Decode(&s))
+			got = append(got, s)
+		default:
+			require.NoError(t, d.Skip())
+			got = append(got, kind)
+		}
+	}
+	_, err = d.Token()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, "two", KindArray}, got)
+}
+
+func TestPeekJSON5Key(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{a: 1}`))
+	d.AllowJSON5()
+	_, err := d.Token()
+	require.NoError(t, err)
+	kind, err := d.PeekKind()
+	require.NoError(t, err)
+	assert.Equal(t, KindString, kind)
+	key, err := d.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "a", key)
+}
+
+func TestPeekErrors(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[1,`))
+	_, err := d.Token()
+	require.NoError(t, err)
+	_, err = d.Token()
+	require.NoError(t, err)
+	_, err = d.Peek()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = NewDecoder(strings.NewReader(" ")).PeekKind()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestKindString(t *testing.T) {
+	assert.Equal(t, "object", KindObject.String())
+	assert.Equal(t, "invalid", Kind(0).String())
+}
@@ -0,0 +1,242 @@
+package json
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// DecodePointer reads the next value from the input and decodes only the part
+// of it named by pointer, a JSON Pointer as defined by RFC 6901, into the value
+// pointed to by v. The rest of the value is checked and skipped without being
+// decoded or held in memory. The empty pointer names the whole value. If an
+// object repeats the key named by the pointer, the first is decoded.
+//
+// A *PointerError is returned if the pointer is malformed, when nothing is read,
+// or if the value has no such part, when the whole value has still been read.
+func (d *Decoder) DecodePointer(pointer string, v interface{}) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return d.Decode(v)
+	}
+	vv := reflect.ValueOf(v)
+	if vv.Kind() != reflect.Ptr || vv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v)}
+	}
+
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !d.tokenValueAllowed() {
+		return d.syntaxErrorf("not at beginning of value")
+	}
+	c, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	found, err := d.readPointer(c, tokens, vv)
+	if err != nil {
+		return err
+	}
+	if err = d.valueEnd(); err != nil {
+		return err
+	}
+	if !found {
+		return &PointerError{Pointer: pointer, msg: "does not name a value"}
+	}
+	return nil
+}
+
+// pointerUnescaper replaces the escape sequences of JSON Pointer reference
+// tokens.
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &PointerError{Pointer: pointer, msg: "does not begin with /"}
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, &PointerError{Pointer: pointer, msg: "has an invalid escape"}
+			}
+		}
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// readPointer reads the value beginning with c, decoding the part of it named
+// by tokens into v. It reports whether there was such a part.
+func (d *Decoder) readPointer(c byte, tokens []string, v reflect.Value) (bool, error) {
+	if len(tokens) == 0 {
+		return true, d.readValue(c, v)
+	}
+	var err error
+	if c, err = d.skipSpace(c); err != nil {
+		return false, err
+	}
+	d.countValue()
+	switch c {
+	case '{':
+		return d.readPointerObject(tokens, v)
+	case '[':
+		return d.readPointerArray(tokens, v)
+	default:
+		return false, d.skipValue(c)
+	}
+}
+
+// readPointerObject reads an object whose opening brace has been consumed,
+// decoding the part of the member named by tokens[0] that the rest of tokens
+// name.
+func (d *Decoder) readPointerObject(tokens []string, v reflect.Value) (bool, error) {
+	if err := d.enter(); err != nil {
+		return false, err
+	}
+	defer d.leave()
+
+	var (
+		c        = byte('{')
+		err      error
+		firstKey = true
+		found    bool
+		seen     map[string]struct{}
+	)
+	for {
+		switch c {
+		case ',', '{':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return false, io.ErrUnexpectedEOF
+				}
+				return false, err
+			}
+			if firstKey && c == '}' {
+				return found, nil
+			}
+			firstKey = false
+
+			key, keyOffset, err := d.readObjectKeyBytes(c)
+			if err != nil {
+				return false, err
+			}
+			match := !found && string(key) == tokens[0]
+			if d.disallowDuplicateKeys {
+				if _, ok := seen[string(key)]; ok {
+					return false, &DuplicateKeyError{
+						Key:    string(key),
+						Offset: keyOffset,
+					}
+				}
+				if seen == nil {
+					seen = map[string]struct{}{}
+				}
+				seen[string(key)] = struct{}{}
+			}
+			if err = d.readObjectSeparator(); err != nil {
+				return false, err
+			}
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return false, io.ErrUnexpectedEOF
+				}
+				return false, err
+			}
+			if match {
+				found, err = d.readPointer(c, tokens[1:], v)
+			} else {
+				err = d.skipValue(c)
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return false, io.ErrUnexpectedEOF
+				}
+				return false, err
+			}
+		case '}':
+			return found, nil
+		default:
+			return false, d.syntaxErrorf("invalid character %q after object key:value pair", c)
+		}
+	}
+}
+
+// readPointerArray reads an array whose opening bracket has been consumed,
+// decoding the part of the element indexed by tokens[0] that the rest of tokens
+// name.
+func (d *Decoder) readPointerArray(tokens []string, v reflect.Value) (bool, error) {
+	if err := d.enter(); err != nil {
+		return false, err
+	}
+	defer d.leave()
+
+	index := pointerIndex(tokens[0])
+	var (
+		c     = byte('[')
+		err   error
+		found bool
+	)
+	for i := 0; ; i++ {
+		switch c {
+		case ',', '[':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return false, io.ErrUnexpectedEOF
+				}
+				return false, err
+			}
+			if i == 0 && c == ']' {
+				return false, nil
+			}
+			if i == index {
+				found, err = d.readPointer(c, tokens[1:], v)
+			} else {
+				err = d.skipValue(c)
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return false, io.ErrUnexpectedEOF
+				}
+				return false, err
+			}
+		case ']':
+			return found, nil
+		default:
+			return false, d.syntaxErrorf("invalid character %q after array element", c)
+		}
+	}
+}
+
+// pointerIndex returns the array index that the reference token names, or -1
+// if it is not an index. An index has no leading zeros.
+func pointerIndex(token string) int {
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return -1
+	}
+	n := 0
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if c < '0' || c > '9' || n > (1<<31)/10 {
+			return -1
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
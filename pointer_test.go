@@ -0,0 +1,118 @@
+package json
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pointerDoc is the example document of RFC 6901.
+const pointerDoc = `{
+	"foo": ["bar", "baz"],
+	"": 0,
+	"a/b": 1,
+	"c%d": 2,
+	"e^f": 3,
+	"g|h": 4,
+	"i\\j": 5,
+	"k\"l": 6,
+	" ": 7,
+	"m~n": 8
+}`
+
+func TestDecodePointer(t *testing.T) {
+	tests := map[string]struct {
+		pointer  string
+		expected interface{}
+		err      string
+	}{
+		"whole": {
+			pointer: "",
+			expected: map[string]interface{}{
+				"foo": []interface{}{"bar", "baz"}, "": float64(0), "a/b": float64(1), "c%d": float64(2),
+				"e^f": float64(3), "g|h": float64(4), "i\\j": float64(5), "k\"l": float64(6), " ": float64(7),
+				"m~n": float64(8),
+			},
+		},
+		"array":           {pointer: "/foo", expected: []interface{}{"bar", "baz"}},
+		"index":           {pointer: "/foo/0", expected: "bar"},
+		"last index":      {pointer: "/foo/1", expected: "baz"},
+		"empty key":       {pointer: "/", expected: float64(0)},
+		"escaped slash":   {pointer: "/a~1b", expected: float64(1)},
+		"percent":         {pointer: "/c%d", expected: float64(2)},
+		"caret":           {pointer: "/e^f", expected: float64(3)},
+		"pipe":            {pointer: "/g|h", expected: float64(4)},
+		"backslash":       {pointer: "/i\\j", expected: float64(5)},
+		"quote":           {pointer: "/k\"l", expected: float64(6)},
+		"space":           {pointer: "/ ", expected: float64(7)},
+		"escaped tilde":   {pointer: "/m~0n", expected: float64(8)},
+		"missing key":     {pointer: "/bar", err: `json: pointer "/bar" does not name a value`},
+		"index too big":   {pointer: "/foo/2", err: `json: pointer "/foo/2" does not name a value`},
+		"leading zero":    {pointer: "/foo/01", err: `json: pointer "/foo/01" does not name a value`},
+		"end of array":    {pointer: "/foo/-", err: `json: pointer "/foo/-" does not name a value`},
+		"into scalar":     {pointer: "/a~1b/c", err: `json: pointer "/a~1b/c" does not name a value`},
+		"no slash":        {pointer: "foo", err: `json: pointer "foo" does not begin with /`},
+		"bad escape":      {pointer: "/m~2n", err: `json: pointer "/m~2n" has an invalid escape`},
+		"trailing escape": {pointer: "/m~", err: `json: pointer "/m~" has an invalid escape`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(pointerDoc + ` "next"`))
+			var actual interface{}
+			err := dec.DecodePointer(test.pointer, &actual)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+			if test.err == "" || strings.HasSuffix(test.err, "does not name a value") {
+				// the whole value was read
+				var next string
+				require.NoError(t, dec.Decode(&next))
+				assert.Equal(t, "next", next)
+			}
+		})
+	}
+}
+
+func TestDecodePointerTyped(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"data": {"items": [{"X": 1}, {"X": 2, "Y": 3}], "items2": []}} [0, [1, 2]]`))
+	var inner decodeInner
+	require.NoError(t, dec.DecodePointer("/data/items/1", &inner))
+	assert.Equal(t, decodeInner{X: 2, Y: 3}, inner)
+
+	var n int
+	require.NoError(t, dec.DecodePointer("/1/1", &n))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.EOF, dec.DecodePointer("/0", &n))
+}
+
+func TestDecodePointerErrors(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"syntax after":   {`{"a": 1, "b": [1,]}`, "invalid character ']' looking for beginning of value"},
+		"syntax before":  {`{"b": tru, "a": 1}`, "invalid character ',' in literal true (expecting 'e')"},
+		"type":           {`{"a": "s"}`, "json: cannot unmarshal string into Go value of type int"},
+		"truncated":      {`{"a": 1, "b": [`, "unexpected EOF"},
+		"duplicate keys": {`{"a": 1, "a": 2}`, `json: duplicate object key "a" at offset 10`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(test.input))
+			dec.DisallowDuplicateKeys()
+			var n int
+			assert.EqualError(t, dec.DecodePointer("/a", &n), test.err)
+		})
+	}
+
+	var n int
+	assert.Equal(t, &InvalidUnmarshalError{}, NewDecoder(strings.NewReader(`{}`)).DecodePointer("/a", nil))
+	assert.EqualError(t, NewDecoder(strings.NewReader(`{}`)).DecodePointer("/a", n), "json: Unmarshal(non-pointer int)")
+}
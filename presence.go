@@ -0,0 +1,35 @@
+package json
+
+import (
+	"strings"
+)
+
+// TrackPresence causes the Decoder to record in present which struct fields had
+// keys in the objects it decodes, so that an absent field can be told apart
+// from a null or a zero value, as a PATCH request needs. Each field present is
+// recorded by its JSON Pointer, such as "/address/city" or "/items/0/name",
+// mapped to false if its value was null and true otherwise. Fields that were
+// absent have no entry. The map is not cleared between calls to Decode, and
+// values decoded by an Unmarshaler or a registered decoder are not tracked.
+// Passing a nil map stops tracking.
+func (d *Decoder) TrackPresence(present map[string]bool) {
+	d.presence = present
+}
+
+// pushPresence adds the member named key, whose value begins with c, to the
+// path of the value being decoded, recording it if it is the struct field f.
+func (d *Decoder) pushPresence(key string, f *field, c byte) {
+	if f != nil {
+		key = f.name
+	}
+	d.presencePath = append(d.presencePath, pointerEscaper.Replace(key))
+	if f == nil {
+		return
+	}
+	var b strings.Builder
+	for _, token := range d.presencePath {
+		b.WriteByte('/')
+		b.WriteString(token)
+	}
+	d.presence[b.String()] = c != 'n'
+}
@@ -0,0 +1,126 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type presenceAddress struct {
+	City   string  `json:"city"`
+	Street *string `json:"street"`
+}
+
+type presenceItem struct {
+	Name string `json:"name"`
+}
+
+type presenceUser struct {
+	Name    string                  `json:"name"`
+	Age     int                     `json:"age"`
+	Address *presenceAddress        `json:"address"`
+	Items   []presenceItem          `json:"items"`
+	ByKey   map[string]presenceItem `json:"byKey"`
+	Extra   interface{}             `json:"extra"`
+	Slash   string                  `json:"a/b~c"`
+}
+
+func TestDecodeTrackPresence(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected map[string]bool
+	}{
+		"empty": {
+			input:    `{}`,
+			expected: map[string]bool{},
+		},
+		"null and zero": {
+			input:    `{"name": null, "age": 0}`,
+			expected: map[string]bool{"/name": false, "/age": true},
+		},
+		"nested": {
+			input: `{"address": {"city": "x", "street": null}}`,
+			expected: map[string]bool{
+				"/address":        true,
+				"/address/city":   true,
+				"/address/street": false,
+			},
+		},
+		"arrays and maps": {
+			input: `{"items": [{"name": "a"}, {}], "byKey": {"k/1": {"name": null}}}`,
+			expected: map[string]bool{
+				"/items":           true,
+				"/items/0/name":    true,
+				"/byKey":           true,
+				"/byKey/k~11/name": false,
+			},
+		},
+		"interface": {
+			input:    `{"extra": {"name": 1}}`,
+			expected: map[string]bool{"/extra": true},
+		},
+		"case insensitive": {
+			input:    `{"NAME": "a", "a/b~c": "b"}`,
+			expected: map[string]bool{"/name": true, "/a~1b~0c": true},
+		},
+		"unknown": {
+			input:    `{"other": {"name": 1}, "age": null}`,
+			expected: map[string]bool{"/age": false},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			present := map[string]bool{}
+			dec.TrackPresence(present)
+			var actual presenceUser
+			require.NoError(t, dec.Decode(&actual))
+			assert.Equal(t, tt.expected, present)
+		})
+	}
+}
+
+func TestDecodeTrackPresenceStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[{"name": "a"}, {"age": 1}] {"address": null}`))
+	present := map[string]bool{}
+	dec.TrackPresence(present)
+	var users []presenceUser
+	require.NoError(t, dec.Decode(&users))
+	assert.Equal(t, map[string]bool{"/0/name": true, "/1/age": true}, present)
+
+	var user presenceUser
+	require.NoError(t, dec.Decode(&user))
+	assert.Equal(t, map[string]bool{"/0/name": true, "/1/age": true, "/address": false}, present)
+
+	dec = NewDecoder(strings.NewReader(`{"name": "a"}`))
+	dec.TrackPresence(present)
+	dec.TrackPresence(nil)
+	require.NoError(t, dec.Decode(&user))
+	assert.Len(t, present, 3)
+}
+
+func TestDecodeTrackPresenceMergePatch(t *testing.T) {
+	user := presenceUser{Name: "a", Age: 2, Address: &presenceAddress{City: "x"}}
+	dec := NewDecoder(strings.NewReader(`{"age": null, "address": {"city": "y"}}`))
+	present := map[string]bool{}
+	dec.TrackPresence(present)
+	require.NoError(t, dec.DecodeMergePatch(&user))
+	assert.Equal(t, presenceUser{Name: "a", Address: &presenceAddress{City: "y"}}, user)
+	assert.Equal(t, map[string]bool{"/age": false, "/address": true, "/address/city": true}, present)
+}
+
+func TestDecodeTrackPresenceError(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"address": {"city": 1}}`))
+	present := map[string]bool{}
+	dec.TrackPresence(present)
+	var user presenceUser
+	require.Error(t, dec.Decode(&user))
+	assert.Equal(t, map[string]bool{"/address": true, "/address/city": true}, present)
+
+	// the path of the failed value is not kept
+	dec.Reset(strings.NewReader(`{"name": "b"}`))
+	require.NoError(t, dec.Decode(&user))
+	assert.True(t, present["/name"])
+}
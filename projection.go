@@ -0,0 +1,249 @@
+package json
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// DecodeFields reads the next value from the input and decodes only the parts
+// of it named by the keys of fields into the values they map to, which must be
+// pointers. The value is read once, and the rest of it is checked and skipped
+// without being decoded or held in memory.
+//
+// A path is a dot separated list of object keys and array indices, such as
+// "items.0.name", and the empty path names the whole value. Keys are matched
+// exactly, and those containing dots can be named with DecodePointer. The
+// values of paths missing from the input are left unchanged. If an object
+// repeats a key, each of its values is decoded in turn. A path may be within
+// another, in which case both are decoded.
+func (d *Decoder) DecodeFields(fields map[string]interface{}) error {
+	root := &fieldPaths{}
+	for path, v := range fields {
+		vv := reflect.ValueOf(v)
+		if vv.Kind() != reflect.Ptr || vv.IsNil() {
+			return &InvalidUnmarshalError{reflect.TypeOf(v)}
+		}
+		node := root
+		if path != "" {
+			for _, key := range strings.Split(path, ".") {
+				node = node.child(key)
+			}
+		}
+		node.v = vv
+	}
+
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !d.tokenValueAllowed() {
+		return d.syntaxErrorf("not at beginning of value")
+	}
+	c, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	if err = d.readFields(c, root); err != nil {
+		return err
+	}
+	return d.valueEnd()
+}
+
+// fieldPaths is a tree of the paths passed to DecodeFields.
+type fieldPaths struct {
+	// v is the pointer to decode the value at this path into, if it is
+	// requested.
+	v reflect.Value
+	// keys holds the paths within the members of an object.
+	keys map[string]*fieldPaths
+	// indices holds the paths within the elements of an array, by the keys
+	// that are indices.
+	indices map[int]*fieldPaths
+}
+
+// child returns the paths within the member or element named key, adding them
+// if there are none.
+func (p *fieldPaths) child(key string) *fieldPaths {
+	if c, ok := p.keys[key]; ok {
+		return c
+	}
+	c := &fieldPaths{}
+	if p.keys == nil {
+		p.keys = map[string]*fieldPaths{}
+	}
+	p.keys[key] = c
+	if i := pointerIndex(key); i >= 0 {
+		if p.indices == nil {
+			p.indices = map[int]*fieldPaths{}
+		}
+		p.indices[i] = c
+	}
+	return c
+}
+
+// readFields reads the value beginning with c, decoding the parts of it named
+// by paths.
+func (d *Decoder) readFields(c byte, paths *fieldPaths) error {
+	if paths.v.IsValid() {
+		if paths.keys == nil {
+			return d.readValue(c, paths.v)
+		}
+		// the value is wanted whole and in parts, so it is decoded twice
+		raw, err := d.readRaw(c)
+		if err != nil {
+			return err
+		}
+		dec := d.valueDecoder(raw)
+		err = dec.Decode(paths.v.Interface())
+		d.stats.Strings += dec.stats.Strings
+		if err != nil {
+			return err
+		}
+		parts := *paths
+		parts.v = reflect.Value{}
+		dec = d.valueDecoder(raw)
+		if c, err = dec.readByte(); err != nil {
+			return err
+		}
+		err = dec.readFields(c, &parts)
+		d.stats.Strings += dec.stats.Strings
+		return err
+	}
+	var err error
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	d.countValue()
+	switch {
+	case c == '{' && paths.keys != nil:
+		return d.readFieldsObject(paths)
+	case c == '[' && paths.indices != nil:
+		return d.readFieldsArray(paths)
+	default:
+		return d.skipValue(c)
+	}
+}
+
+// readFieldsObject reads an object whose opening brace has been consumed,
+// decoding the parts of its members named by paths.
+func (d *Decoder) readFieldsObject(paths *fieldPaths) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c        = byte('{')
+		err      error
+		firstKey = true
+		seen     map[string]struct{}
+	)
+	for {
+		switch c {
+		case ',', '{':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if firstKey && c == '}' {
+				return nil
+			}
+			firstKey = false
+
+			key, keyOffset, err := d.readObjectKeyBytes(c)
+			if err != nil {
+				return err
+			}
+			member := paths.keys[string(key)]
+			if d.disallowDuplicateKeys {
+				if _, ok := seen[string(key)]; ok {
+					return &DuplicateKeyError{
+						Key:    string(key),
+						Offset: keyOffset,
+					}
+				}
+				if seen == nil {
+					seen = map[string]struct{}{}
+				}
+				seen[string(key)] = struct{}{}
+			}
+			if err = d.readObjectSeparator(); err != nil {
+				return err
+			}
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if member != nil {
+				err = d.readFields(c, member)
+			} else {
+				err = d.skipValue(c)
+			}
+			if err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case '}':
+			return nil
+		default:
+			return d.syntaxErrorf("invalid character %q after object key:value pair", c)
+		}
+	}
+}
+
+// readFieldsArray reads an array whose opening bracket has been consumed,
+// decoding the parts of its elements named by paths.
+func (d *Decoder) readFieldsArray(paths *fieldPaths) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c   = byte('[')
+		err error
+	)
+	for i := 0; ; i++ {
+		switch c {
+		case ',', '[':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if i == 0 && c == ']' {
+				return nil
+			}
+			if element := paths.indices[i]; element != nil {
+				err = d.readFields(c, element)
+			} else {
+				err = d.skipValue(c)
+			}
+			if err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case ']':
+			return nil
+		default:
+			return d.syntaxErrorf("invalid character %q after array element", c)
+		}
+	}
+}
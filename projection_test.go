@@ -0,0 +1,176 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeFields(t *testing.T) {
+	input := `{
+		"id": 7,
+		"user": {"name": "a", "tags": ["x", "y"], "address": {"city": "c"}},
+		"items": [{"name": "first"}, {"name": "second", "price": 1.5}],
+		"a.b": 1,
+		"0": "zero",
+		"skipped": {"deep": [[[{}]]], "s": "é"}
+	} "next"`
+	var (
+		id      int
+		name    string
+		tag     string
+		city    string
+		user    map[string]interface{}
+		item    decodeItem
+		price   float64
+		missing = "unchanged"
+		index   string
+		whole   interface{}
+	)
+	dec := NewDecoder(strings.NewReader(input))
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{
+		"id":                &id,
+		"user.name":         &name,
+		"user.tags.1":       &tag,
+		"user.address.city": &city,
+		"user":              &user,
+		"items.0":           &item,
+		"items.1.price":     &price,
+		"items.2.name":      &missing,
+		"user.nothing":      &missing,
+		"id.into":           &missing,
+		"a.b":               &missing,
+		"0":                 &index,
+	}))
+	assert.Equal(t, 7, id)
+	assert.Equal(t, "a", name)
+	assert.Equal(t, "y", tag)
+	assert.Equal(t, "c", city)
+	assert.Equal(t, map[string]interface{}{
+		"name":    "a",
+		"tags":    []interface{}{"x", "y"},
+		"address": map[string]interface{}{"city": "c"},
+	}, user)
+	assert.Equal(t, decodeItem{Name: "first"}, item)
+	assert.Equal(t, 1.5, price)
+	assert.Equal(t, "unchanged", missing)
+	assert.Equal(t, "zero", index)
+
+	// the whole value was read
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{"": &whole}))
+	assert.Equal(t, "next", whole)
+	assert.Equal(t, io.EOF, dec.DecodeFields(map[string]interface{}{"": &whole}))
+}
+
+type decodeItem struct {
+	Name string `json:"name"`
+}
+
+func TestDecodeFieldsArray(t *testing.T) {
+	var (
+		first, third int
+		key          string
+	)
+	dec := NewDecoder(strings.NewReader(`[1, 2, 3] {"01": "a"}`))
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{"0": &first, "2": &third, "3": &first}))
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 3, third)
+
+	// keys that are not indices still name members
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{"01": &key}))
+	assert.Equal(t, "a", key)
+}
+
+func TestDecodeFieldsDuplicateKeys(t *testing.T) {
+	var n int
+	require.NoError(t, NewDecoder(strings.NewReader(`{"a": 1, "a": 2}`)).DecodeFields(map[string]interface{}{"a": &n}))
+	assert.Equal(t, 2, n)
+
+	dec := NewDecoder(strings.NewReader(`{"a": 1, "b": 2, "a": 3}`))
+	dec.DisallowDuplicateKeys()
+	assert.EqualError(t, dec.DecodeFields(map[string]interface{}{"b": &n}), `json: duplicate object key "a" at offset 18`)
+}
+
+func TestDecodeFieldsToken(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[{"a": 1, "b": 2}, {"a": 3}]`))
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('['), tok)
+	var a []int
+	for dec.More() {
+		var n int
+		require.NoError(t, dec.DecodeFields(map[string]interface{}{"a": &n}))
+		a = append(a, n)
+	}
+	assert.Equal(t, []int{1, 3}, a)
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim(']'), tok)
+}
+
+func TestDecodeFieldsErrors(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"syntax after":  {`{"a": 1, "b": [1,]}`, "invalid character ']' looking for beginning of value"},
+		"syntax before": {`{"b": tru, "a": 1}`, "invalid character ',' in literal true (expecting 'e')"},
+		"syntax within": {`{"c": {"d": 1 "e": 2}}`, "invalid character '\"' after object key:value pair"},
+		"type":          {`{"a": "s"}`, "json: cannot unmarshal string into Go value of type int"},
+		"nested type":   {`{"c": {"d": "s"}}`, "json: cannot unmarshal string into Go value of type int"},
+		"truncated":     {`{"a": 1, "b": [`, "unexpected EOF"},
+		"array":         {`{"c": [1 2]}`, "invalid character '2' after array element"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var a, d int
+			var c interface{}
+			err := NewDecoder(strings.NewReader(test.input)).DecodeFields(map[string]interface{}{"a": &a, "c": &c, "c.d": &d, "c.0": &d})
+			assert.EqualError(t, err, test.err)
+		})
+	}
+
+	var n int
+	assert.Equal(t, &InvalidUnmarshalError{}, NewDecoder(strings.NewReader(`{}`)).DecodeFields(map[string]interface{}{"a": nil}))
+	assert.EqualError(t, NewDecoder(strings.NewReader(`{}`)).DecodeFields(map[string]interface{}{"a": n}), "json: Unmarshal(non-pointer int)")
+}
+
+func TestDecodeFieldsOptions(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: {b: 'c', d: [+1]}, /* e */ f: 0x10}`))
+	dec.AllowJSON5()
+	var (
+		b string
+		a map[string]interface{}
+	)
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{"a.b": &b, "a": &a}))
+	assert.Equal(t, "c", b)
+	assert.Equal(t, map[string]interface{}{"b": "c", "d": []interface{}{float64(1)}}, a)
+
+	dec = NewDecoder(strings.NewReader(`{"a": [[[1]]]}`))
+	dec.SetMaxDepth(3)
+	var n int
+	assert.Error(t, dec.DecodeFields(map[string]interface{}{"a.0.0.0": &n}))
+}
+
+func TestDecodeFieldsAllocs(t *testing.T) {
+	allocs := func(skipped string) float64 {
+		input := []byte(`{"skipped": ` + skipped + `, "a": {"b": 1}}`)
+		r := bytes.NewReader(input)
+		var (
+			dec Decoder
+			n   int
+		)
+		fields := map[string]interface{}{"a.b": &n}
+		return testing.AllocsPerRun(100, func() {
+			r.Reset(input)
+			dec.Reset(r)
+			require.NoError(t, dec.DecodeFields(fields))
+		})
+	}
+	large := `[` + strings.Repeat(`{"key": "value", "n": [1.5, true, null]},`, 100) + `{}]`
+	assert.Equal(t, allocs(`0`), allocs(large), "skipped values should not be allocated")
+}
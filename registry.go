@@ -0,0 +1,116 @@
+package json
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// RegisterDecoder makes the Decoder decode values of type t by calling fn, in
+// place of how they would otherwise be decoded, including by an Unmarshaler.
+// This allows decoding types that cannot be changed to implement Unmarshaler.
+//
+// fn is passed a Decoder holding only the JSON value, which may be null, and
+// with the same options as this one, and v, the settable value of type t to
+// decode it into. Decoding into a t with dec calls fn again, to decode the value
+// as usual convert v to a pointer to a type that has t's underlying type.
+// Registering a nil fn removes the type's decoder.
+func (d *Decoder) RegisterDecoder(t reflect.Type, fn func(dec *Decoder, v reflect.Value) error) {
+	if fn == nil {
+		delete(d.decoders, t)
+		return
+	}
+	if d.decoders == nil {
+		d.decoders = make(map[reflect.Type]func(*Decoder, reflect.Value) error)
+	}
+	d.decoders[t] = fn
+}
+
+// readRegistered reads the value beginning with c into the pointer v using the
+// decoder fn registered for the type of v's element.
+func (d *Decoder) readRegistered(c byte, v reflect.Value, fn func(*Decoder, reflect.Value) error) error {
+	raw, err := d.readRaw(c)
+	if err != nil {
+		return err
+	}
+	dec := d.valueDecoder(raw)
+	err = fn(dec, v.Elem())
+	d.stats.Strings += dec.stats.Strings
+	return err
+}
+
+// valueDecoder returns a Decoder of the JSON value raw with the same options
+// as d, and the depth it has reached.
+func (d *Decoder) valueDecoder(raw []byte) *Decoder {
+	return &Decoder{
+		buf:                   raw,
+		strictNumbers:         d.strictNumbers,
+		disallowUnknownFields: d.disallowUnknownFields,
+		matchCaseSensitive:    d.matchCaseSensitive,
+		disallowDuplicateKeys: d.disallowDuplicateKeys,
+		mergePatch:            d.mergePatch,
+		allowComments:         d.allowComments,
+		json5:                 d.json5,
+		allowNonFinite:        d.allowNonFinite,
+		invalidUTF8:           d.invalidUTF8,
+		timeLayout:            d.timeLayout,
+		tagKey:                d.tagKey,
+		decoders:              d.decoders,
+		arena:                 d.arena,
+		maxDepth:              d.maxDepth,
+		maxStringLen:          d.maxStringLen,
+		maxNumberLen:          d.maxNumberLen,
+		orderedObjects:        d.orderedObjects,
+		rawNumber:             d.rawNumber,
+		allowQuotedInts:       d.allowQuotedInts,
+		depth:                 d.depth,
+	}
+}
+
+// RegisterEncoder makes the Encoder encode values of type t by calling fn, in
+// place of how they would otherwise be encoded, including by a Marshaler. This
+// allows encoding types in a format of the application's choosing without
+// wrapping them. A nil pointer to t is still encoded as null.
+//
+// fn is passed an Encoder with the same options as this one and v, the value of
+// type t, and must call the Encoder's Encode exactly once to write the value.
+// Encoding a t with enc calls fn again. Registering a nil fn removes the type's
+// encoder.
+func (enc *Encoder) RegisterEncoder(t reflect.Type, fn func(enc *Encoder, v reflect.Value) error) {
+	if fn == nil {
+		delete(enc.encoders, t)
+		return
+	}
+	if enc.encoders == nil {
+		enc.encoders = make(map[reflect.Type]func(*Encoder, reflect.Value) error)
+	}
+	enc.encoders[t] = fn
+}
+
+// encodeRegistered appends v as encoded by the encoder fn registered for its
+// type, which must write a single valid value.
+func (e *encodeState) encodeRegistered(v reflect.Value, fn func(*Encoder, reflect.Value) error) error {
+	var buf bytes.Buffer
+	err := fn(&Encoder{
+		w:                  &buf,
+		escapeHTML:         e.escapeHTML,
+		nonFinite:          e.nonFinite,
+		timeLayout:         e.timeLayout,
+		tagKey:             e.tagKey,
+		encoders:           e.encoders,
+		unsortedKeys:       e.unsortedKeys,
+		stringifyLargeInts: e.stringifyLargeInts,
+		escapeSolidus:      e.escapeSolidus,
+	}, v)
+	if err == nil {
+		err = checkValid(buf.Bytes())
+	}
+	if err != nil {
+		return &MarshalerError{
+			Type:       v.Type(),
+			Err:        err,
+			sourceFunc: "registered encoder",
+		}
+	}
+	e.buf = appendCompact(e.buf, buf.Bytes(), e.escapeHTML, e.escapeSolidus)
+	return nil
+}
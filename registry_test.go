@@ -0,0 +1,207 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// decodeBigInt decodes a big.Int from a string of decimal digits.
+func decodeBigInt(dec *Decoder, v reflect.Value) error {
+	var s *string
+	if err := dec.Decode(&s); err != nil || s == nil {
+		return err
+	}
+	if _, ok := v.Addr().Interface().(*big.Int).SetString(*s, 10); !ok {
+		return errors.New("bad integer " + *s)
+	}
+	return nil
+}
+
+type registryFields struct {
+	A  big.Int
+	P  *big.Int
+	S  []big.Int
+	R  decodeRaw
+	In decodeInner
+}
+
+func TestDecoderRegisterDecoder(t *testing.T) {
+	big1 := new(big.Int).Lsh(big.NewInt(1), 100)
+
+	tests := map[string]struct {
+		input    string
+		expected registryFields
+		err      string
+	}{
+		"value": {
+			input:    `{"A": "1267650600228229401496703205376"}`,
+			expected: registryFields{A: *big1},
+		},
+		"pointer": {
+			input:    `{"P": "1267650600228229401496703205376"}`,
+			expected: registryFields{P: big1},
+		},
+		"slice": {
+			input:    `{"S": ["1", "2"]}`,
+			expected: registryFields{S: []big.Int{*big.NewInt(1), *big.NewInt(2)}},
+		},
+		"null": {
+			input: `{"A": null, "P": null}`,
+		},
+		"overrides Unmarshaler": {
+			input:    `{"R": false}`,
+			expected: registryFields{R: decodeRaw{raw: "registered"}},
+		},
+		"error": {
+			input: `{"A": "1.5"}`,
+			err:   "bad integer 1.5",
+		},
+		"syntax error": {
+			input: `{"A": [1,]}`,
+			err:   "invalid character ']' looking for beginning of value",
+		},
+		"options": {
+			input: `{"In": {"W": 1}}`,
+			err:   `json: unknown field "W"`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(test.input))
+			dec.DisallowUnknownFields()
+			dec.RegisterDecoder(bigIntType, decodeBigInt)
+			dec.RegisterDecoder(reflect.TypeOf(decodeRaw{}), func(dec *Decoder, v reflect.Value) error {
+				v.Set(reflect.ValueOf(decodeRaw{raw: "registered"}))
+				return dec.Skip()
+			})
+			dec.RegisterDecoder(reflect.TypeOf(decodeInner{}), func(dec *Decoder, v reflect.Value) error {
+				type plain decodeInner
+				return dec.Decode((*plain)(v.Addr().Interface().(*decodeInner)))
+			})
+			var actual registryFields
+			err := dec.Decode(&actual)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDecoderRegisterDecoderScalar(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`"a" "b"`))
+	dec.RegisterDecoder(reflect.TypeOf(""), func(dec *Decoder, v reflect.Value) error {
+		type plain string
+		var s plain
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		v.SetString(strings.ToUpper(string(s)))
+		return nil
+	})
+	var s string
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, "A", s)
+
+	dec.RegisterDecoder(reflect.TypeOf(""), nil)
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, "b", s)
+}
+
+type registryEncoded struct {
+	A  big.Int
+	P  *big.Int
+	S  []*big.Int
+	R  decodeRaw
+	In decodeInner
+}
+
+func TestEncoderRegisterEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", " ")
+	enc.RegisterEncoder(bigIntType, func(enc *Encoder, v reflect.Value) error {
+		n := v.Addr().Interface().(*big.Int)
+		return enc.Encode(n.String())
+	})
+	enc.RegisterEncoder(reflect.TypeOf(decodeRaw{}), func(enc *Encoder, v reflect.Value) error {
+		return enc.Encode(map[string]string{"raw": v.Interface().(decodeRaw).raw})
+	})
+	enc.RegisterEncoder(reflect.TypeOf(decodeInner{}), func(enc *Encoder, v reflect.Value) error {
+		type plain decodeInner
+		return enc.Encode(plain(v.Interface().(decodeInner)))
+	})
+
+	v := registryEncoded{
+		A:  *new(big.Int).Lsh(big.NewInt(1), 100),
+		S:  []*big.Int{big.NewInt(1), nil},
+		R:  decodeRaw{raw: "<r>"},
+		In: decodeInner{X: 1},
+	}
+	require.NoError(t, enc.Encode(&v))
+	assert.Equal(t, `{
+ "A": "1267650600228229401496703205376",
+ "P": null,
+ "S": [
+  "1",
+  null
+ ],
+ "R": {
+  "raw": "\u003cr\u003e"
+ },
+ "In": {
+  "X": 1,
+  "Y": 0,
+  "A": ""
+ }
+}
+`, buf.String())
+
+	enc.RegisterEncoder(bigIntType, nil)
+	buf.Reset()
+	require.NoError(t, enc.Encode(big.NewInt(7)))
+	assert.Equal(t, "7\n", buf.String())
+}
+
+func TestEncoderRegisterEncoderErrors(t *testing.T) {
+	tests := map[string]struct {
+		fn  func(enc *Encoder, v reflect.Value) error
+		err string
+	}{
+		"error": {
+			fn:  func(enc *Encoder, v reflect.Value) error { return errors.New("boom") },
+			err: "json: error calling registered encoder for type big.Int: boom",
+		},
+		"nothing": {
+			fn:  func(enc *Encoder, v reflect.Value) error { return nil },
+			err: "json: error calling registered encoder for type big.Int: unexpected end of JSON input",
+		},
+		"two values": {
+			fn: func(enc *Encoder, v reflect.Value) error {
+				_ = enc.Encode(1)
+				return enc.Encode(2)
+			},
+			err: "json: error calling registered encoder for type big.Int: invalid character '2' after top-level value",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.RegisterEncoder(bigIntType, test.fn)
+			assert.EqualError(t, enc.Encode([]*big.Int{big.NewInt(1)}), test.err)
+			assert.Empty(t, buf.String())
+		})
+	}
+}
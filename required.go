@@ -0,0 +1,25 @@
+package json
+
+import (
+	"reflect"
+)
+
+// checkRequired returns a *MissingFieldError naming the fields of the struct
+// pointed to by v that are required but were absent, present holds the presence
+// of each field of fields.
+func (d *Decoder) checkRequired(v reflect.Value, fields *structFields, present []byte) error {
+	var missing []string
+	for i := range fields.list {
+		if fields.list[i].required && present[i] == fieldAbsent {
+			missing = append(missing, fields.list[i].name)
+		}
+	}
+	if missing == nil {
+		return nil
+	}
+	return &MissingFieldError{
+		Type:   v.Elem().Type(),
+		Fields: missing,
+		Offset: d.offset,
+	}
+}
@@ -0,0 +1,92 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requiredEmbedded struct {
+	ID int `json:"id,required"`
+}
+
+type requiredConfig struct {
+	requiredEmbedded
+	Host  string          `json:"host,required"`
+	Port  int             `json:",omitempty,required"`
+	Level string          `json:"level,required,default:info"`
+	Inner *requiredConfig `json:"inner"`
+	Plain string          `json:"plain"`
+}
+
+func TestDecodeRequired(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected requiredConfig
+		err      error
+	}{
+		"present": {
+			input:    `{"id": 1, "host": "a", "Port": 2, "level": "debug"}`,
+			expected: requiredConfig{requiredEmbedded: requiredEmbedded{ID: 1}, Host: "a", Port: 2, Level: "debug"},
+		},
+		"null": {
+			input:    `{"id": null, "host": null, "port": null, "level": null}`,
+			expected: requiredConfig{Level: "info"},
+		},
+		"one missing": {
+			input:    `{"id": 1, "host": "a", "level": "debug"}`,
+			expected: requiredConfig{requiredEmbedded: requiredEmbedded{ID: 1}, Host: "a", Level: "debug"},
+			err: &MissingFieldError{
+				Type:   reflect.TypeOf(requiredConfig{}),
+				Fields: []string{"Port"},
+				Offset: 40,
+			},
+		},
+		"all missing": {
+			input:    `{"plain": "x"}`,
+			expected: requiredConfig{Plain: "x"},
+			err: &MissingFieldError{
+				Type:   reflect.TypeOf(requiredConfig{}),
+				Fields: []string{"id", "host", "Port", "level"},
+				Offset: 14,
+			},
+		},
+		"nested": {
+			input:    `{"id": 1, "host": "a", "Port": 2, "level": "debug", "inner": {"host": "b", "plain": "y"}}`,
+			expected: requiredConfig{requiredEmbedded: requiredEmbedded{ID: 1}, Host: "a", Port: 2, Level: "debug", Inner: &requiredConfig{Host: "b", Plain: "y"}},
+			err: &MissingFieldError{
+				Type:   reflect.TypeOf(requiredConfig{}),
+				Fields: []string{"id", "Port", "level"},
+				Offset: 88,
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual requiredConfig
+			err := Unmarshal([]byte(tt.input), &actual)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestMissingFieldError(t *testing.T) {
+	err := NewDecoder(strings.NewReader(`[{}]`)).Decode(new([]requiredEmbedded))
+	assert.EqualError(t, err, `json: missing required field "id" for Go value of type json.requiredEmbedded`)
+
+	err = Unmarshal([]byte(`{}`), new(requiredConfig))
+	assert.EqualError(t, err, `json: missing required fields "id", "host", "Port", "level" for Go value of type json.requiredConfig`)
+	var missing *MissingFieldError
+	require.True(t, errors.As(err, &missing))
+}
+
+func TestDecodeRequiredMergePatch(t *testing.T) {
+	actual := requiredConfig{Host: "a"}
+	require.NoError(t, NewDecoder(strings.NewReader(`{"plain": "x"}`)).DecodeMergePatch(&actual))
+	assert.Equal(t, requiredConfig{Host: "a", Plain: "x"}, actual)
+}
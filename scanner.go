@@ -0,0 +1,108 @@
+package json
+
+import (
+	"io"
+)
+
+// TokenKind is the kind of a token returned by Scanner.Next.
+type TokenKind int
+
+const (
+	ObjectStartToken TokenKind = iota + 1
+	ObjectEndToken
+	ArrayStartToken
+	ArrayEndToken
+	KeyToken
+	StringToken
+	NumberToken
+	BoolToken
+	NullToken
+)
+
+// Scanner splits JSON input into tokens, checking that they form valid JSON
+// values, without decoding them. It reads its input in chunks and, once its
+// buffers have grown, allocates nothing, so it is a base for tools such as
+// validators, indexers and pretty-printers. Like a Decoder it reads a stream of
+// values separated by whitespace.
+type Scanner struct {
+	d      *Decoder
+	delim  [1]byte
+	offset int64
+}
+
+// NewScanner returns a Scanner that reads from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{d: NewDecoder(r)}
+}
+
+// Next returns the next token of the input and its bytes as they are written,
+// so strings and keys are quoted and escaped. The commas and colons separating
+// values are consumed and checked but not returned. The bytes are only valid
+// until the next call to Next. At the end of the input it returns io.EOF, or
+// io.ErrUnexpectedEOF if the input ends within a value. Errors in the input are
+// returned as a *SyntaxError, after which Next should not be called again.
+func (s *Scanner) Next() (TokenKind, []byte, error) {
+	d := s.d
+	c, err := d.nextToken()
+	if err != nil {
+		if err == io.EOF && len(d.tokenStack) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	var kind TokenKind
+	switch c {
+	case '{':
+		kind = ObjectStartToken
+	case '}':
+		kind = ObjectEndToken
+	case '[':
+		kind = ArrayStartToken
+	case ']':
+		kind = ArrayEndToken
+	}
+	if kind != 0 {
+		s.offset = d.offset - 1
+		s.delim[0] = c
+		return kind, s.delim[:], nil
+	}
+
+	c, _ = d.readByte()
+	d.capturing = true
+	d.raw = append(d.raw[:0], c)
+	if d.tokenKeyNext() {
+		kind = KeyToken
+		_, _, err = d.readObjectKeyBytes(c)
+		d.tokenState = tokenObjectColon
+	} else {
+		switch c {
+		case '"', '\'':
+			kind = StringToken
+		case 't', 'f':
+			kind = BoolToken
+		case 'n':
+			kind = NullToken
+		default:
+			kind = NumberToken
+		}
+		if err = d.skipValue(c); err == nil {
+			d.capturing = false
+			err = d.valueEnd()
+		}
+	}
+	d.capturing = false
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	s.offset = d.offset - int64(len(d.raw))
+	return kind, d.raw, nil
+}
+
+// Offset returns the offset in the input of the first byte of the last token
+// returned by Next.
+func (s *Scanner) Offset() int64 {
+	return s.offset
+}
@@ -0,0 +1,159 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scannedToken struct {
+	kind   TokenKind
+	text   string
+	offset int64
+}
+
+func scanAll(s *Scanner) ([]scannedToken, error) {
+	var tokens []scannedToken
+	for {
+		kind, b, err := s.Next()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, scannedToken{kind, string(b), s.Offset()})
+	}
+}
+
+func TestScanner(t *testing.T) {
+	input := `{"a\"b": [1, -2.5e3, "x\ny", true, false, null], "c": {}} []`
+	expected := []scannedToken{
+		{ObjectStartToken, "{", 0},
+		{KeyToken, `"a\"b"`, 1},
+		{ArrayStartToken, "[", 9},
+		{NumberToken, "1", 10},
+		{NumberToken, "-2.5e3", 13},
+		{StringToken, `"x\ny"`, 21},
+		{BoolToken, "true", 29},
+		{BoolToken, "false", 35},
+		{NullToken, "null", 42},
+		{ArrayEndToken, "]", 46},
+		{KeyToken, `"c"`, 49},
+		{ObjectStartToken, "{", 54},
+		{ObjectEndToken, "}", 55},
+		{ObjectEndToken, "}", 56},
+		{ArrayStartToken, "[", 58},
+		{ArrayEndToken, "]", 59},
+	}
+	for name, r := range map[string]io.Reader{
+		"buffered": strings.NewReader(input),
+		"one byte": iotest.OneByteReader(strings.NewReader(input)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tokens, err := scanAll(NewScanner(r))
+			require.NoError(t, err)
+			assert.Equal(t, expected, tokens)
+		})
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"bad value":          {`[1, ~]`, "invalid character '~' looking for beginning of value"},
+		"missing comma":      {`[1 2]`, "invalid character '2' after array element"},
+		"missing colon":      {`{"a" 1}`, "invalid character '1' after object key"},
+		"unquoted key":       {`{"a": 1, b: 2}`, "invalid character 'b' looking for beginning of object key string"},
+		"mismatched":         {`[1}`, "invalid character '}' after array element"},
+		"bad number":         {`[1.]`, "invalid character ']' after decimal point in numeric literal"},
+		"bad literal":        {`nul`, "unexpected EOF"},
+		"unterminated array": {`[1,`, "unexpected EOF"},
+		"unterminated key":   {`{"a`, "unexpected EOF"},
+		"unterminated value": {`{"a":`, "unexpected EOF"},
+		"unterminated str":   {`"a`, "unexpected EOF"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := scanAll(NewScanner(strings.NewReader(tt.input)))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestScannerDecodeTests(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			tokens, err := scanAll(NewScanner(bytes.NewReader(input)))
+
+			// encoding/json splits the input into the same tokens
+			dec := json.NewDecoder(bytes.NewReader(input))
+			var expected []string
+			var errJ error
+			depth := 0
+			for {
+				var tok json.Token
+				if tok, errJ = dec.Token(); errJ != nil {
+					break
+				}
+				switch tok {
+				case json.Delim('['), json.Delim('{'):
+					depth++
+				case json.Delim(']'), json.Delim('}'):
+					depth--
+				}
+				if delim, ok := tok.(json.Delim); ok {
+					expected = append(expected, delim.String())
+				} else {
+					expected = append(expected, "v")
+				}
+			}
+			if errJ == io.EOF && depth == 0 {
+				// encoding/json does not report input ending within a value
+				errJ = nil
+			}
+			assert.Equal(t, errJ != nil, err != nil, "%v %v", errJ, err)
+			if err != nil {
+				return
+			}
+			var actual []string
+			for _, tok := range tokens {
+				switch tok.kind {
+				case ObjectStartToken, ObjectEndToken, ArrayStartToken, ArrayEndToken:
+					actual = append(actual, tok.text)
+				default:
+					actual = append(actual, "v")
+				}
+				assert.Equal(t, tok.text, string(input[tok.offset:tok.offset+int64(len(tok.text))]))
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	input := []byte(parallelInput(1000))
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	s := NewScanner(nil)
+	for i := 0; i < b.N; i++ {
+		s.d.Reset(bytes.NewReader(input))
+		for {
+			_, _, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package json
+
+import (
+	"reflect"
+)
+
+// sqlScanner is sql.Scanner, without importing database/sql.
+type sqlScanner interface {
+	Scan(src interface{}) error
+}
+
+var sqlScannerType = reflect.TypeOf((*sqlScanner)(nil)).Elem()
+
+// isSQLNull reports whether t is shaped like the nullable types of database/sql,
+// such as sql.NullString, sql.NullTime and sql.Null[T]. These are structs of an
+// exported value field followed by a Valid bool, whose pointers are Scanners.
+// They are decoded from null with Valid false, and from anything else into the
+// value field with Valid true. They are encoded as null if they are not Valid,
+// or as the value field if they are, and are empty to the omitempty option if
+// they are not Valid.
+func isSQLNull(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t.NumField() == 2 &&
+		t.Field(0).IsExported() &&
+		t.Field(1).Name == "Valid" &&
+		t.Field(1).Type.Kind() == reflect.Bool &&
+		reflect.PointerTo(t).Implements(sqlScannerType)
+}
+
+// readSQLNull reads the value beginning with c into the database/sql nullable
+// type that v points to.
+func (d *Decoder) readSQLNull(c byte, v reflect.Value) error {
+	if c == 'n' {
+		if err := d.readLiteral(c); err != nil {
+			return err
+		}
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		return nil
+	}
+	if err := d.readValue(c, v.Elem().Field(0).Addr()); err != nil {
+		return err
+	}
+	v.Elem().Field(1).SetBool(true)
+	return nil
+}
+
+// encodeSQLNull appends the database/sql nullable type v.
+func (e *encodeState) encodeSQLNull(v reflect.Value) error {
+	if !v.Field(1).Bool() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	return e.encode(v.Field(0))
+}
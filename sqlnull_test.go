@@ -0,0 +1,112 @@
+package json
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sqlRow struct {
+	Name    sql.NullString  `json:"name"`
+	Count   sql.NullInt64   `json:"count"`
+	Small   sql.NullInt32   `json:"small,omitempty"`
+	Tiny    sql.NullInt16   `json:"tiny,omitempty"`
+	Byte    sql.NullByte    `json:"byte,omitempty"`
+	Score   sql.NullFloat64 `json:"score,omitempty"`
+	Active  sql.NullBool    `json:"active,omitempty"`
+	Updated sql.NullTime    `json:"updated,omitempty"`
+	Generic sql.Null[int]   `json:"generic,omitempty"`
+	Ptr     *sql.NullString `json:"ptr,omitempty"`
+}
+
+func TestDecodeSQLNull(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]struct {
+		input    string
+		expected sqlRow
+	}{
+		"absent": {
+			input: `{}`,
+		},
+		"null": {
+			input: `{"name": null, "count": null, "small": null, "tiny": null, "byte": null, "score": null, "active": null, "updated": null, "generic": null, "ptr": null}`,
+		},
+		"values": {
+			input: `{"name": "a", "count": 1, "small": 2, "tiny": 3, "byte": 4, "score": 1.5, "active": false, "updated": "2024-01-02T03:04:05Z", "generic": 0, "ptr": ""}`,
+			expected: sqlRow{
+				Name:    sql.NullString{String: "a", Valid: true},
+				Count:   sql.NullInt64{Int64: 1, Valid: true},
+				Small:   sql.NullInt32{Int32: 2, Valid: true},
+				Tiny:    sql.NullInt16{Int16: 3, Valid: true},
+				Byte:    sql.NullByte{Byte: 4, Valid: true},
+				Score:   sql.NullFloat64{Float64: 1.5, Valid: true},
+				Active:  sql.NullBool{Bool: false, Valid: true},
+				Updated: sql.NullTime{Time: updated, Valid: true},
+				Generic: sql.Null[int]{V: 0, Valid: true},
+				Ptr:     &sql.NullString{Valid: true},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual sqlRow
+			require.NoError(t, Unmarshal([]byte(tt.input), &actual))
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDecodeSQLNullReplaces(t *testing.T) {
+	actual := sql.NullString{String: "a", Valid: true}
+	require.NoError(t, Unmarshal([]byte(`null`), &actual))
+	assert.Equal(t, sql.NullString{}, actual)
+
+	err := Unmarshal([]byte(`{"count": "1"}`), new(sqlRow))
+	assert.EqualError(t, err, "json: cannot unmarshal string into Go struct field sqlRow.count of type int64")
+}
+
+func TestDecodeSQLNullTimeLayout(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"updated": "2024-01-02"}`))
+	dec.SetTimeLayout("2006-01-02")
+	var actual sqlRow
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, sql.NullTime{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Valid: true}, actual.Updated)
+}
+
+func TestEncodeSQLNull(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"string":       {sql.NullString{String: "a", Valid: true}, `"a"`},
+		"invalid":      {sql.NullString{String: "a"}, `null`},
+		"time":         {sql.NullTime{Time: updated, Valid: true}, `"2024-01-02T03:04:05Z"`},
+		"generic":      {sql.Null[[]int]{V: []int{1}, Valid: true}, `[1]`},
+		"pointer":      {&sql.NullInt64{Int64: 1, Valid: true}, `1`},
+		"empty row":    {sqlRow{}, `{"name":null,"count":null}`},
+		"omitted":      {sqlRow{Small: sql.NullInt32{Int32: 1}, Ptr: &sql.NullString{}}, `{"name":null,"count":null,"ptr":null}`},
+		"values":       {sqlRow{Name: sql.NullString{Valid: true}, Count: sql.NullInt64{Valid: true}, Active: sql.NullBool{Valid: true}, Updated: sql.NullTime{Time: updated, Valid: true}}, `{"name":"","count":0,"active":false,"updated":"2024-01-02T03:04:05Z"}`},
+		"not sql null": {struct{ A, Valid bool }{true, false}, `{"A":true,"Valid":false}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+}
+
+func TestSQLNullRoundTrip(t *testing.T) {
+	input := `{"name":"a","count":null,"score":0.5,"updated":"2024-01-02T03:04:05Z","generic":7}`
+	var row sqlRow
+	require.NoError(t, Unmarshal([]byte(input), &row))
+	b, err := Marshal(row)
+	require.NoError(t, err)
+	assert.Equal(t, input, string(b))
+}
@@ -0,0 +1,133 @@
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"iter"
+)
+
+// SSEDecoder decodes the data payloads of a text/event-stream as JSON values.
+// Each event's data lines are joined as described by the Server-Sent Events
+// specification and decoded as a single JSON value. Events without any data,
+// comments and unknown fields are skipped.
+type SSEDecoder struct {
+	in    *bufio.Reader
+	event string
+	id    string
+}
+
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	return &SSEDecoder{
+		in: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next event carrying data from the stream and decodes its
+// payload into the value pointed to by v. It returns io.EOF when the stream
+// ends, an event left unterminated by the end of the stream is discarded.
+func (s *SSEDecoder) Decode(v interface{}) error {
+	var (
+		data    []byte
+		hasData bool
+		event   string
+	)
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+
+		if len(line) == 0 {
+			if !hasData {
+				event = ""
+				continue
+			}
+			s.event = event
+			return Unmarshal(data, v)
+		}
+
+		field, value := line, []byte{}
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			if len(value) > 0 && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+		switch string(field) {
+		case "":
+			// comment
+		case "data":
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+		case "event":
+			event = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				s.id = string(value)
+			}
+		}
+	}
+}
+
+// Event returns the event type of the most recently decoded event, this is
+// "message" if the event did not name a type.
+func (s *SSEDecoder) Event() string {
+	if s.event == "" {
+		return "message"
+	}
+	return s.event
+}
+
+// LastEventID returns the last event ID seen in the stream.
+func (s *SSEDecoder) LastEventID() string {
+	return s.id
+}
+
+// SSEValues returns an iterator over the payloads of the events read by s, each
+// decoded into a T. Event and LastEventID describe the event of the value being
+// yielded. Iteration ends when the stream ends, or after the first error is
+// yielded.
+func SSEValues[T any](s *SSEDecoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			var v T
+			err := s.Decode(&v)
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLine reads a line terminated by CRLF, LF or CR, the terminator is not
+// returned.
+func (s *SSEDecoder) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		c, err := s.in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '\n':
+			return line, nil
+		case '\r':
+			if c, err = s.in.ReadByte(); err == nil && c != '\n' {
+				err = s.in.UnreadByte()
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return line, nil
+		default:
+			line = append(line, c)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package json
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEDecoder(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []interface{}
+		events   []string
+		err      error
+	}{
+		"empty": {
+			input: ``,
+		},
+		"single event": {
+			input:    "data: {\"a\":1}\n\n",
+			expected: []interface{}{map[string]interface{}{"a": float64(1)}},
+			events:   []string{"message"},
+		},
+		"two events": {
+			input:    "data: 1\n\ndata: \"two\"\n\n",
+			expected: []interface{}{float64(1), "two"},
+			events:   []string{"message", "message"},
+		},
+		"multiline data": {
+			input:    "data: [1,\ndata: 2]\n\n",
+			expected: []interface{}{[]interface{}{float64(1), float64(2)}},
+			events:   []string{"message"},
+		},
+		"no space": {
+			input:    "data:true\n\n",
+			expected: []interface{}{true},
+			events:   []string{"message"},
+		},
+		"crlf": {
+			input:    "data: true\r\n\r\ndata: false\r\r",
+			expected: []interface{}{true, false},
+			events:   []string{"message", "message"},
+		},
+		"named events": {
+			input:    "event: delta\ndata: 1\n\ndata: 2\n\n",
+			expected: []interface{}{float64(1), float64(2)},
+			events:   []string{"delta", "message"},
+		},
+		"comments and ids": {
+			input:    ": keepalive\n\nid: 7\nretry: 100\ndata: null\n\n",
+			expected: []interface{}{nil},
+			events:   []string{"message"},
+		},
+		"unterminated event": {
+			input: "data: 1",
+		},
+		"empty data": {
+			input: "data:\n\n",
+			err:   &SyntaxError{msg: "unexpected end of JSON input", Offset: 0, Line: 1, Column: 0},
+		},
+		"two values": {
+			input: "data: 1 2\n\n",
+			err:   &SyntaxError{msg: "invalid character '2' after top-level value", Offset: 3, Line: 1, Column: 3, context: "1 2\n  ^"},
+		},
+		"invalid": {
+			input: "data: {\"a\"}\n\n",
+			err:   &SyntaxError{msg: "invalid character '}' after object key", Offset: 5, Line: 1, Column: 5, context: "{\"a\"}\n    ^"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewSSEDecoder(strings.NewReader(tt.input))
+			for i := range tt.expected {
+				var v interface{}
+				require.NoError(t, dec.Decode(&v))
+				assert.Equal(t, tt.expected[i], v)
+				assert.Equal(t, tt.events[i], dec.Event())
+			}
+			var v interface{}
+			err := dec.Decode(&v)
+			if tt.err == nil {
+				assert.Equal(t, io.EOF, err)
+			} else {
+				assert.Equal(t, tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSSEValues(t *testing.T) {
+	type delta struct {
+		Text string `json:"text"`
+	}
+	tests := map[string]struct {
+		input    string
+		expected []delta
+		events   []string
+		err      error
+	}{
+		"empty": {input: ``},
+		"events": {
+			input:    "event: start\ndata: {\"text\":\"a\"}\n\n: keepalive\n\ndata: {\"text\":\"b\"}\n\n",
+			expected: []delta{{Text: "a"}, {Text: "b"}},
+			events:   []string{"start", "message"},
+		},
+		"unterminated event": {
+			input:    "data: {\"text\":\"a\"}\n\ndata: {",
+			expected: []delta{{Text: "a"}},
+			events:   []string{"message"},
+		},
+		"error": {
+			input:    "data: {\"text\":\"a\"}\n\ndata: {\"text\":1}\n\ndata: {\"text\":\"c\"}\n\n",
+			expected: []delta{{Text: "a"}},
+			events:   []string{"message"},
+			err:      &UnmarshalTypeError{Value: "number", Type: reflect.TypeOf(""), Offset: 9, Struct: "delta", Field: "text"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewSSEDecoder(strings.NewReader(tt.input))
+			var (
+				values []delta
+				events []string
+				err    error
+			)
+			for v, vErr := range SSEValues[delta](dec) {
+				if vErr != nil {
+					err = vErr
+					continue
+				}
+				values = append(values, v)
+				events = append(events, dec.Event())
+			}
+			assert.Equal(t, tt.expected, values)
+			assert.Equal(t, tt.events, events)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestSSEValuesBreak(t *testing.T) {
+	dec := NewSSEDecoder(strings.NewReader("data: 1\n\ndata: 2\n\n"))
+	for v, err := range SSEValues[int](dec) {
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		break
+	}
+	var v int
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, 2, v)
+}
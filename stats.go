@@ -0,0 +1,36 @@
+package json
+
+// Stats describes the input a Decoder has read since it was made or last
+// Reset, so that services can monitor the shape of payloads and notice abusive
+// ones.
+type Stats struct {
+	// BytesRead is the number of bytes of input consumed, including
+	// whitespace.
+	BytesRead int64
+	// Values is the number of JSON values read at every depth, whether they
+	// were decoded or skipped. Object keys are not values, and values read by
+	// Token are not counted.
+	Values int64
+	// MaxDepth is the deepest nesting of objects and arrays reached.
+	MaxDepth int
+	// Strings is the number of strings made for string values and object
+	// keys, including the keys of skipped objects. Short keys seen before
+	// are usually shared rather than made again.
+	Strings int64
+}
+
+// Stats returns counters describing the input the Decoder has read.
+func (d *Decoder) Stats() Stats {
+	s := d.stats
+	s.BytesRead = d.offset
+	return s
+}
+
+// countValue counts the value whose first byte was the last read, once however
+// many times it is passed on to be read.
+func (d *Decoder) countValue() {
+	if d.offset != d.valueAt {
+		d.stats.Values++
+		d.valueAt = d.offset
+	}
+}
@@ -0,0 +1,103 @@
+package json
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statsStruct struct {
+	Name    string            `json:"name"`
+	Tags    []string          `json:"tags"`
+	Nested  map[string]int    `json:"nested"`
+	Null    Null[int]         `json:"null"`
+	SQL     sql.NullString    `json:"sql"`
+	Raw     RawMessage        `json:"raw"`
+	Quoted  int               `json:"quoted,string"`
+	Time    time.Time         `json:"time,format:2006-01-02"`
+	Bytes   []byte            `json:"bytes"`
+	Unknown map[string]string `json:"-"`
+}
+
+func TestDecoderStats(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		dest     func() interface{}
+		expected Stats
+	}{
+		"scalar": {
+			input:    ` "a" `,
+			dest:     func() interface{} { return new(string) },
+			expected: Stats{BytesRead: 4, Values: 1, Strings: 1},
+		},
+		"interface": {
+			input:    `{"a": [1, "b", {"c": null}], "d": true}`,
+			dest:     func() interface{} { return new(interface{}) },
+			expected: Stats{BytesRead: 39, Values: 7, MaxDepth: 3, Strings: 4},
+		},
+		"struct": {
+			input: `{"name": "n", "tags": ["a", "b"], "nested": {"x": 1}, "null": 2, "sql": "s", "raw": [[1]],
+				"quoted": "3", "time": "2024-01-02", "bytes": "AQ==", "unknown": {"y": [2]}}`,
+			dest: func() interface{} { return new(statsStruct) },
+			// the object, name, tags and its 2 elements, nested and its
+			// member, null, sql, raw and its 2 nested values, quoted, time,
+			// bytes, and unknown and its 2 nested values; the strings are
+			// the 12 keys, including those of the skipped object, n, a, b
+			// and s
+			expected: Stats{BytesRead: 171, Values: 18, MaxDepth: 3, Strings: 16},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			require.NoError(t, dec.Decode(tt.dest()))
+			assert.Equal(t, tt.expected, dec.Stats())
+		})
+	}
+}
+
+func TestDecoderStatsStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": 1} {"a": 2} [[[]]]`))
+	var v map[string]int
+	require.NoError(t, dec.Decode(&v))
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, Stats{BytesRead: 17, Values: 4, MaxDepth: 1, Strings: 1}, dec.Stats(), "the shared key is made once")
+
+	require.NoError(t, dec.Skip())
+	assert.Equal(t, Stats{BytesRead: 24, Values: 7, MaxDepth: 3, Strings: 1}, dec.Stats())
+
+	dec.Reset(strings.NewReader(`"x"`))
+	assert.Equal(t, Stats{}, dec.Stats())
+	var s string
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, Stats{BytesRead: 3, Values: 1, Strings: 1}, dec.Stats())
+}
+
+// statsStrings has a registered decoder in TestDecoderStatsPartial.
+type statsStrings []string
+
+func TestDecoderStatsPartial(t *testing.T) {
+	var n int
+	dec := NewDecoder(strings.NewReader(`{"a": {"b": [1, 2]}, "c": 3}`))
+	require.NoError(t, dec.DecodePointer("/a/b/1", &n))
+	assert.Equal(t, Stats{BytesRead: 28, Values: 6, MaxDepth: 3}, dec.Stats())
+
+	// strings made by a registered decoder are counted
+	dec = NewDecoder(strings.NewReader(`{"a": ["x"]}`))
+	dec.RegisterDecoder(reflect.TypeOf(statsStrings{}), func(dec *Decoder, v reflect.Value) error {
+		return dec.Decode((*[]string)(v.Addr().Interface().(*statsStrings)))
+	})
+	var a map[string]statsStrings
+	require.NoError(t, dec.Decode(&a))
+	assert.Equal(t, Stats{BytesRead: 12, Values: 3, MaxDepth: 2, Strings: 2}, dec.Stats())
+
+	dec = NewDecoder(strings.NewReader(`{"a": {"b": [1, 2]}, "c": 3}`))
+	require.NoError(t, dec.DecodeFields(map[string]interface{}{"a": new(interface{}), "a.b.0": &n}))
+	// the key b is made when the value of a is read, and when it is decoded
+	assert.Equal(t, Stats{BytesRead: 28, Values: 6, MaxDepth: 3, Strings: 2}, dec.Stats())
+}
@@ -0,0 +1,221 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// flusher is an http.Flusher.
+type flusher interface {
+	Flush()
+}
+
+// errFlusher is a writer that buffers, such as a *bufio.Writer.
+type errFlusher interface {
+	Flush() error
+}
+
+// isStream reports whether v is a channel that can be received from or an
+// iter.Seq, which Encode streams, and which has no encoder or marshaler of its
+// own.
+func (enc *Encoder) isStream(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir == 0 {
+			return false
+		}
+	case t.Kind() == reflect.Func:
+		if _, ok := seqElem(t); !ok {
+			return false
+		}
+	default:
+		return false
+	}
+	if enc.encoders[t] != nil {
+		return false
+	}
+	_, marshaler := implementation(v, marshalerType)
+	_, textMarshaler := implementation(v, textMarshalerType)
+	return !marshaler && !textMarshaler
+}
+
+// seqElem returns the element type of t if it is an iter.Seq or a function type
+// like it.
+func seqElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return nil, false
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumIn() != 1 || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return yield.In(0), true
+}
+
+// OpenArray begins a JSON array whose elements are then written one at a time
+// by EncodeElement, so that output can be interleaved with the work producing
+// it, and ends it with CloseArray. Calling OpenArray again while an array is
+// open begins a nested array as its next element. Nothing is written until the
+// first element, the end of the array or a call to Flush, and Encode may not be
+// called while an array is open.
+func (enc *Encoder) OpenArray() {
+	enc.pending = append(enc.beginElement(), '[')
+	enc.arrays = append(enc.arrays, 0)
+}
+
+// EncodeElement writes the JSON encoding of v as the next element of the array
+// opened by OpenArray. Nothing is written if v cannot be encoded, a channel or
+// an iter.Seq is streamed as a nested array as Encode describes. The output is
+// not flushed, call Flush to send it on.
+func (enc *Encoder) EncodeElement(v interface{}) error {
+	if len(enc.arrays) == 0 {
+		return errors.New("json: EncodeElement called without an open array")
+	}
+	return enc.encodeElement(reflect.ValueOf(v))
+}
+
+// CloseArray ends the array most recently opened by OpenArray, and writes a
+// newline if it was not nested.
+func (enc *Encoder) CloseArray() error {
+	if len(enc.arrays) == 0 {
+		return errors.New("json: CloseArray called without an open array")
+	}
+	n := enc.arrays[len(enc.arrays)-1]
+	enc.arrays = enc.arrays[:len(enc.arrays)-1]
+	b := enc.pending
+	enc.pending = nil
+	if n > 0 && enc.indented() {
+		b = append(b, '\n')
+		b = append(b, enc.elementPrefix()...)
+	}
+	b = append(b, ']')
+	if len(enc.arrays) == 0 {
+		b = append(b, '\n')
+	}
+	_, err := enc.w.Write(b)
+	return err
+}
+
+// Flush writes any output held back by OpenArray, and then flushes the
+// Encoder's writer if it has a Flush method, as an http.ResponseWriter or a
+// *bufio.Writer does.
+func (enc *Encoder) Flush() error {
+	if len(enc.pending) > 0 {
+		b := enc.pending
+		enc.pending = nil
+		if _, err := enc.w.Write(b); err != nil {
+			return err
+		}
+	}
+	switch w := enc.w.(type) {
+	case flusher:
+		w.Flush()
+	case errFlusher:
+		return w.Flush()
+	}
+	return nil
+}
+
+// encodeElement writes v as the next element of the innermost open array.
+func (enc *Encoder) encodeElement(v reflect.Value) error {
+	if enc.isStream(v) {
+		return enc.encodeStream(v)
+	}
+	b, err := enc.marshal(v, enc.elementPrefix())
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(append(enc.beginElement(), b...))
+	return err
+}
+
+// beginElement returns the output held back followed by what precedes the
+// next element of the innermost open array, and counts that element.
+func (enc *Encoder) beginElement() []byte {
+	b := enc.pending
+	enc.pending = nil
+	if len(enc.arrays) == 0 {
+		return b
+	}
+	n := &enc.arrays[len(enc.arrays)-1]
+	if *n > 0 {
+		b = append(b, ',')
+	}
+	*n++
+	if enc.indented() {
+		b = append(b, '\n')
+		b = append(b, enc.elementPrefix()...)
+	}
+	return b
+}
+
+// elementPrefix returns the indentation of the elements of the innermost open
+// array.
+func (enc *Encoder) elementPrefix() string {
+	return enc.indentPrefix + strings.Repeat(enc.indent, len(enc.arrays))
+}
+
+// encodeStream writes the values received from the channel v, or yielded by
+// the iter.Seq v, as an array, flushing after each element. A nil channel or
+// function is written as null. If an element cannot be encoded the array is
+// abandoned, its open arrays are as they were before but any elements already
+// written remain in the output.
+func (enc *Encoder) encodeStream(v reflect.Value) error {
+	if v.IsNil() {
+		b := append(enc.beginElement(), "null"...)
+		if len(enc.arrays) == 0 {
+			b = append(b, '\n')
+		}
+		_, err := enc.w.Write(b)
+		return err
+	}
+
+	arrays, pending := append([]int(nil), enc.arrays...), enc.pending
+	enc.OpenArray()
+	var (
+		err     error
+		written bool
+	)
+	element := func(elem reflect.Value) bool {
+		if err != nil {
+			// the iter.Seq ignored being told to stop
+			return false
+		}
+		if err = enc.encodeElement(elem); err == nil {
+			written = true
+			err = enc.Flush()
+		}
+		return err == nil
+	}
+
+	if v.Kind() == reflect.Chan {
+		for {
+			elem, ok := v.Recv()
+			if !ok || !element(elem) {
+				break
+			}
+		}
+	} else {
+		yieldType := v.Type().In(0)
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.ValueOf(element(args[0])).Convert(yieldType.Out(0))}
+		})
+		v.Call([]reflect.Value{yield})
+	}
+	if err != nil {
+		enc.arrays, enc.pending = arrays, nil
+		if !written {
+			enc.pending = pending
+		}
+		return err
+	}
+	if err = enc.CloseArray(); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
@@ -0,0 +1,214 @@
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"iter"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stringSeq func(yield func(string) bool)
+
+type namedBool bool
+
+func TestEncoderStream(t *testing.T) {
+	closed := func(values ...interface{}) chan interface{} {
+		c := make(chan interface{}, len(values))
+		for _, v := range values {
+			c <- v
+		}
+		close(c)
+		return c
+	}
+	tests := map[string]struct {
+		v        interface{}
+		expected string
+	}{
+		"chan":         {closed(1, "two", map[string]int{"three": 3}), `[1,"two",{"three":3}]` + "\n"},
+		"empty chan":   {closed(), "[]\n"},
+		"nil chan":     {(chan int)(nil), "null\n"},
+		"receive only": {(<-chan interface{})(closed(true)), "[true]\n"},
+		"seq":          {slices.Values([]int{1, 2, 3}), "[1,2,3]\n"},
+		"empty seq":    {slices.Values([]int{}), "[]\n"},
+		"nil seq":      {iter.Seq[int](nil), "null\n"},
+		"named seq":    {stringSeq(slices.Values([]string{"<a>"})), `["\u003ca\u003e"]` + "\n"},
+		"named bool": {func(yield func(int) namedBool) {
+			_ = yield(1) && yield(2)
+		}, "[1,2]\n"},
+		"nested": {slices.Values([][]int{{1}, nil}), "[[1],null]\n"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, NewEncoder(&buf).Encode(test.v))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestEncoderStreamIndent(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent(">", "  ")
+	require.NoError(t, enc.Encode(slices.Values([]interface{}{1, []int{2}})))
+	require.NoError(t, enc.Encode(slices.Values([]int{})))
+	assert.Equal(t, "[\n>  1,\n>  [\n>    2\n>  ]\n>]\n[]\n", buf.String())
+}
+
+// flushWriter sends what has been written to it each time it is flushed.
+type flushWriter struct {
+	bytes.Buffer
+	flushed chan string
+}
+
+func (w *flushWriter) Flush() {
+	w.flushed <- w.String()
+}
+
+func TestEncoderStreamFlush(t *testing.T) {
+	w := &flushWriter{flushed: make(chan string)}
+	c := make(chan int)
+	done := make(chan error)
+	go func() {
+		done <- NewEncoder(w).Encode(c)
+	}()
+	c <- 1
+	assert.Equal(t, "[1", <-w.flushed)
+	c <- 2
+	assert.Equal(t, "[1,2", <-w.flushed)
+	close(c)
+	assert.Equal(t, "[1,2]\n", <-w.flushed)
+	require.NoError(t, <-done)
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriterSize(&buf, 1024)
+	seq := func(yield func(int) bool) {
+		yield(1)
+		assert.Equal(t, "[1", buf.String())
+	}
+	require.NoError(t, NewEncoder(bw).Encode(iter.Seq[int](seq)))
+	assert.Equal(t, "[1]\n", buf.String())
+}
+
+func TestEncoderStreamError(t *testing.T) {
+	var buf bytes.Buffer
+	calls := 0
+	seq := func(yield func(float64) bool) {
+		for _, f := range []float64{1, math.NaN(), 2} {
+			calls++
+			yield(f) // ignores being told to stop
+		}
+	}
+	assert.EqualError(t, NewEncoder(&buf).Encode(seq), "json: unsupported value: NaN")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "[1", buf.String())
+
+	c := make(chan float64, 3)
+	c <- math.Inf(1)
+	c <- 1
+	buf.Reset()
+	assert.EqualError(t, NewEncoder(&buf).Encode(c), "json: unsupported value: +Inf")
+	assert.Len(t, c, 1, "the channel is not drained")
+	assert.Empty(t, buf.String())
+
+	w := &mockWriter{}
+	w.Test(t)
+	w.On("Write", []byte("[1")).Return(0, errors.New("lol")).Once()
+	assert.EqualError(t, NewEncoder(w).Encode(slices.Values([]int{1, 2})), "lol")
+	w.AssertExpectations(t)
+}
+
+func TestEncoderStreamNotStreamed(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	assert.Error(t, enc.Encode(make(chan<- int)))
+	assert.Error(t, enc.Encode(func(int) {}))
+	assert.Error(t, enc.Encode([]interface{}{make(chan int)}))
+	assert.Empty(t, buf.String())
+
+	_, err := Marshal(slices.Values([]int{1}))
+	assert.Error(t, err, "only Encode streams")
+}
+
+func TestEncoderOpenArray(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.OpenArray()
+	assert.Empty(t, buf.String(), "nothing is written until the first element")
+	require.NoError(t, enc.EncodeElement(1))
+	assert.Equal(t, "[1", buf.String())
+	enc.OpenArray()
+	enc.OpenArray()
+	require.NoError(t, enc.CloseArray())
+	require.NoError(t, enc.EncodeElement("<b>"))
+	require.NoError(t, enc.EncodeElement(slices.Values([]int{2, 3})))
+	require.NoError(t, enc.CloseArray())
+	assert.Error(t, enc.EncodeElement(math.NaN()))
+	require.NoError(t, enc.EncodeElement(map[string]int{"a": 4}))
+	assert.EqualError(t, enc.Encode(5), "json: Encode called with an array open, use EncodeElement")
+	require.NoError(t, enc.CloseArray())
+	require.NoError(t, enc.Encode(5))
+	enc.OpenArray()
+	require.NoError(t, enc.CloseArray())
+
+	expected := `[1,[[],"\u003cb\u003e",[2,3]],{"a":4}]` + "\n5\n[]\n"
+	assert.Equal(t, expected, buf.String())
+	var v []interface{}
+	require.NoError(t, Unmarshal([]byte(expected[:len(expected)-6]), &v))
+
+	assert.EqualError(t, enc.CloseArray(), "json: CloseArray called without an open array")
+	assert.EqualError(t, enc.EncodeElement(1), "json: EncodeElement called without an open array")
+}
+
+func TestEncoderOpenArrayIndent(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "\t")
+	enc.OpenArray()
+	require.NoError(t, enc.EncodeElement(map[string]int{"a": 1}))
+	enc.OpenArray()
+	require.NoError(t, enc.EncodeElement(2))
+	require.NoError(t, enc.EncodeElement(slices.Values([]int{3})))
+	require.NoError(t, enc.CloseArray())
+	enc.OpenArray()
+	require.NoError(t, enc.CloseArray())
+	require.NoError(t, enc.CloseArray())
+
+	var expected bytes.Buffer
+	require.NoError(t, json.Indent(&expected, []byte(`[{"a":1},[2,[3]],[]]`), "", "\t"))
+	assert.Equal(t, expected.String()+"\n", buf.String())
+}
+
+func TestEncoderFlush(t *testing.T) {
+	w := &flushWriter{flushed: make(chan string, 1)}
+	enc := NewEncoder(w)
+	enc.OpenArray()
+	require.NoError(t, enc.Flush())
+	assert.Equal(t, "[", <-w.flushed, "the start of the array is sent on")
+	require.NoError(t, enc.EncodeElement(1))
+	require.NoError(t, enc.EncodeElement(2))
+	assert.Empty(t, w.flushed, "elements are not flushed")
+	require.NoError(t, enc.Flush())
+	assert.Equal(t, "[1,2", <-w.flushed)
+	require.NoError(t, enc.CloseArray())
+	assert.Equal(t, "[1,2]\n", w.String())
+
+	require.NoError(t, NewEncoder(&bytes.Buffer{}).Flush())
+}
+
+func TestEncoderNestedStreamError(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.OpenArray()
+	assert.Error(t, enc.EncodeElement(slices.Values([]float64{math.Inf(-1)})))
+	require.NoError(t, enc.EncodeElement(1))
+	require.NoError(t, enc.CloseArray())
+	assert.Equal(t, "[1]\n", buf.String())
+}
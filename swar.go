@@ -0,0 +1,58 @@
+package json
+
+import (
+	"encoding/binary"
+)
+
+// The scanner looks at eight bytes at a time, as a uint64, to skip over runs of
+// bytes that need no attention. These are the classic bit tricks for finding a
+// byte in a word.
+const (
+	swarOnes  = 0x0101010101010101
+	swarHighs = 0x8080808080808080
+	swarSpace = swarOnes * ' '
+)
+
+// swarWord returns the eight bytes of b from i, which must be in range.
+func swarWord(b []byte, i int) uint64 {
+	return binary.LittleEndian.Uint64(b[i:])
+}
+
+// swarHasByte reports whether any byte of w is c.
+func swarHasByte(w uint64, c byte) bool {
+	x := w ^ swarOnes*uint64(c)
+	return (x-swarOnes)&^x&swarHighs != 0
+}
+
+// swarHasLess reports whether any byte of w is less than n, which must be at
+// most 128.
+func swarHasLess(w uint64, n byte) bool {
+	return (w-swarOnes*uint64(n))&^w&swarHighs != 0
+}
+
+// scanStringWords returns the index, from i, of the first eight bytes of
+// buf[:end] that may hold the quote q, a backslash or a control character, or
+// of the last few bytes if there are too few to make a word.
+func scanStringWords(buf []byte, i, end int, q byte) int {
+	for ; i+8 <= end; i += 8 {
+		w := swarWord(buf, i)
+		if swarHasLess(w, 0x20) || swarHasByte(w, '\\') || swarHasByte(w, q) {
+			break
+		}
+	}
+	return i
+}
+
+// skipSpaceWords consumes buffered spaces eight at a time, as an indented
+// document has many of them. The rest are left to readByte, which also counts
+// lines.
+func (d *Decoder) skipSpaceWords() {
+	end := d.readableEnd()
+	i := d.pos
+	for i+8 <= end && swarWord(d.buf, i) == swarSpace {
+		i += 8
+	}
+	if i > d.pos {
+		d.consume(i - d.pos)
+	}
+}
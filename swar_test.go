@@ -0,0 +1,80 @@
+package json
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSWAR(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+	special := []byte{0, 0x1f, 0x20, '"', '\'', '\\', 0x7f, 0x80, 0xff}
+	for i := 0; i < 100000; i++ {
+		var b [8]byte
+		for j := range b {
+			if r.Intn(4) == 0 {
+				b[j] = special[r.Intn(len(special))]
+			} else {
+				b[j] = byte(r.Intn(256))
+			}
+		}
+		w := swarWord(b[:], 0)
+		var hasQuote, hasBackslash, hasControl bool
+		for _, c := range b {
+			hasQuote = hasQuote || c == '"'
+			hasBackslash = hasBackslash || c == '\\'
+			hasControl = hasControl || c < 0x20
+		}
+		require.Equal(t, hasQuote, swarHasByte(w, '"'), "%q", b)
+		require.Equal(t, hasBackslash, swarHasByte(w, '\\'), "%q", b)
+		require.Equal(t, hasControl, swarHasLess(w, 0x20), "%q", b)
+	}
+}
+
+func TestDecodeStringWords(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		for _, special := range []string{`\"`, `\\`, `\n`, `é`, "é", "\t"} {
+			input := `"` + strings.Repeat("a", n) + special + strings.Repeat("b", 20-n) + `"`
+			var expected, actual interface{}
+			errJ := json.Unmarshal([]byte(input), &expected)
+			err := Unmarshal([]byte(input), &actual)
+			if errJ != nil {
+				eqaulError(t, errJ, err)
+				continue
+			}
+			require.NoError(t, err, input)
+			assert.Equal(t, expected, actual, input)
+		}
+	}
+}
+
+func TestDecodeSpaceWords(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		space := strings.Repeat(" ", n)
+		input := "{" + space + `"a"` + space + ":\n" + space + "[1," + space + "x]}"
+		var v interface{}
+		dec := NewDecoder(strings.NewReader(input))
+		dec.ReportPosition()
+		err := dec.Decode(&v)
+		require.IsType(t, &SyntaxError{}, err)
+		synErr := err.(*SyntaxError)
+		assert.Equal(t, int64(strings.IndexByte(input, 'x')+1), synErr.Offset, n)
+		assert.Equal(t, int64(2), synErr.Line, n)
+		assert.Equal(t, int64(2*n+4), synErr.Column, n)
+	}
+}
+
+func BenchmarkDecodeLongString(b *testing.B) {
+	input := []byte(`"` + strings.Repeat("The quick brown fox jumps over the lazy dog. ", 1000) + `"`)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		var s string
+		if err := Unmarshal(input, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
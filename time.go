@@ -0,0 +1,110 @@
+package json
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// durationUnits is the format option value that encodes a time.Duration as a
+// string such as "1h30m0s" rather than as a number of nanoseconds.
+const durationUnits = "units"
+
+// SetTimeLayout sets the layout, as used by time.Parse, that the Decoder parses
+// time.Time values with. A field's format tag option takes precedence, as in
+// `json:"day,format:2006-01-02"`. It must be the last option, as its layout
+// runs to the end of the tag and may contain commas. The empty string, the
+// default, means RFC 3339 as time.Time.UnmarshalJSON uses.
+//
+// A time.Duration is decoded from a number of nanoseconds or a string as
+// time.ParseDuration accepts.
+func (d *Decoder) SetTimeLayout(layout string) {
+	d.timeLayout = layout
+}
+
+// SetTimeLayout sets the layout, as used by time.Time.Format, that the Encoder
+// formats time.Time values with. A field's format tag option takes precedence.
+// The empty string, the default, means RFC 3339 with nanoseconds as
+// time.Time.MarshalJSON uses.
+//
+// A time.Duration is encoded as a number of nanoseconds, or as a string such as
+// "1h30m0s" if its field has the option format:units.
+func (enc *Encoder) SetTimeLayout(layout string) {
+	enc.timeLayout = layout
+}
+
+// readTime reads the value beginning with c into the pointer v to a time.Time,
+// or a pointer to one, parsing a string with layout. Other values are read as
+// readValue reads them.
+func (d *Decoder) readTime(c byte, v reflect.Value, layout string) error {
+	var err error
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	if c != '"' && (c != '\'' || !d.json5) {
+		return d.readValue(c, v)
+	}
+	for v.Elem().Kind() == reflect.Ptr {
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(v.Elem().Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	buf, err := d.readStringBytes(c)
+	if err != nil {
+		return err
+	}
+	t, err := time.Parse(layout, string(buf))
+	if err != nil {
+		return d.unmarshalTypeError("string "+strconv.Quote(string(buf)), v.Elem().Type())
+	}
+	v.Elem().Set(reflect.ValueOf(t))
+	return nil
+}
+
+// isTimePtr reports whether t is a time.Time or a pointer to one, through any
+// number of pointers.
+func isTimePtr(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType
+}
+
+// encodeTime appends the time.Time or time.Duration v, or a pointer to one,
+// formatted as a field's format option asks, or as the Encoder's time layout.
+// It reports whether v was one of those types, a nil pointer is not.
+func (e *encodeState) encodeTime(v reflect.Value, format string) bool {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return false
+	}
+	switch v.Type() {
+	case timeType:
+		if format == "" {
+			format = e.timeLayout
+		}
+		if format == "" {
+			return false
+		}
+		e.buf = appendString(e.buf, v.Interface().(time.Time).Format(format), e.escapeHTML, e.escapeSolidus)
+	case durationType:
+		if format != durationUnits {
+			return false
+		}
+		e.buf = appendString(e.buf, v.Interface().(time.Duration).String(), false, false)
+	default:
+		return false
+	}
+	return true
+}
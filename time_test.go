@@ -0,0 +1,155 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timeFields struct {
+	Default  time.Time
+	Day      time.Time      `json:"day,format:2006-01-02"`
+	DayPtr   *time.Time     `json:",omitempty,format:2006-01-02"`
+	Dur      time.Duration  `json:"dur"`
+	DurUnits time.Duration  `json:"durUnits,format:units"`
+	DurPtr   *time.Duration `json:",format:units"`
+}
+
+func TestDecodeTime(t *testing.T) {
+	day := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	dur := 90 * time.Minute
+
+	tests := map[string]struct {
+		input    string
+		expected timeFields
+		err      string
+	}{
+		"default layout": {
+			input:    `{"Default": "2024-02-29T12:30:00Z"}`,
+			expected: timeFields{Default: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC)},
+		},
+		"format": {
+			input:    `{"day": "2024-02-29", "DayPtr": "2024-02-29"}`,
+			expected: timeFields{Day: day, DayPtr: &day},
+		},
+		"format null": {
+			input:    `{"DayPtr": null}`,
+			expected: timeFields{},
+		},
+		"bad format": {
+			input: `{"day": "2024-02-29T12:30:00Z"}`,
+			err:   `json: cannot unmarshal string "2024-02-29T12:30:00Z" into Go struct field timeFields.day of type time.Time`,
+		},
+		"bad format pointer": {
+			input: `{"DayPtr": "29/02/2024"}`,
+			err:   `json: cannot unmarshal string "29/02/2024" into Go struct field timeFields.DayPtr of type time.Time`,
+		},
+		"format number": {
+			input: `{"day": 1}`,
+			err:   "Time.UnmarshalJSON: input is not a JSON string",
+		},
+		"duration nanoseconds": {
+			input:    `{"dur": 5400000000000, "durUnits": 5400000000000}`,
+			expected: timeFields{Dur: dur, DurUnits: dur},
+		},
+		"duration string": {
+			input:    `{"dur": "1h30m", "durUnits": "90m", "DurPtr": "1.5h"}`,
+			expected: timeFields{Dur: dur, DurUnits: dur, DurPtr: &dur},
+		},
+		"bad duration": {
+			input: `{"dur": "90 minutes"}`,
+			err:   `json: cannot unmarshal string "90 minutes" into Go struct field timeFields.dur of type time.Duration`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual timeFields
+			err := Unmarshal([]byte(test.input), &actual)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDecodeSetTimeLayout(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"Default": "29/02/2024", "day": "2024-03-01"} ["01/03/2024", null]`))
+	dec.SetTimeLayout("02/01/2006")
+
+	var actual timeFields
+	require.NoError(t, dec.Decode(&actual))
+	assert.Equal(t, timeFields{
+		Default: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Day:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, actual)
+
+	var times []*time.Time
+	require.NoError(t, dec.Decode(&times))
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []*time.Time{&day, nil}, times)
+
+	dec = NewDecoder(strings.NewReader(`["01/03/2024", "2024-03-01"]`))
+	dec.SetTimeLayout("02/01/2006")
+	err := dec.Decode(&times)
+	var typeErr *UnmarshalTypeError
+	require.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, `string "2024-03-01"`, typeErr.Value)
+	assert.Equal(t, timeType, typeErr.Type)
+	assert.Equal(t, int64(27), typeErr.Offset)
+	assert.Empty(t, typeErr.Field)
+}
+
+func TestEncodeTime(t *testing.T) {
+	day := time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC)
+	dur := 90 * time.Minute
+	v := timeFields{
+		Default:  day,
+		Day:      day,
+		DayPtr:   &day,
+		Dur:      dur,
+		DurUnits: dur,
+		DurPtr:   &dur,
+	}
+
+	b, err := Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Default":"2024-02-29T12:30:00Z","day":"2024-02-29","DayPtr":"2024-02-29",`+
+		`"dur":5400000000000,"durUnits":"1h30m0s","DurPtr":"1h30m0s"}`, string(b))
+
+	b, err = Marshal(timeFields{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Default":"0001-01-01T00:00:00Z","day":"0001-01-01","dur":0,"durUnits":"0s","DurPtr":null}`, string(b))
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTimeLayout(time.Kitchen)
+	require.NoError(t, enc.Encode(v))
+	require.NoError(t, enc.Encode(map[string]interface{}{"t": day, "d": dur}))
+	assert.Equal(t, `{"Default":"12:30PM","day":"2024-02-29","DayPtr":"2024-02-29",`+
+		`"dur":5400000000000,"durUnits":"1h30m0s","DurPtr":"1h30m0s"}`+"\n"+
+		`{"d":5400000000000,"t":"12:30PM"}`+"\n", buf.String())
+
+	var roundTrip timeFields
+	require.NoError(t, Unmarshal(b, &roundTrip))
+	assert.Equal(t, timeFields{}, roundTrip)
+}
+
+func TestTimeFormatComma(t *testing.T) {
+	var v struct {
+		Day time.Time `json:"day,omitempty,format:2,Jan,2006"`
+	}
+	require.NoError(t, Unmarshal([]byte(`{"day": "29,Feb,2024"}`), &v))
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), v.Day)
+
+	b, err := Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"day":"29,Feb,2024"}`, string(b))
+}
@@ -0,0 +1,55 @@
+package json
+
+import (
+	"time"
+)
+
+// Timings holds the number of values of each kind that a Decoder has decoded,
+// and the time it spent decoding them, as recorded by RecordTimings.
+type Timings struct {
+	Object Timing
+	Array  Timing
+	String Timing
+	Number Timing
+	Bool   Timing
+	Null   Timing
+}
+
+// Timing is the number of values of one kind decoded and the total time taken.
+// The time of an object or array includes that of its members or elements.
+type Timing struct {
+	Count    int64
+	Duration time.Duration
+}
+
+// RecordTimings causes the Decoder to add the count and duration of each value
+// it decodes to t, by its kind, to find where decoding a document spends its
+// time. Values that are skipped, such as unknown object keys, are not counted,
+// and those decoded by an Unmarshaler or a registered decoder are counted as a
+// whole. Reading the clock for every value slows decoding, so this is for
+// profiling. A nil t stops recording.
+func (d *Decoder) RecordTimings(t *Timings) {
+	d.timings = t
+}
+
+// recordTiming adds the value beginning with c, which began at start, to the
+// Decoder's timings.
+func (d *Decoder) recordTiming(c byte, start time.Time) {
+	var t *Timing
+	switch c {
+	case '{':
+		t = &d.timings.Object
+	case '[':
+		t = &d.timings.Array
+	case '"', '\'':
+		t = &d.timings.String
+	case 't', 'f':
+		t = &d.timings.Bool
+	case 'n':
+		t = &d.timings.Null
+	default:
+		t = &d.timings.Number
+	}
+	t.Count++
+	t.Duration += time.Since(start)
+}
@@ -0,0 +1,53 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brackendawson/json/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoderRecordTimings(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, "s", true, null, {"b": 2.5}], "c": "d", "unknown": [1, 2]} "next"`))
+	var timings Timings
+	dec.RecordTimings(&timings)
+	var v struct {
+		A []interface{} `json:"a"`
+		C RawMessage    `json:"c"`
+	}
+	require.NoError(t, dec.Decode(&v))
+
+	counts := map[string]int64{
+		"object": timings.Object.Count,
+		"array":  timings.Array.Count,
+		"string": timings.String.Count,
+		"number": timings.Number.Count,
+		"bool":   timings.Bool.Count,
+		"null":   timings.Null.Count,
+	}
+	assert.Equal(t, map[string]int64{"object": 2, "array": 1, "string": 2, "number": 2, "bool": 1, "null": 1}, counts)
+	assert.Greater(t, int64(timings.Object.Duration), int64(0))
+	assert.GreaterOrEqual(t, int64(timings.Object.Duration), int64(timings.Array.Duration), "the time of an object includes its members")
+
+	// top level scalars are counted, and counts accumulate
+	var s string
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, int64(3), timings.String.Count)
+
+	dec.RecordTimings(nil)
+	dec.Reset(strings.NewReader(`"again"`))
+	require.NoError(t, dec.Decode(&s))
+	assert.Equal(t, int64(3), timings.String.Count)
+}
+
+func TestDecoderRecordTimingsDeep(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(string(fixture.Generate(fixture.Deep, 0))))
+	var timings Timings
+	dec.RecordTimings(&timings)
+	var v interface{}
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, int64(fixture.MaxDepth/2), timings.Object.Count)
+	assert.Equal(t, int64(fixture.MaxDepth/2+1), timings.Array.Count)
+}
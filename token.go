@@ -0,0 +1,282 @@
+package json
+
+import (
+	"errors"
+	"io"
+)
+
+// Token holds a value of one of these types:
+//
+//	Delim, for the four JSON delimiters [ ] { }
+//	bool, for JSON booleans
+//	float64, for JSON numbers, or Number if the Decoder is set to UseRawNumber
+//	string, for JSON string literals
+//	nil, for JSON null
+type Token interface{}
+
+// Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// The position of the Decoder in the stream of tokens, so that Token and Decode
+// can be mixed.
+const (
+	tokenTopValue = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// Token returns the next JSON token in the input stream, at the end of the input
+// it returns nil, io.EOF. The commas and colons separating values are consumed
+// and checked but not returned. Object keys are returned as strings.
+//
+// Token may be mixed with calls to Decode, which decodes the next whole value.
+func (d *Decoder) Token() (Token, error) {
+	c, err := d.nextToken()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		return Delim(c), nil
+	case d.tokenKeyNext():
+		c, _ = d.readByte()
+		key, _, err := d.readObjectKey(c)
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		d.tokenState = tokenObjectColon
+		return key, nil
+	}
+	var x interface{}
+	if err := d.Decode(&x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// nextToken consumes the commas and colons before the next token and returns
+// its first byte. A delimiter is consumed, but an object key or a value is left
+// for the caller to read, tokenKeyNext reports which it is.
+func (d *Decoder) nextToken() (byte, error) {
+	for {
+		c, err := d.peek()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case '[':
+			if !d.tokenValueAllowed() {
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+			d.tokenStack = append(d.tokenStack, d.tokenState)
+			d.tokenState = tokenArrayStart
+			return c, nil
+		case ']':
+			if d.tokenState != tokenArrayStart && d.tokenState != tokenArrayComma {
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+			d.tokenState = d.tokenStack[len(d.tokenStack)-1]
+			d.tokenStack = d.tokenStack[:len(d.tokenStack)-1]
+			d.tokenValueEnd()
+			return c, nil
+		case '{':
+			if !d.tokenValueAllowed() {
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+			d.tokenStack = append(d.tokenStack, d.tokenState)
+			d.tokenState = tokenObjectStart
+			return c, nil
+		case '}':
+			if d.tokenState != tokenObjectStart && d.tokenState != tokenObjectComma {
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+			d.tokenState = d.tokenStack[len(d.tokenStack)-1]
+			d.tokenStack = d.tokenStack[:len(d.tokenStack)-1]
+			d.tokenValueEnd()
+			return c, nil
+		case ':':
+			if d.tokenState != tokenObjectColon {
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+			d.tokenState = tokenObjectValue
+		case ',':
+			switch d.tokenState {
+			case tokenArrayComma:
+				d.tokenState = tokenArrayValue
+			case tokenObjectComma:
+				d.tokenState = tokenObjectKey
+			default:
+				return d.tokenByteError(c)
+			}
+			_, _ = d.readByte()
+		default:
+			if (c == '"' || d.json5) && d.tokenKeyNext() {
+				return c, nil
+			}
+			if !d.tokenValueAllowed() {
+				return d.tokenByteError(c)
+			}
+			return c, nil
+		}
+	}
+}
+
+// tokenKeyNext reports whether an object key is the next token.
+func (d *Decoder) tokenKeyNext() bool {
+	return d.tokenState == tokenObjectStart || d.tokenState == tokenObjectKey
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed, or at the top level whether another value follows in
+// the stream.
+func (d *Decoder) More() bool {
+	c, err := d.peek()
+	return err == nil && c != ']' && c != '}'
+}
+
+// DecodeArray reads the next value, which must be an array, calling fn once for
+// each element with the Decoder positioned at the start of the element. fn
+// should read the element with Decode, Skip or Token, an element fn does not
+// read is skipped. Only the element being read is held in memory, so arrays
+// larger than memory can be processed. The first error returned by fn is
+// returned.
+func (d *Decoder) DecodeArray(fn func(dec *Decoder) error) error {
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if c != '[' {
+		if !d.tokenValueAllowed() {
+			_, err = d.tokenError(c)
+			return err
+		}
+		return d.syntaxErrorf("invalid character %q looking for beginning of array", c)
+	}
+	if _, err = d.Token(); err != nil {
+		return err
+	}
+	depth := len(d.tokenStack)
+	for d.More() {
+		// consume the comma first, so the state shows whether fn read the element
+		if err = d.tokenPrepareForDecode(); err != nil {
+			return err
+		}
+		if err = fn(d); err != nil {
+			if err == io.EOF {
+				// the input ended inside the array
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		switch {
+		case len(d.tokenStack) != depth:
+			return errors.New("json: DecodeArray function did not read a whole element")
+		case d.tokenState != tokenArrayComma:
+			if err = d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err = d.Token(); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// peek returns the next byte that readNonSpace would, without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	c, err := d.readNonSpace()
+	if err != nil {
+		return 0, err
+	}
+	return c, d.unreadByte()
+}
+
+// tokenPrepareForDecode consumes the separator before a value that Decode is
+// about to read, if Token has left one unread.
+func (d *Decoder) tokenPrepareForDecode() error {
+	switch d.tokenState {
+	case tokenArrayComma:
+		c, err := d.peek()
+		if err != nil {
+			return err
+		}
+		if c != ',' {
+			return d.syntaxErrorf("expected comma after array element")
+		}
+		_, _ = d.readByte()
+		d.tokenState = tokenArrayValue
+	case tokenObjectColon:
+		c, err := d.peek()
+		if err != nil {
+			return err
+		}
+		if c != ':' {
+			return d.syntaxErrorf("expected colon after object key")
+		}
+		_, _ = d.readByte()
+		d.tokenState = tokenObjectValue
+	}
+	return nil
+}
+
+func (d *Decoder) tokenValueAllowed() bool {
+	switch d.tokenState {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) tokenValueEnd() {
+	switch d.tokenState {
+	case tokenArrayStart, tokenArrayValue:
+		d.tokenState = tokenArrayComma
+	case tokenObjectValue:
+		d.tokenState = tokenObjectComma
+	}
+}
+
+func (d *Decoder) tokenError(c byte) (Token, error) {
+	var context string
+	switch d.tokenState {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		context = " looking for beginning of value"
+	case tokenArrayComma:
+		context = " after array element"
+	case tokenObjectKey:
+		context = " looking for beginning of object key string"
+	case tokenObjectColon:
+		context = " after object key"
+	case tokenObjectComma:
+		context = " after object key:value pair"
+	}
+	return nil, d.syntaxErrorf("invalid character %q%s", c, context)
+}
+
+// tokenByteError is tokenError for nextToken.
+func (d *Decoder) tokenByteError(c byte) (byte, error) {
+	_, err := d.tokenError(c)
+	return 0, err
+}
@@ -0,0 +1,258 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	tests := map[string]string{
+		"empty":            ``,
+		"literals":         `true false null`,
+		"numbers":          ` 1 -2.5 3e2 `,
+		"string":           `"a\nb"`,
+		"array":            `[1, "two", [true], {}]`,
+		"object":           `{"a": 1, "b": {"c": [null]}, "de": "e"}`,
+		"empty containers": `[] {} [[]] [{}]`,
+		"whitespace":       " [\n\t1 ,\r\n2 ] ",
+		"stream":           `{"a":1}{"b":2} [3]`,
+		"missing comma":    `[1 2]`,
+		"missing colon":    `{"a" 1}`,
+		"bad key":          `{1:2}`,
+		"trailing comma":   `[1,]`,
+		"leading comma":    `[,1]`,
+		"object comma":     `{"a":1,}`,
+		"double colon":     `{"a"::1}`,
+		"mismatched":       `[1}`,
+		"mismatched obj":   `{"a":1]`,
+		"close at top":     `]`,
+		"colon at top":     `:`,
+		"comma at top":     `,`,
+		"truncated array":  `[1,`,
+		"truncated key":    `{"a`,
+		"truncated value":  `{"a":tr`,
+		"invalid value":    `[1, lol]`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			decJ := json.NewDecoder(strings.NewReader(input))
+			dec := NewDecoder(strings.NewReader(input))
+			for {
+				expected, errJ := decJ.Token()
+				actual, err := dec.Token()
+				t.Logf("expected %#v %v, actual %#v %v", expected, errJ, actual, err)
+				if errJ != nil {
+					// encoding/json gives offsets from the start of values
+					// read by Decode, this package gives them from the start
+					// of the input.
+					assert.EqualError(t, err, errJ.Error())
+					return
+				}
+				require.NoError(t, err)
+				if d, ok := expected.(json.Delim); ok {
+					expected = Delim(d)
+				}
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+}
+
+func TestTokenMore(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[{"a": 1}, {"a": 2}] {"x": [], "y": {"a": 3}}`))
+
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('['), tok)
+	var got []map[string]int
+	for dec.More() {
+		var m map[string]int
+		require.NoError(t, dec.Decode(&m))
+		got = append(got, m)
+	}
+	assert.Equal(t, []map[string]int{{"a": 1}, {"a": 2}}, got)
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim(']'), tok)
+
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('{'), tok)
+	var keys []string
+	for dec.More() {
+		tok, err = dec.Token()
+		require.NoError(t, err)
+		keys = append(keys, tok.(string))
+		var v interface{}
+		require.NoError(t, dec.Decode(&v))
+	}
+	assert.Equal(t, []string{"x", "y"}, keys)
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('}'), tok)
+
+	assert.False(t, dec.More())
+	_, err = dec.Token()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTokenDecodeErrors(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens int
+	}{
+		"missing comma":    {`[1 2]`, 2},
+		"missing colon":    {`{"a" 1}`, 2},
+		"decode at key":    {`{"a":1}`, 1},
+		"decode at colon":  {`{"a":1}`, 2},
+		"decode after end": {`[]`, 2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			decJ := json.NewDecoder(strings.NewReader(tt.input))
+			dec := NewDecoder(strings.NewReader(tt.input))
+			for i := 0; i < tt.tokens; i++ {
+				_, err := decJ.Token()
+				require.NoError(t, err)
+				_, err = dec.Token()
+				require.NoError(t, err)
+			}
+			var expected, actual interface{}
+			errJ := decJ.Decode(&expected)
+			err := dec.Decode(&actual)
+			assert.Equal(t, expected, actual)
+			if errJ == nil {
+				assert.NoError(t, err)
+				return
+			}
+			eqaulError(t, errJ, err)
+		})
+	}
+}
+
+func TestTokenSkip(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, {"b": 2}], "c": 3, "d": {}} "next"`))
+
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('{'), tok)
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "a", tok)
+	require.NoError(t, dec.Skip())
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "c", tok)
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), tok)
+	assert.EqualError(t, dec.Skip(), "not at beginning of value")
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "d", tok)
+	require.NoError(t, dec.Skip())
+	tok, err = dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('}'), tok)
+
+	require.NoError(t, dec.Skip())
+	assert.Equal(t, io.EOF, dec.Skip())
+}
+
+func TestDecodeArray(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []interface{}
+		err      string
+	}{
+		"empty":       {input: `[]`},
+		"elements":    {input: ` [1, "two", {"three": [3]}] `, expected: []interface{}{float64(1), "two", map[string]interface{}{"three": []interface{}{float64(3)}}}},
+		"not array":   {input: `{"a": 1}`, err: "invalid character '{' looking for beginning of array"},
+		"no input":    {input: ``, err: "EOF"},
+		"truncated":   {input: `[1,`, expected: []interface{}{float64(1)}, err: "unexpected EOF"},
+		"unclosed":    {input: `[1`, expected: []interface{}{float64(1)}, err: "unexpected EOF"},
+		"no comma":    {input: `[1 2]`, expected: []interface{}{float64(1)}, err: "expected comma after array element"},
+		"mismatched":  {input: `[1}`, expected: []interface{}{float64(1)}, err: "invalid character '}' after array element"},
+		"bad element": {input: `[1, lol]`, expected: []interface{}{float64(1)}, err: "invalid character 'l' looking for beginning of value"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []interface{}
+			err := NewDecoder(strings.NewReader(tt.input)).DecodeArray(func(dec *Decoder) error {
+				var v interface{}
+				if err := dec.Decode(&v); err != nil {
+					return err
+				}
+				got = append(got, v)
+				return nil
+			})
+			assert.Equal(t, tt.expected, got)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestDecodeArrayNested(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"skip": [[1]], "items": [1, [2], 3, {"4": 4}, 5], "after": true}`))
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, Delim('{'), tok)
+
+	var got []float64
+	for dec.More() {
+		tok, err = dec.Token()
+		require.NoError(t, err)
+		if tok != "items" {
+			require.NoError(t, dec.Skip())
+			continue
+		}
+		i := 0
+		require.NoError(t, dec.DecodeArray(func(dec *Decoder) error {
+			i++
+			if i%2 == 0 {
+				// leave it to be skipped
+				return nil
+			}
+			var n float64
+			if err := dec.Decode(&n); err != nil {
+				return err
+			}
+			got = append(got, n)
+			return nil
+		}))
+	}
+	assert.Equal(t, []float64{1, 3, 5}, got)
+}
+
+func TestDecodeArrayPartialElement(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[[1, 2]]`))
+	err := dec.DecodeArray(func(dec *Decoder) error {
+		_, err := dec.Token()
+		return err
+	})
+	assert.EqualError(t, err, "json: DecodeArray function did not read a whole element")
+}
+
+func TestDecodeArrayError(t *testing.T) {
+	calls := 0
+	err := NewDecoder(strings.NewReader(`[1, 2]`)).DecodeArray(func(dec *Decoder) error {
+		calls++
+		return io.ErrShortWrite
+	})
+	assert.Equal(t, io.ErrShortWrite, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDelimString(t *testing.T) {
+	assert.Equal(t, "[", fmt.Sprint(Delim('[')))
+}
@@ -0,0 +1,73 @@
+package json
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterUnion makes the Decoder decode objects into the interface type t by
+// looking up the value of the object's member named key in types, and decoding
+// the object into a new value of the type found. For example, with key "type"
+// and types mapping "circle" to Circle, decoding {"type": "circle", "r": 1}
+// into a Shape sets it to a Circle. Each type must implement t, it may be a
+// pointer type. The key's value is looked up as the contents of a string, or
+// as written for any other value. A JSON null sets the interface to nil.
+//
+// The object is decoded into the concrete type as usual, so if unknown fields
+// are disallowed the type must have a field for key. Objects with no member
+// named key, or an unknown value for it, return a *UnionError. RegisterUnion
+// replaces any decoder registered for t with RegisterDecoder, registering nil
+// types removes it.
+func (d *Decoder) RegisterUnion(t reflect.Type, key string, types map[string]reflect.Type) {
+	if types == nil {
+		d.RegisterDecoder(t, nil)
+		return
+	}
+	union := make(map[string]reflect.Type, len(types))
+	for name, typ := range types {
+		union[name] = typ
+	}
+	d.RegisterDecoder(t, func(dec *Decoder, v reflect.Value) error {
+		return dec.readUnion(v, key, union)
+	})
+}
+
+// readUnion reads an object into the interface v, as a value of the type in
+// union named by its member key.
+func (d *Decoder) readUnion(v reflect.Value, key string, union map[string]reflect.Type) error {
+	kind, err := d.PeekKind()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case KindNull:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case KindObject:
+	default:
+		return d.unmarshalTypeError(kind.String(), v.Type())
+	}
+
+	value, err := d.ReadValue()
+	if err != nil {
+		return err
+	}
+	member := value.Get(key)
+	if !member.Exists() {
+		return &UnionError{Type: v.Type(), Key: key}
+	}
+	name := member.String()
+	typ, ok := union[name]
+	if !ok {
+		return &UnionError{Type: v.Type(), Key: key, Value: name}
+	}
+	if !typ.AssignableTo(v.Type()) {
+		return fmt.Errorf("json: union type %v does not implement %v", typ, v.Type())
+	}
+	p := reflect.New(typ)
+	if err = d.valueDecoder(value.Raw()).Decode(p.Interface()); err != nil {
+		return err
+	}
+	v.Set(p.Elem())
+	return nil
+}
@@ -0,0 +1,126 @@
+package json
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unionShape interface {
+	Area() float64
+}
+
+type unionCircle struct {
+	R float64
+}
+
+func (c unionCircle) Area() float64 { return math.Pi * c.R * c.R }
+
+type unionSquare struct {
+	Type string
+	Side float64
+}
+
+func (s *unionSquare) Area() float64 { return s.Side * s.Side }
+
+type unionDrawing struct {
+	Name   string
+	Main   unionShape
+	Shapes []unionShape
+}
+
+var unionShapeType = reflect.TypeOf((*unionShape)(nil)).Elem()
+
+func newUnionDecoder(input string) *Decoder {
+	d := NewDecoder(strings.NewReader(input))
+	d.RegisterUnion(unionShapeType, "type", map[string]reflect.Type{
+		"circle": reflect.TypeOf(unionCircle{}),
+		"square": reflect.TypeOf(&unionSquare{}),
+	})
+	return d
+}
+
+func TestDecoderRegisterUnion(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected unionDrawing
+		err      string
+	}{
+		"value type": {
+			input:    `{"Main": {"type": "circle", "R": 2}}`,
+			expected: unionDrawing{Main: unionCircle{R: 2}},
+		},
+		"pointer type": {
+			input:    `{"Main": {"Side": 3, "type": "square"}}`,
+			expected: unionDrawing{Main: &unionSquare{Type: "square", Side: 3}},
+		},
+		"slice": {
+			input: `{"Name": "d", "Shapes": [{"type": "circle", "R": 1}, null, {"type": "square", "Side": 1}]}`,
+			expected: unionDrawing{Name: "d", Shapes: []unionShape{
+				unionCircle{R: 1}, nil, &unionSquare{Type: "square", Side: 1},
+			}},
+		},
+		"null": {
+			input: `{"Main": null}`,
+		},
+		"missing key": {
+			input: `{"Main": {"R": 1}}`,
+			err:   `json: cannot unmarshal object with no "type" member into Go value of type json.unionShape`,
+		},
+		"unknown type": {
+			input: `{"Main": {"type": "hexagon"}}`,
+			err:   `json: cannot unmarshal object with "type" "hexagon" into Go value of type json.unionShape`,
+		},
+		"not an object": {
+			input: `{"Main": "circle"}`,
+			err:   "json: cannot unmarshal string into Go struct field unionDrawing.Main of type json.unionShape",
+		},
+		"concrete type error": {
+			input: `{"Main": {"type": "circle", "R": "big"}}`,
+			err:   "json: cannot unmarshal string into Go struct field unionCircle.Main.R of type float64",
+		},
+		"syntax error": {
+			input: `{"Main": {"type": "circle", "R": 1]}`,
+			err:   "invalid character ']' after object key:value pair",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual unionDrawing
+			err := newUnionDecoder(test.input).Decode(&actual)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDecoderRegisterUnionOptions(t *testing.T) {
+	d := newUnionDecoder(`{"type": "circle", "R": 1} {"type": "square", "Side": 2}`)
+	d.DisallowUnknownFields()
+	var s unionShape
+	assert.EqualError(t, d.Decode(&s), `json: unknown field "type"`)
+	require.NoError(t, d.Decode(&s))
+	assert.Equal(t, &unionSquare{Type: "square", Side: 2}, s)
+
+	d = newUnionDecoder(`{"type": "circle"}`)
+	d.RegisterUnion(unionShapeType, "type", nil)
+	var removed unionShape
+	assert.EqualError(t, d.Decode(&removed), "json: cannot unmarshal object into Go value of type json.unionShape")
+}
+
+func TestDecoderRegisterUnionNotImplemented(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"type": "int"}`))
+	d.RegisterUnion(unionShapeType, "type", map[string]reflect.Type{
+		"int": reflect.TypeOf(0),
+	})
+	var s unionShape
+	assert.EqualError(t, d.Decode(&s), "json: union type int does not implement json.unionShape")
+}
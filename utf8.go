@@ -0,0 +1,77 @@
+package json
+
+import (
+	"unicode/utf8"
+)
+
+// InvalidUTF8 is what a Decoder does with bytes in strings that are not valid
+// UTF-8, which JSON text must be.
+type InvalidUTF8 int
+
+const (
+	// InvalidUTF8Replace replaces each invalid byte with the replacement
+	// character U+FFFD, as encoding/json does, this is the default.
+	InvalidUTF8Replace InvalidUTF8 = iota
+	// InvalidUTF8Error fails with a *SyntaxError at the first invalid byte.
+	InvalidUTF8Error
+	// InvalidUTF8Keep passes the invalid bytes through untouched.
+	InvalidUTF8Keep
+)
+
+// SetInvalidUTF8 sets what the Decoder does with invalid UTF-8 in strings,
+// including object keys and strings that are skipped. RawMessage values are
+// always kept as they were read.
+func (d *Decoder) SetInvalidUTF8(mode InvalidUTF8) {
+	d.invalidUTF8 = mode
+}
+
+// checkUTF8 checks the string bytes buf[start:], which were read as they are
+// just before the last byte read, as the Decoder's InvalidUTF8 mode says. It
+// returns buf with any invalid bytes replaced, in a new slice so that buf is
+// never written.
+func (d *Decoder) checkUTF8(buf []byte, start int) ([]byte, error) {
+	if d.invalidUTF8 == InvalidUTF8Keep || utf8.Valid(buf[start:]) {
+		return buf, nil
+	}
+	s := buf[start:]
+	if d.invalidUTF8 == InvalidUTF8Error {
+		i := 0
+		for i < len(s) {
+			r, size := utf8.DecodeRune(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				break
+			}
+			i += size
+		}
+		return nil, d.invalidUTF8Error(len(s) - i)
+	}
+
+	out := make([]byte, start, len(buf)+2*len(s))
+	copy(out, buf)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			out = utf8.AppendRune(out, utf8.RuneError)
+		} else {
+			out = append(out, s[i:i+size]...)
+		}
+		i += size
+	}
+	return out, nil
+}
+
+// invalidUTF8Error returns a *SyntaxError for the invalid byte n bytes before
+// the last byte read. Strings hold no newlines, so it is on the same line.
+func (d *Decoder) invalidUTF8Error(n int) *SyntaxError {
+	pos, offset := d.pos, d.offset
+	d.offset -= int64(n)
+	if d.pos >= n {
+		d.pos -= n
+	} else {
+		// the byte is no longer buffered, there is no context to show
+		d.pos = 0
+	}
+	err := d.syntaxErrorf("invalid UTF-8 in string literal")
+	d.pos, d.offset = pos, offset
+	return err
+}
@@ -0,0 +1,144 @@
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeInvalidUTF8(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	tests := map[string]struct {
+		input    string
+		replaced string
+		kept     string
+		offset   int64
+		context  string
+	}{
+		"truncated 2": {
+			input:    "\"a\xc3\"",
+			replaced: "a\ufffd",
+			kept:     "a\xc3",
+			offset:   3,
+			context:  "\"a\xc3\n  ^",
+		},
+		"bad continuation": {
+			input:    "\"\xe2\x28\xa1\"",
+			replaced: "\ufffd(\ufffd",
+			kept:     "\xe2\x28\xa1",
+			offset:   2,
+			context:  "\"\xe2\n ^",
+		},
+		"surrogate": {
+			input:    "\"ok \xed\xa0\x80\"",
+			replaced: "ok \ufffd\ufffd\ufffd",
+			kept:     "ok \xed\xa0\x80",
+			offset:   5,
+			context:  "\"ok \xed\n    ^",
+		},
+		"after escape": {
+			input:    "\"\\n\xff\\t\"",
+			replaced: "\n\ufffd\t",
+			kept:     "\n\xff\t",
+			offset:   4,
+			context:  "\"\\n\xff\n   ^",
+		},
+		"before escape": {
+			input:    "\"\xff\\t\"",
+			replaced: "\ufffd\t",
+			kept:     "\xff\t",
+			offset:   2,
+			context:  "\"\xff\n ^",
+		},
+		"valid": {
+			input:    "\"h\u00e9llo \U0001F680\"",
+			replaced: "h\u00e9llo \U0001F680",
+			kept:     "h\u00e9llo \U0001F680",
+		},
+		"long": {
+			input:    "\"" + long + "\xc3\x28\"",
+			replaced: long + "\ufffd(",
+			kept:     long + "\xc3\x28",
+			offset:   5002,
+			context:  strings.Repeat("x", 32) + "\xc3\n" + strings.Repeat(" ", 32) + "^",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s, sJ string
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &sJ))
+			require.NoError(t, Unmarshal([]byte(tt.input), &s))
+			assert.Equal(t, sJ, s)
+			assert.Equal(t, tt.replaced, s)
+
+			s = ""
+			dec := NewDecoder(iotest.OneByteReader(strings.NewReader(tt.input)))
+			require.NoError(t, dec.Decode(&s))
+			assert.Equal(t, tt.replaced, s)
+
+			s = ""
+			dec = NewDecoder(strings.NewReader(tt.input))
+			dec.SetInvalidUTF8(InvalidUTF8Keep)
+			require.NoError(t, dec.Decode(&s))
+			assert.Equal(t, tt.kept, s)
+
+			for _, r := range []struct {
+				name string
+				dec  *Decoder
+			}{
+				{"buffered", NewDecoder(strings.NewReader(tt.input))},
+				{"one byte", NewDecoder(iotest.OneByteReader(strings.NewReader(tt.input)))},
+			} {
+				r.dec.SetInvalidUTF8(InvalidUTF8Error)
+				s = ""
+				err := r.dec.Decode(&s)
+				if tt.offset == 0 {
+					assert.NoError(t, err, r.name)
+					assert.Equal(t, tt.replaced, s, r.name)
+					continue
+				}
+				require.IsType(t, &SyntaxError{}, err, r.name)
+				synErr := err.(*SyntaxError)
+				assert.EqualError(t, err, "invalid UTF-8 in string literal", r.name)
+				assert.Equal(t, tt.offset, synErr.Offset, r.name)
+				assert.Equal(t, int64(1), synErr.Line, r.name)
+				assert.Equal(t, tt.offset, synErr.Column, r.name)
+				if r.name == "buffered" || tt.offset < contextWidth {
+					// less context may be kept before a byte that was not the last read
+					assert.Equal(t, tt.context, synErr.Context(), r.name)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidUTF8Keys(t *testing.T) {
+	input := "{\"k\xff\": \"v\xfe\", \"skip\": [\"\xc0\"]}"
+	var v, vJ map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(input), &vJ))
+	require.NoError(t, Unmarshal([]byte(input), &v))
+	assert.Equal(t, vJ, v)
+
+	var s struct{ Other string }
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetInvalidUTF8(InvalidUTF8Error)
+	err := dec.Decode(&s)
+	require.IsType(t, &SyntaxError{}, err)
+	assert.Equal(t, int64(4), err.(*SyntaxError).Offset)
+
+	var raw struct{ Skip RawMessage }
+	dec = NewDecoder(strings.NewReader(input))
+	dec.SetInvalidUTF8(InvalidUTF8Keep)
+	require.NoError(t, dec.Decode(&raw))
+	assert.Equal(t, RawMessage("[\"\xc0\"]"), raw.Skip)
+
+	dec = NewDecoder(strings.NewReader(input))
+	dec.SetInvalidUTF8(InvalidUTF8Keep)
+	v = nil
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, map[string]interface{}{"k\xff": "v\xfe", "skip": []interface{}{"\xc0"}}, v)
+}
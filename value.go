@@ -0,0 +1,178 @@
+package json
+
+import (
+	"strconv"
+)
+
+// Value is a JSON value kept as the bytes it was read from, the parts of it
+// that are asked for are only parsed when they are accessed. The zero Value is a
+// value that does not exist, such as the result of getting a missing key, and
+// all its accessors return zero values, so that accesses can be chained:
+//
+//	name := v.Get("items").Index(0).Get("name").String()
+type Value struct {
+	raw            []byte
+	json5          bool
+	allowComments  bool
+	allowNonFinite bool
+	invalidUTF8    InvalidUTF8
+}
+
+// ReadValue reads the next value from the input and returns it as a Value
+// without decoding it. Like Decode it may be mixed with calls to Token.
+func (d *Decoder) ReadValue() (Value, error) {
+	var raw RawMessage
+	if err := d.Decode(&raw); err != nil {
+		return Value{}, err
+	}
+	return Value{
+		raw:            raw,
+		json5:          d.json5,
+		allowComments:  d.allowComments,
+		allowNonFinite: d.allowNonFinite,
+		invalidUTF8:    d.invalidUTF8,
+	}, nil
+}
+
+// Exists reports whether the value exists, it is true for a JSON null.
+func (v Value) Exists() bool {
+	return len(v.raw) > 0
+}
+
+// Raw returns the JSON encoding of the value as it was read, or nil if it does
+// not exist.
+func (v Value) Raw() RawMessage {
+	return RawMessage(v.raw)
+}
+
+// Get returns the value of the member of the object v named key. It returns
+// the zero Value if v is not an object or has no such member. The key must
+// match exactly, if it appears more than once the first is returned.
+func (v Value) Get(key string) Value {
+	d := v.decoder()
+	c, err := d.readNonSpace()
+	if err != nil || c != '{' {
+		return Value{}
+	}
+	for {
+		if c, err = d.readNonSpace(); err != nil || c == '}' {
+			return Value{}
+		}
+		k, _, err := d.readObjectKeyBytes(c)
+		if err != nil {
+			return Value{}
+		}
+		match := string(k) == key
+		if err = d.readObjectSeparator(); err != nil {
+			return Value{}
+		}
+		if match {
+			return v.next(d)
+		}
+		if c, err = d.readByte(); err != nil {
+			return Value{}
+		}
+		if err = d.skipValue(c); err != nil {
+			return Value{}
+		}
+		if c, err = d.readNonSpace(); err != nil || c != ',' {
+			return Value{}
+		}
+	}
+}
+
+// Index returns the element i of the array v, counting from zero. It returns
+// the zero Value if v is not an array or is too short.
+func (v Value) Index(i int) Value {
+	d := v.decoder()
+	c, err := d.readNonSpace()
+	if err != nil || c != '[' || i < 0 {
+		return Value{}
+	}
+	for n := 0; ; n++ {
+		if c, err = d.readNonSpace(); err != nil || c == ']' {
+			return Value{}
+		}
+		_ = d.unreadByte()
+		if n == i {
+			return v.next(d)
+		}
+		if c, err = d.readByte(); err != nil {
+			return Value{}
+		}
+		if err = d.skipValue(c); err != nil {
+			return Value{}
+		}
+		if c, err = d.readNonSpace(); err != nil || c != ',' {
+			return Value{}
+		}
+	}
+}
+
+// String returns the contents of a JSON string. Any other value is returned as
+// it was written, without surrounding space, and the empty string if it does
+// not exist.
+func (v Value) String() string {
+	d := v.decoder()
+	c, err := d.readNonSpace()
+	if err != nil {
+		return ""
+	}
+	if c == '"' || c == '\'' && v.json5 {
+		s, err := d.readStringBytes(c)
+		if err != nil {
+			return ""
+		}
+		return string(s)
+	}
+	_ = d.unreadByte()
+	return string(v.next(d).raw)
+}
+
+// Int64 returns a JSON number as an int64, the fraction of a number that is
+// not an integer is discarded. It returns 0 for any other value, or if the
+// number does not fit in an int64.
+func (v Value) Int64() int64 {
+	d := v.decoder()
+	c, err := d.readNonSpace()
+	if err != nil || c != '-' && (c < '0' || c > '9') && (c != '+' || !v.json5) {
+		return 0
+	}
+	_ = d.unreadByte()
+	num := string(v.next(d).raw)
+	// base 0 reads JSON5 hexadecimal, JSON has no leading zeros to be octal
+	if n, err := strconv.ParseInt(num, 0, 64); err == nil {
+		return n
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < -(1<<63) || f >= 1<<63 {
+		return 0
+	}
+	return int64(f)
+}
+
+// decoder returns a Decoder of the value's bytes.
+func (v Value) decoder() *Decoder {
+	return &Decoder{
+		buf:            v.raw,
+		json5:          v.json5,
+		allowComments:  v.allowComments,
+		allowNonFinite: v.allowNonFinite,
+		invalidUTF8:    v.invalidUTF8,
+	}
+}
+
+// next returns the value that d is positioned before, which shares v's bytes.
+func (v Value) next(d *Decoder) Value {
+	c, err := d.readNonSpace()
+	if err != nil {
+		return Value{}
+	}
+	start := d.offset - 1
+	if err = d.skipValue(c); err != nil {
+		return Value{}
+	}
+	next := v
+	next.raw = v.raw[start:d.offset:d.offset]
+	return next
+}
@@ -0,0 +1,78 @@
+package json
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoderReadValue(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{
+		"name": "a\tb",
+		"count": 12,
+		"big": 1e20,
+		"ratio": -2.5,
+		"items": [{"id": 1}, {"id": 2, "tags": ["x", "y"]}, null],
+		"nested": {"ok": true},
+		"name": "second"
+	} [1]`))
+
+	v, err := dec.ReadValue()
+	require.NoError(t, err)
+	assert.True(t, v.Exists())
+	assert.Equal(t, "a\tb", v.Get("name").String())
+	assert.Equal(t, `"a\tb"`, string(v.Get("name").Raw()))
+	assert.Equal(t, int64(12), v.Get("count").Int64())
+	assert.Equal(t, "12", v.Get("count").String())
+	assert.Equal(t, int64(0), v.Get("big").Int64())
+	assert.Equal(t, int64(-2), v.Get("ratio").Int64())
+	assert.Equal(t, int64(2), v.Get("items").Index(1).Get("id").Int64())
+	assert.Equal(t, "y", v.Get("items").Index(1).Get("tags").Index(1).String())
+	assert.Equal(t, `{"ok": true}`, v.Get("nested").String())
+	assert.Equal(t, "true", v.Get("nested").Get("ok").String())
+	assert.Equal(t, "null", v.Get("items").Index(2).String())
+	assert.True(t, v.Get("items").Index(2).Exists())
+
+	for name, missing := range map[string]Value{
+		"key":           v.Get("missing"),
+		"case":          v.Get("Name"),
+		"index":         v.Get("items").Index(3),
+		"negative":      v.Get("items").Index(-1),
+		"not an object": v.Get("items").Get("id"),
+		"not an array":  v.Get("nested").Index(0),
+		"chained":       v.Get("missing").Get("a").Index(0),
+	} {
+		assert.False(t, missing.Exists(), name)
+		assert.Equal(t, "", missing.String(), name)
+		assert.Equal(t, int64(0), missing.Int64(), name)
+		assert.Nil(t, missing.Raw(), name)
+	}
+	assert.Equal(t, int64(0), v.Get("name").Int64())
+
+	v, err = dec.ReadValue()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), v.Index(0).Int64())
+	_, err = dec.ReadValue()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderReadValueJSON5(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: ['x', /* y */ 0x10, +3] // end
+	}`))
+	dec.AllowJSON5()
+	v, err := dec.ReadValue()
+	require.NoError(t, err)
+	assert.Equal(t, "x", v.Get("a").Index(0).String())
+	assert.Equal(t, int64(16), v.Get("a").Index(1).Int64())
+	assert.Equal(t, int64(3), v.Get("a").Index(2).Int64())
+	assert.False(t, v.Get("a").Index(3).Exists())
+}
+
+func TestDecoderReadValueError(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1,]}`))
+	_, err := dec.ReadValue()
+	assert.EqualError(t, err, "invalid character ']' looking for beginning of value")
+}
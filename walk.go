@@ -0,0 +1,266 @@
+package json
+
+import (
+	"errors"
+	"io"
+)
+
+// SkipValue may be returned by the OnObjectStart, OnArrayStart or OnKey methods
+// of a Visitor to skip the object, the array, or the value of the key. The
+// skipped value is still checked to be valid but no more methods are called for
+// it, not even OnEnd.
+var SkipValue = errors.New("json: skip value")
+
+// ValueKind is the kind of a JSON value passed to Visitor.OnValue.
+type ValueKind int
+
+const (
+	StringValue ValueKind = iota
+	NumberValue
+	BoolValue
+	NullValue
+)
+
+// Visitor receives the parts of a JSON value as Decoder.Walk reads them. The
+// byte slices passed to it are only valid until the method returns. If a method
+// returns an error other than SkipValue, Walk stops and returns it.
+type Visitor interface {
+	// OnObjectStart is called at the opening brace of an object.
+	OnObjectStart() error
+	// OnKey is called with the unescaped key of each member of an object,
+	// before its value.
+	OnKey(key []byte) error
+	// OnArrayStart is called at the opening bracket of an array.
+	OnArrayStart() error
+	// OnEnd is called at the closing brace or bracket of an object or array.
+	OnEnd() error
+	// OnValue is called with each string, number, boolean or null. A string is
+	// unescaped, the others are given as they are in the input.
+	OnValue(kind ValueKind, value []byte) error
+}
+
+// Walk reads the next value from the input, calling the methods of v for each
+// part of it in the order they appear. Nothing is decoded and, once the
+// Decoder's buffers have grown, nothing is allocated, so Walk can scan input
+// far larger than memory for the few parts of it that are wanted. Like Decode
+// it may be mixed with calls to Token.
+func (d *Decoder) Walk(v Visitor) error {
+	if err := d.tokenPrepareForDecode(); err != nil {
+		return err
+	}
+	if !d.tokenValueAllowed() {
+		return d.syntaxErrorf("not at beginning of value")
+	}
+	c, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	if err = d.walkValue(c, v); err != nil {
+		return err
+	}
+	return d.valueEnd()
+}
+
+// walkValue reads the value beginning with c, calling the methods of v.
+func (d *Decoder) walkValue(c byte, v Visitor) (err error) {
+	if c, err = d.skipSpace(c); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			setErrorKind(err, c)
+		}
+	}()
+
+	switch c {
+	case '{':
+		if err = v.OnObjectStart(); err == SkipValue {
+			return d.skipObject()
+		} else if err != nil {
+			return err
+		}
+		return d.walkObject(v)
+	case '[':
+		if err = v.OnArrayStart(); err == SkipValue {
+			return d.skipArray()
+		} else if err != nil {
+			return err
+		}
+		return d.walkArray(v)
+	case '\'':
+		if !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '"':
+		buf, err := d.readStringBytes(c)
+		if err != nil {
+			return err
+		}
+		return visitValue(v, StringValue, buf)
+	case 't', 'f', 'n':
+		if err = d.readLiteral(c); err != nil {
+			return err
+		}
+		kind := BoolValue
+		if c == 'n' {
+			kind = NullValue
+		}
+		d.scratch = append(append(d.scratch[:0], c), endOf[c]...)
+		return visitValue(v, kind, d.scratch)
+	case '+', 'I', 'N':
+		if !d.allowNonFinite || c == '+' && !d.json5 {
+			return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+		}
+		fallthrough
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		raw, err := d.readNumber(c)
+		if err != nil {
+			return err
+		}
+		return visitValue(v, NumberValue, raw)
+	default:
+		return d.syntaxErrorf("invalid character %q looking for beginning of value", c)
+	}
+}
+
+// visitValue calls v.OnValue, there is nothing to skip so SkipValue is
+// ignored.
+func visitValue(v Visitor, kind ValueKind, value []byte) error {
+	if err := v.OnValue(kind, value); err != nil && err != SkipValue {
+		return err
+	}
+	return nil
+}
+
+// visitEnd calls v.OnEnd, ignoring SkipValue.
+func visitEnd(v Visitor) error {
+	if err := v.OnEnd(); err != nil && err != SkipValue {
+		return err
+	}
+	return nil
+}
+
+// walkObject reads an object whose opening brace has been consumed, calling the
+// methods of v.
+func (d *Decoder) walkObject(v Visitor) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c        = byte('{')
+		err      error
+		firstKey = true
+		seen     map[string]struct{}
+	)
+	for {
+		switch c {
+		case ',', '{':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if firstKey && c == '}' {
+				return visitEnd(v)
+			}
+			firstKey = false
+
+			key, keyOffset, err := d.readObjectKeyBytes(c)
+			if err != nil {
+				return err
+			}
+			if d.disallowDuplicateKeys {
+				if _, ok := seen[string(key)]; ok {
+					return &DuplicateKeyError{
+						Key:    string(key),
+						Offset: keyOffset,
+					}
+				}
+				if seen == nil {
+					seen = map[string]struct{}{}
+				}
+				seen[string(key)] = struct{}{}
+			}
+			keyErr := v.OnKey(key)
+			if keyErr != nil && keyErr != SkipValue {
+				return keyErr
+			}
+			if err = d.readObjectSeparator(); err != nil {
+				return err
+			}
+			if c, err = d.readByte(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if keyErr == SkipValue {
+				err = d.skipValue(c)
+			} else {
+				err = d.walkValue(c, v)
+			}
+			if err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case '}':
+			return visitEnd(v)
+		default:
+			return d.syntaxErrorf("invalid character %q after object key:value pair", c)
+		}
+	}
+}
+
+// walkArray reads an array whose opening bracket has been consumed, calling the
+// methods of v.
+func (d *Decoder) walkArray(v Visitor) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	var (
+		c         = byte('[')
+		err       error
+		firstElem = true
+	)
+	for {
+		switch c {
+		case ',', '[':
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if firstElem && c == ']' {
+				return visitEnd(v)
+			}
+			firstElem = false
+			if err = d.walkValue(c, v); err != nil {
+				return err
+			}
+
+			if c, err = d.readNonSpace(); err != nil {
+				if err == io.EOF {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		case ']':
+			return visitEnd(v)
+		default:
+			return d.syntaxErrorf("invalid character %q after array element", c)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walkRecorder records the calls made to it, returning SkipValue for the key
+// or nesting level named by skip.
+type walkRecorder struct {
+	calls []string
+	skip  string
+	depth int
+	err   error
+}
+
+func (w *walkRecorder) OnObjectStart() error {
+	w.calls = append(w.calls, "{")
+	return w.start()
+}
+
+func (w *walkRecorder) OnArrayStart() error {
+	w.calls = append(w.calls, "[")
+	return w.start()
+}
+
+func (w *walkRecorder) start() error {
+	w.depth++
+	if w.skip == strings.Repeat("+", w.depth) {
+		w.depth--
+		return SkipValue
+	}
+	return w.err
+}
+
+func (w *walkRecorder) OnKey(key []byte) error {
+	w.calls = append(w.calls, "key "+string(key))
+	if w.skip == string(key) {
+		return SkipValue
+	}
+	return nil
+}
+
+func (w *walkRecorder) OnEnd() error {
+	w.depth--
+	w.calls = append(w.calls, "end")
+	return nil
+}
+
+func (w *walkRecorder) OnValue(kind ValueKind, value []byte) error {
+	w.calls = append(w.calls, []string{"string", "number", "bool", "null"}[kind]+" "+string(value))
+	return nil
+}
+
+func TestDecoderWalk(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		skip     string
+		expected []string
+	}{
+		"scalars": {
+			input:    `["a\n", -1.5e3, true, false, null]`,
+			expected: []string{"[", "string a\n", "number -1.5e3", "bool true", "bool false", "null null", "end"},
+		},
+		"object": {
+			input: `{"a": {}, "b!": [], "c": [{"d": 1}]}`,
+			expected: []string{"{", "key a", "{", "end", "key b!", "[", "end",
+				"key c", "[", "{", "key d", "number 1", "end", "end", "end"},
+		},
+		"skip key": {
+			input:    `{"a": {"x": [1]}, "b": 2}`,
+			skip:     "a",
+			expected: []string{"{", "key a", "key b", "number 2", "end"},
+		},
+		"skip nested": {
+			input:    `[[1, [2]], 3]`,
+			skip:     "++",
+			expected: []string{"[", "[", "number 3", "end"},
+		},
+		"skip top": {
+			input:    `{"a": 1}`,
+			skip:     "+",
+			expected: []string{"{"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := &walkRecorder{skip: test.skip}
+			dec := NewDecoder(strings.NewReader(test.input + " 7"))
+			require.NoError(t, dec.Walk(w))
+			assert.Equal(t, test.expected, w.calls)
+
+			var n int
+			require.NoError(t, dec.Decode(&n))
+			assert.Equal(t, 7, n)
+		})
+	}
+}
+
+func TestDecoderWalkErrors(t *testing.T) {
+	for name, input := range decodeTests {
+		t.Run(name, func(t *testing.T) {
+			expected := NewDecoder(bytes.NewReader(input)).Skip()
+			actual := NewDecoder(bytes.NewReader(input)).Walk(&walkRecorder{})
+			assert.Equal(t, expected, actual)
+		})
+	}
+
+	visitorErr := errors.New("stop")
+	w := &walkRecorder{err: visitorErr}
+	assert.Equal(t, visitorErr, NewDecoder(strings.NewReader(`[1]`)).Walk(w))
+	assert.Equal(t, []string{"["}, w.calls)
+}
+
+func TestDecoderWalkToken(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[{"a": 1}, 2]`))
+	_, err := dec.Token()
+	require.NoError(t, err)
+	w := &walkRecorder{}
+	require.NoError(t, dec.Walk(w))
+	assert.Equal(t, []string{"{", "key a", "number 1", "end"}, w.calls)
+	tok, err := dec.Token()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), tok)
+}
+
+// countVisitor counts values without allocating.
+type countVisitor int
+
+func (c *countVisitor) OnObjectStart() error                       { return nil }
+func (c *countVisitor) OnKey(key []byte) error                     { return nil }
+func (c *countVisitor) OnArrayStart() error                        { return nil }
+func (c *countVisitor) OnEnd() error                               { return nil }
+func (c *countVisitor) OnValue(kind ValueKind, value []byte) error { *c++; return nil }
+
+func TestDecoderWalkAllocs(t *testing.T) {
+	input := []byte(`{"a": [1, 2.5, "three", true, null], "b\n": {"c": "é"}}`)
+	r := bytes.NewReader(input)
+	var (
+		dec   Decoder
+		count countVisitor
+	)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(input)
+		dec.Reset(r)
+		count = 0
+		require.NoError(t, dec.Walk(&count))
+	})
+	assert.Equal(t, float64(0), allocs)
+	assert.Equal(t, countVisitor(6), count)
+}